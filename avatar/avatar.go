@@ -0,0 +1,181 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	Small  = `small`
+	Medium = `medium`
+	Large  = `large`
+)
+
+// MaxDimension bounds the width and height of uploaded images, so that a
+// small compressed file cannot expand into a huge bitmap when decoded.
+const MaxDimension = 8192
+
+// Size is a square variant generated for every avatar.
+type Size struct {
+	Name   string
+	Pixels int
+	// Quality is the JPEG quality of the variant. Smaller variants use a
+	// higher one: compression artifacts are more visible on few pixels,
+	// which make a small file anyway.
+	Quality int
+}
+
+// Sizes are the variants generated for every avatar, smallest first.
+var Sizes = []Size{
+	{Name: Small, Pixels: 64, Quality: 92},
+	{Name: Medium, Pixels: 256, Quality: 85},
+	{Name: Large, Pixels: 512, Quality: 80},
+}
+
+var (
+	ErrUnsupportedType = errors.New(`avatar: unsupported image type`)
+	ErrTooLarge        = errors.New(`avatar: image dimensions too large`)
+	ErrInvalidImage    = errors.New(`avatar: invalid image`)
+)
+
+// Variant is an encoded avatar of one of the Sizes.
+type Variant struct {
+	Name        string
+	ContentType string
+	// Extension is the file extension for ContentType, with the dot.
+	Extension string
+	Data      []byte
+}
+
+type decoder struct {
+	decode       func(data []byte) (image.Image, error)
+	decodeConfig func(data []byte) (image.Config, error)
+}
+
+// decoders are keyed by the content type sniffed from the data, which is
+// trusted over the one declared by the client.
+var decoders = map[string]decoder{
+	`image/jpeg`: {
+		decode:       func(data []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(data)) },
+		decodeConfig: func(data []byte) (image.Config, error) { return jpeg.DecodeConfig(bytes.NewReader(data)) },
+	},
+	`image/png`: {
+		decode:       func(data []byte) (image.Image, error) { return png.Decode(bytes.NewReader(data)) },
+		decodeConfig: func(data []byte) (image.Config, error) { return png.DecodeConfig(bytes.NewReader(data)) },
+	},
+	`image/webp`: {
+		decode:       func(data []byte) (image.Image, error) { return webp.Decode(bytes.NewReader(data)) },
+		decodeConfig: func(data []byte) (image.Config, error) { return webp.DecodeConfig(bytes.NewReader(data)) },
+	},
+}
+
+// Process decodes a JPEG, PNG or WebP image and returns it cropped to a
+// centered square and scaled to each of the Sizes. The variants are
+// re-encoded from the pixels, so they carry none of the original metadata
+// such as EXIF; a JPEG's EXIF orientation is applied first. Opaque images
+// are encoded as JPEG and images with transparency as PNG, WebP included,
+// since there is no WebP encoder.
+func Process(data []byte) ([]Variant, error) {
+	contentType := http.DetectContentType(data)
+	dec, ok := decoders[contentType]
+	if !ok {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, err := dec.decodeConfig(data)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension {
+		return nil, ErrTooLarge
+	}
+
+	src, err := dec.decode(data)
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	orientation := 1
+	if contentType == `image/jpeg` {
+		orientation = jpegOrientation(data)
+	}
+
+	crop := squareCrop(src.Bounds())
+	if crop.Empty() {
+		return nil, ErrInvalidImage
+	}
+
+	scaled := make([]*image.RGBA, len(Sizes))
+	for i, size := range Sizes {
+		scaled[i] = orient(scale(src, crop, size.Pixels), orientation)
+	}
+
+	// Every variant uses the same format, picked from the largest one.
+	contentType, extension := `image/jpeg`, `.jpg`
+	if !scaled[len(scaled)-1].Opaque() {
+		contentType, extension = `image/png`, `.png`
+	}
+
+	variants := make([]Variant, len(Sizes))
+	for i, size := range Sizes {
+		var buf bytes.Buffer
+		if contentType == `image/png` {
+			err = png.Encode(&buf, scaled[i])
+		} else {
+			err = jpeg.Encode(&buf, scaled[i], &jpeg.Options{Quality: size.Quality})
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		variants[i] = Variant{
+			Name:        size.Name,
+			ContentType: contentType,
+			Extension:   extension,
+			Data:        buf.Bytes(),
+		}
+	}
+
+	return variants, nil
+}
+
+// VariantKey returns the storage key of the named variant of the avatar
+// stored under key, such as "avatars/1/abc_small.jpg" for "avatars/1/abc.jpg".
+func VariantKey(key string, name string) string {
+	dot := strings.LastIndex(key, `.`)
+	if dot < 0 || strings.Contains(key[dot:], `/`) {
+		return key + `_` + name
+	}
+
+	return key[:dot] + `_` + name + key[dot:]
+}
+
+func squareCrop(bounds image.Rectangle) image.Rectangle {
+	side := bounds.Dx()
+	if bounds.Dy() < side {
+		side = bounds.Dy()
+	}
+
+	origin := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+	return image.Rectangle{Min: origin, Max: origin.Add(image.Pt(side, side))}
+}
+
+// scale never enlarges, so small uploads give variants smaller than the
+// requested size rather than blurry ones.
+func scale(src image.Image, crop image.Rectangle, pixels int) *image.RGBA {
+	if crop.Dx() < pixels {
+		pixels = crop.Dx()
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, pixels, pixels))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Src, nil)
+	return dst
+}
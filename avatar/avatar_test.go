@@ -0,0 +1,157 @@
+package avatar
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcess(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+
+	// halves returns an image with its left half red and its right half blue.
+	halves := func(width int, height int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, width, height))
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				img.SetRGBA(x, y, red)
+				if x >= width/2 {
+					img.SetRGBA(x, y, blue)
+				}
+			}
+		}
+		return img
+	}
+
+	encodeJPEG := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, img, nil); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	encodePNG := func(img image.Image) []byte {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	// withOrientation inserts a big-endian EXIF segment holding only the
+	// orientation tag right after the JPEG start of image marker.
+	withOrientation := func(data []byte, orientation byte) []byte {
+		exif := []byte("Exif\x00\x00MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00")
+		exif = append(exif, orientation, 0, 0, 0, 0, 0, 0)
+		segment := append([]byte{0xFF, 0xE1, 0, byte(len(exif) + 2)}, exif...)
+		return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+	}
+
+	decode := func(t *testing.T, variant Variant) image.Image {
+		img, _, err := image.Decode(bytes.NewReader(variant.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return img
+	}
+
+	t.Run(`JPEGVariants`, func(t *testing.T) {
+		variants, err := Process(encodeJPEG(halves(800, 600)))
+		if !assert.NoError(t, err) || !assert.Len(t, variants, len(Sizes)) {
+			return
+		}
+
+		for i, size := range Sizes {
+			assert.Equal(t, size.Name, variants[i].Name)
+			assert.Equal(t, `image/jpeg`, variants[i].ContentType)
+			assert.Equal(t, `.jpg`, variants[i].Extension)
+			assert.Equal(t, image.Rect(0, 0, size.Pixels, size.Pixels), decode(t, variants[i]).Bounds())
+		}
+	})
+
+	t.Run(`AppliesAndStripsEXIFOrientation`, func(t *testing.T) {
+		// A 90° clockwise rotation moves the red left half to the top, and a
+		// counter-clockwise one to the bottom.
+		for orientation, redOnTop := range map[byte]bool{6: true, 8: false} {
+			data := withOrientation(encodeJPEG(halves(100, 100)), orientation)
+			assert.Equal(t, int(orientation), jpegOrientation(data))
+
+			variants, err := Process(data)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			img := decode(t, variants[0])
+			r, _, b, _ := img.At(32, 8).RGBA()
+			assert.Equal(t, redOnTop, r > b, `top of orientation %d`, orientation)
+			r, _, b, _ = img.At(32, 56).RGBA()
+			assert.Equal(t, redOnTop, b > r, `bottom of orientation %d`, orientation)
+
+			for _, variant := range variants {
+				assert.False(t, bytes.Contains(variant.Data, []byte(`Exif`)))
+			}
+		}
+	})
+
+	t.Run(`TransparentPNGStaysPNGAndIsNotEnlarged`, func(t *testing.T) {
+		img := halves(300, 200)
+		img.SetRGBA(150, 100, color.RGBA{})
+
+		variants, err := Process(encodePNG(img))
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		large := variants[len(variants)-1]
+		assert.Equal(t, `image/png`, large.ContentType)
+		assert.Equal(t, `.png`, large.Extension)
+		assert.Equal(t, image.Rect(0, 0, 200, 200), decode(t, large).Bounds())
+	})
+
+	t.Run(`WebP`, func(t *testing.T) {
+		data, _ := base64.StdEncoding.DecodeString(`UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA==`)
+
+		// There is no WebP encoder, so the variants are converted.
+		variants, err := Process(data)
+		if assert.NoError(t, err) {
+			assert.Equal(t, image.Rect(0, 0, 1, 1), decode(t, variants[0]).Bounds())
+			assert.NotEqual(t, `image/webp`, variants[0].ContentType)
+		}
+	})
+
+	t.Run(`Rejected`, func(t *testing.T) {
+		var gifData bytes.Buffer
+		_ = gif.Encode(&gifData, halves(10, 10), nil)
+
+		tests := []struct {
+			name string
+			data []byte
+			want error
+		}{
+			{`GIF`, gifData.Bytes(), ErrUnsupportedType},
+			{`Text`, []byte(`<html></html>`), ErrUnsupportedType},
+			{`CorruptJPEG`, []byte("\xFF\xD8\xFF\xE0garbage"), ErrInvalidImage},
+			{`TooWide`, encodePNG(image.NewGray(image.Rect(0, 0, MaxDimension+1, 1))), ErrTooLarge},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := Process(tt.data)
+				assert.Equal(t, tt.want, err)
+			})
+		}
+	})
+}
+
+func TestVariantKey(t *testing.T) {
+	assert.Equal(t, `avatars/1/abc_small.jpg`, VariantKey(`avatars/1/abc.jpg`, Small))
+	assert.Equal(t, `avatars/1.x/abc_large`, VariantKey(`avatars/1.x/abc`, Large))
+}
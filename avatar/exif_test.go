@@ -0,0 +1,82 @@
+package avatar
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// exifJPEG returns the start of a JPEG holding an APP1 Exif segment with an
+// orientation tag in the given byte order.
+func exifJPEG(littleEndian bool, orientation byte) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00")
+	tiff = append(tiff, orientation, 0, 0, 0, 0, 0, 0)
+	if littleEndian {
+		tiff = []byte("II\x2a\x00\x08\x00\x00\x00\x01\x00\x12\x01\x03\x00\x01\x00\x00\x00")
+		tiff = append(tiff, orientation, 0, 0, 0, 0, 0, 0, 0)
+	}
+
+	exif := append([]byte("Exif\x00\x00"), tiff...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE0, 0x00, 0x04, 0x00, 0x00}
+	data = append(data, 0xFF, 0xE1, 0, byte(len(exif)+2))
+	data = append(data, exif...)
+	return append(data, 0xFF, 0xDA, 0x00, 0x02)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	for orientation := byte(1); orientation <= 8; orientation++ {
+		assert.Equal(t, int(orientation), jpegOrientation(exifJPEG(false, orientation)), `big endian %d`, orientation)
+		assert.Equal(t, int(orientation), jpegOrientation(exifJPEG(true, orientation)), `little endian %d`, orientation)
+	}
+
+	truncated := exifJPEG(false, 6)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{`NotJPEG`, []byte(`PNG`)},
+		{`NoExif`, []byte{0xFF, 0xD8, 0xFF, 0xDA, 0x00, 0x02}},
+		{`OutOfRange`, exifJPEG(false, 9)},
+		{`Truncated`, truncated[:len(truncated)-12]},
+		{`UnknownByteOrder`, append([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x10}, []byte("Exif\x00\x00XX\x00\x2a\x00\x00\x00\x08")...)},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, 1, jpegOrientation(tt.data), tt.name)
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// The source has a distinct color in each corner, as stored in the file.
+	topLeft := color.RGBA{R: 0xff, A: 0xff}
+	topRight := color.RGBA{G: 0xff, A: 0xff}
+	bottomLeft := color.RGBA{B: 0xff, A: 0xff}
+	bottomRight := color.RGBA{R: 0xff, G: 0xff, A: 0xff}
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, topLeft)
+	src.SetRGBA(1, 0, topRight)
+	src.SetRGBA(0, 1, bottomLeft)
+	src.SetRGBA(1, 1, bottomRight)
+
+	// want lists the displayed top-left, top-right, bottom-left and
+	// bottom-right corners.
+	tests := []struct {
+		orientation int
+		want        [4]color.RGBA
+	}{
+		{1, [4]color.RGBA{topLeft, topRight, bottomLeft, bottomRight}},
+		{2, [4]color.RGBA{topRight, topLeft, bottomRight, bottomLeft}},
+		{3, [4]color.RGBA{bottomRight, bottomLeft, topRight, topLeft}},
+		{4, [4]color.RGBA{bottomLeft, bottomRight, topLeft, topRight}},
+		{5, [4]color.RGBA{topLeft, bottomLeft, topRight, bottomRight}},
+		{6, [4]color.RGBA{bottomLeft, topLeft, bottomRight, topRight}},
+		{7, [4]color.RGBA{bottomRight, topRight, bottomLeft, topLeft}},
+		{8, [4]color.RGBA{topRight, bottomRight, topLeft, bottomLeft}},
+	}
+	for _, tt := range tests {
+		dst := orient(src, tt.orientation)
+		got := [4]color.RGBA{dst.RGBAAt(0, 0), dst.RGBAAt(1, 0), dst.RGBAAt(0, 1), dst.RGBAAt(1, 1)}
+		assert.Equal(t, tt.want, got, `orientation %d`, tt.orientation)
+	}
+}
@@ -0,0 +1,87 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// localStorage keeps files in a directory that the server, or a reverse
+// proxy in front of it, serves at baseURL.
+type localStorage struct {
+	dir     string
+	baseURL string
+}
+
+// NewLocalStorage returns a Storage writing under dir, which is created when
+// missing, and serving files at baseURL such as "/blobs".
+func NewLocalStorage(dir string, baseURL string) (Storage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &localStorage{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(baseURL, `/`),
+	}, nil
+}
+
+// Put writes to a temporary file first, so that readers never see a
+// partially written file.
+func (s *localStorage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), `.upload-*`)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func (s *localStorage) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (s *localStorage) URL(key string) string {
+	return s.baseURL + `/` + key
+}
+
+func (s *localStorage) path(key string) (string, error) {
+	if !validKey(key) {
+		return ``, ErrInvalidKey
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,45 @@
+package blob
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalStorage(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	storage, err := NewLocalStorage(filepath.Join(dir, `blobs`), `/blobs/`)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run(`PutReplacesAndDeletes`, func(t *testing.T) {
+		assert.NoError(t, storage.Put(ctx, `avatars/1/a.png`, []byte(`first`), `image/png`))
+		assert.NoError(t, storage.Put(ctx, `avatars/1/a.png`, []byte(`second`), `image/png`))
+
+		data, err := os.ReadFile(filepath.Join(dir, `blobs`, `avatars`, `1`, `a.png`))
+		assert.NoError(t, err)
+		assert.Equal(t, `second`, string(data))
+		assert.Equal(t, `/blobs/avatars/1/a.png`, storage.URL(`avatars/1/a.png`))
+
+		assert.NoError(t, storage.Delete(ctx, `avatars/1/a.png`))
+		assert.NoError(t, storage.Delete(ctx, `avatars/1/a.png`))
+		_, err = os.Stat(filepath.Join(dir, `blobs`, `avatars`, `1`, `a.png`))
+		assert.True(t, os.IsNotExist(err))
+
+		entries, _ := os.ReadDir(filepath.Join(dir, `blobs`, `avatars`, `1`))
+		assert.Empty(t, entries)
+	})
+
+	t.Run(`RejectsKeysOutsideDir`, func(t *testing.T) {
+		for _, key := range []string{``, `/etc/passwd`, `../a.png`, `avatars/../../a.png`, `avatars//a.png`, `avatars\a.png`} {
+			assert.Equal(t, ErrInvalidKey, storage.Put(ctx, key, []byte(`x`), ``), key)
+			assert.Equal(t, ErrInvalidKey, storage.Delete(ctx, key), key)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options locates an S3-compatible bucket. Objects are addressed
+// path-style, as Endpoint/Bucket/key, which MinIO and other compatible
+// servers support as well as AWS.
+type S3Options struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL is where the bucket's objects are served from, such as a
+	// CDN. It defaults to Endpoint/Bucket.
+	PublicURL string
+}
+
+// s3Storage talks to the S3 REST API directly, signing each request with
+// AWS Signature Version 4.
+type s3Storage struct {
+	opts   S3Options
+	client *http.Client
+	now    func() time.Time
+}
+
+// NewS3Storage returns a Storage keeping files in the bucket described by
+// opts. The objects must be readable at PublicURL for the returned URLs to
+// work, for example through a public-read bucket policy.
+func NewS3Storage(opts S3Options, client *http.Client) Storage {
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, `/`)
+	if opts.PublicURL == `` {
+		opts.PublicURL = opts.Endpoint + `/` + opts.Bucket
+	}
+	opts.PublicURL = strings.TrimSuffix(opts.PublicURL, `/`)
+
+	return &s3Storage{
+		opts:   opts,
+		client: client,
+		now:    time.Now,
+	}
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	return s.do(ctx, http.MethodPut, key, data, contentType)
+}
+
+// Delete succeeds for missing keys, since S3 answers 204 for them too.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+
+	return s.do(ctx, http.MethodDelete, key, nil, ``)
+}
+
+func (s *s3Storage) URL(key string) string {
+	return s.opts.PublicURL + `/` + escapePath(key)
+}
+
+func (s *s3Storage) do(ctx context.Context, method string, key string, data []byte, contentType string) error {
+	target := s.opts.Endpoint + `/` + escapePath(s.opts.Bucket+`/`+key)
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if contentType != `` {
+		req.Header.Set(`Content-Type`, contentType)
+	}
+
+	sum := sha256.Sum256(data)
+	signV4(req, s.opts.AccessKeyID, s.opts.SecretAccessKey, s.opts.Region, hex.EncodeToString(sum[:]), s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf(`s3 %s %s: %s %s`, method, key, res.Status, body)
+	}
+
+	return nil
+}
+
+// signV4 adds the x-amz-date, x-amz-content-sha256 and Authorization
+// headers to req, signing the host and every header already set on it.
+func signV4(req *http.Request, accessKeyID string, secretAccessKey string, region string, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(`20060102T150405Z`)
+	date := now.Format(`20060102`)
+
+	req.Header.Set(`x-amz-date`, amzDate)
+	req.Header.Set(`x-amz-content-sha256`, payloadHash)
+
+	headers := map[string]string{`host`: req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, `,`))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + `:` + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, `;`)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + `/` + region + `/s3/aws4_request`
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte(`AWS4`+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, `s3`)
+	key = hmacSHA256(key, `aws4_request`)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set(`Authorization`, fmt.Sprintf(`AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s`, accessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// escapePath percent-encodes every byte of each segment of key except the
+// unreserved characters, the way S3 expects it in the canonical request.
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, `%%%02X`, c)
+	}
+
+	return b.String()
+}
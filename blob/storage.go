@@ -0,0 +1,57 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+)
+
+// Storage keeps uploaded files under slash-separated keys such as
+// "avatars/1/photo.jpg". Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores data under key, replacing any previous value.
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the address the file at key is served from.
+	URL(key string) string
+}
+
+// ErrInvalidKey is returned for keys that are empty, absolute or that
+// contain "." or ".." segments.
+var ErrInvalidKey = errors.New(`blob: invalid key`)
+
+// NewStorage returns the storage selected by cfg.BlobStorage.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.BlobStorage {
+	case config.BlobStorageS3:
+		return NewS3Storage(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PublicURL:       cfg.S3PublicURL,
+		}, &http.Client{Timeout: 30 * time.Second}), nil
+	}
+
+	return NewLocalStorage(cfg.BlobDir, cfg.BlobBaseURL)
+}
+
+func validKey(key string) bool {
+	if key == `` || strings.HasPrefix(key, `/`) || strings.Contains(key, `\`) {
+		return false
+	}
+
+	for _, segment := range strings.Split(key, `/`) {
+		if segment == `` || segment == `.` || segment == `..` {
+			return false
+		}
+	}
+
+	return true
+}
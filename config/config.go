@@ -5,27 +5,276 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/glebarez/sqlite"
+	"github.com/sawitpro/technical_test/keyring"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 )
 
+const (
+	defaultStepUpWindow          = 5 * time.Minute
+	defaultImpersonationTTL      = 15 * time.Minute
+	defaultReplicaHealthInterval = 10 * time.Second
+	defaultCacheTTL              = time.Minute
+	defaultCacheSize             = 10000
+	defaultOutboxInterval        = time.Second
+	defaultOutboxBatchSize       = 100
+	defaultWebhookInterval       = time.Second
+	defaultWebhookMaxAttempts    = 8
+	defaultWebhookTimeout        = 10 * time.Second
+	defaultJobInterval           = time.Second
+	defaultJobConcurrency        = 4
+	defaultJobTimeout            = 5 * time.Minute
+	defaultShutdownTimeout       = 30 * time.Second
+	defaultJobRetention          = 7 * 24 * time.Hour
+	defaultAvatarMaxSize         = 5 << 20
+	defaultPhoneChangeCodeTTL    = 10 * time.Minute
+	defaultPhoneChangeCooldown   = 24 * time.Hour
+)
+
+const (
+	DriverPostgres = `postgres`
+	DriverSQLite   = `sqlite`
+	DriverMemory   = `memory`
+)
+
+const defaultSQLitePath = `app.db`
+
+const (
+	CacheMemory = `memory`
+	CacheRedis  = `redis`
+)
+
+// Phone number uniqueness modes.
+const (
+	PhoneNumberUniqueGlobal = `global`
+	PhoneNumberUniqueTenant = `tenant`
+)
+
+const (
+	BlobStorageLocal = `local`
+	BlobStorageS3    = `s3`
+)
+
+const (
+	SMSSenderLog  = `log`
+	SMSSenderHTTP = `http`
+)
+
+const (
+	defaultBlobDir     = `blobs`
+	defaultBlobBaseURL = `/blobs`
+)
+
+const (
+	OutboxSinkStdout = `stdout`
+	OutboxSinkFile   = `file`
+	OutboxSinkHTTP   = `http`
+)
+
 type Config struct {
 	DB         *gorm.DB
 	PublicKey  *rsa.PublicKey
 	PrivateKey *rsa.PrivateKey
+
+	// DBDriver selects the storage backend. DB is nil for the memory
+	// driver, which keeps all data in process and loses it on restart.
+	DBDriver string
+
+	// Replicas are read-only copies of DB that serve profile reads. They
+	// are only used with the postgres driver.
+	Replicas []*gorm.DB
+
+	// ReplicaHealthInterval is how often replicas are pinged. Zero
+	// disables the check and a replica is only taken out of rotation
+	// when a read from it fails.
+	ReplicaHealthInterval time.Duration
+
+	// StepUpWindow is how long after a password authentication a token
+	// may still be used for sensitive operations such as changing the
+	// phone number or password.
+	StepUpWindow time.Duration
+
+	// ImpersonationTTL is the lifetime of tokens issued to admins acting
+	// on behalf of another user.
+	ImpersonationTTL time.Duration
+
+	// RequireIfMatch rejects profile updates that do not send the ETag of
+	// the version they were based on.
+	RequireIfMatch bool
+
+	// CacheBackend selects where user lookups are cached: in process, in
+	// the Redis server at CacheRedisAddr, or not at all when empty.
+	CacheBackend   string
+	CacheRedisAddr string
+	// CacheSize bounds the number of entries kept by the memory backend.
+	CacheSize int
+	CacheTTL  time.Duration
+
+	// DebugVars serves expvar metrics such as the cache hit rate at
+	// /debug/vars.
+	DebugVars bool
+
+	// OutboxSink selects where domain events are published: stdout, the
+	// JSON lines file at OutboxFile, or the OutboxURL endpoint. Events are
+	// still written to the outbox but not dispatched when it is empty.
+	OutboxSink string
+	OutboxFile string
+	OutboxURL  string
+	// OutboxInterval is how often the dispatcher polls for new events.
+	OutboxInterval  time.Duration
+	OutboxBatchSize int
+
+	// Webhooks queues outbox events for the webhook subscriptions and
+	// delivers them, checking for due deliveries every WebhookInterval.
+	Webhooks        bool
+	WebhookInterval time.Duration
+	// WebhookMaxAttempts is how many times a delivery is tried before it
+	// is marked as failed.
+	WebhookMaxAttempts int
+	WebhookTimeout     time.Duration
+
+	// JobWorker runs background jobs in the API process. Turn it off when
+	// they are run by the worker subcommand instead.
+	JobWorker bool
+	// JobInterval is how often the worker polls for due jobs, and
+	// JobConcurrency how many jobs it runs at once.
+	JobInterval    time.Duration
+	JobConcurrency int
+	// JobTimeout bounds a single run of a job.
+	JobTimeout time.Duration
+	// JobRetention is how long succeeded jobs are kept. Dead jobs are kept
+	// until deleted by hand.
+	JobRetention time.Duration
+
+	// ShutdownTimeout is how long a stopping process waits for running
+	// requests and jobs before cancelling them.
+	ShutdownTimeout time.Duration
+
+	// ValidateRequests rejects requests that do not match api.yaml before
+	// they reach the handlers. ValidateResponses, which only applies along
+	// with it, also checks the JSON responses and logs those that do not
+	// match, which is meant for development.
+	ValidateRequests  bool
+	ValidateResponses bool
+
+	// APIDocs serves api.yaml at /openapi.yaml and /openapi.json, and a page
+	// to browse it at /docs.
+	APIDocs bool
+
+	// BlobStorage selects where uploaded files such as avatars are kept:
+	// under BlobDir on the local filesystem, served at BlobBaseURL, or in
+	// an S3-compatible bucket.
+	BlobStorage string
+	BlobDir     string
+	BlobBaseURL string
+	// S3Endpoint is the bucket's service URL, such as
+	// "https://s3.ap-southeast-1.amazonaws.com" or a MinIO server. Objects
+	// are addressed path-style and served from S3PublicURL, which defaults
+	// to the endpoint followed by the bucket.
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PublicURL       string
+
+	// AvatarMaxSize is the largest avatar upload accepted, in bytes.
+	AvatarMaxSize int
+
+	// PhoneNumberUniqueness decides whether a phone number may belong to
+	// one user across all organizations or to one user in each of them.
+	// It applies to users created after it is set.
+	PhoneNumberUniqueness string
+
+	// SMSSender selects how text messages such as one-time codes are
+	// sent: written to the log, or posted to the SMS gateway at SMSURL.
+	SMSSender string
+	SMSURL    string
+
+	// PhoneChangeCodeTTL is how long the code sent to confirm a new phone
+	// number is valid, and PhoneChangeCooldown how long after a phone
+	// number change another one may be requested.
+	PhoneChangeCodeTTL  time.Duration
+	PhoneChangeCooldown time.Duration
+
+	// Keyring encrypts the phone numbers of users written while it is set
+	// and finds them by blind index. It is nil when personal data is stored
+	// in plain text. The memory driver never encrypts.
+	Keyring *keyring.Keyring
 }
 
 func NewConfig() *Config {
 	privateKey, publicKey := InitKey()
 
+	driver := InitDriver()
+	var (
+		db       *gorm.DB
+		replicas []*gorm.DB
+	)
+	if driver != DriverMemory {
+		db = InitDB()
+	}
+	if driver == DriverPostgres {
+		replicas = InitReplicas()
+	}
+
 	return &Config{
-		DB:         InitDB(),
-		PublicKey:  publicKey,
-		PrivateKey: privateKey,
+		DB:                    db,
+		DBDriver:              driver,
+		Replicas:              replicas,
+		ReplicaHealthInterval: InitDuration("DB_REPLICA_HEALTH_INTERVAL", defaultReplicaHealthInterval),
+		PublicKey:             publicKey,
+		PrivateKey:            privateKey,
+		StepUpWindow:          InitDuration("STEP_UP_WINDOW", defaultStepUpWindow),
+		ImpersonationTTL:      InitDuration("IMPERSONATION_TTL", defaultImpersonationTTL),
+		RequireIfMatch:        InitBool("REQUIRE_IF_MATCH", false),
+		CacheBackend:          InitCacheBackend(),
+		CacheRedisAddr:        os.Getenv("CACHE_REDIS_ADDR"),
+		CacheSize:             InitInt("CACHE_SIZE", defaultCacheSize),
+		CacheTTL:              InitDuration("CACHE_TTL", defaultCacheTTL),
+		DebugVars:             InitBool("DEBUG_VARS", false),
+		OutboxSink:            InitOutboxSink(),
+		OutboxFile:            os.Getenv("OUTBOX_FILE"),
+		OutboxURL:             os.Getenv("OUTBOX_URL"),
+		OutboxInterval:        InitDuration("OUTBOX_INTERVAL", defaultOutboxInterval),
+		OutboxBatchSize:       InitInt("OUTBOX_BATCH_SIZE", defaultOutboxBatchSize),
+		Webhooks:              InitBool("WEBHOOKS", false),
+		WebhookInterval:       InitDuration("WEBHOOK_INTERVAL", defaultWebhookInterval),
+		WebhookMaxAttempts:    InitInt("WEBHOOK_MAX_ATTEMPTS", defaultWebhookMaxAttempts),
+		WebhookTimeout:        InitDuration("WEBHOOK_TIMEOUT", defaultWebhookTimeout),
+		JobWorker:             InitBool("JOB_WORKER", true),
+		JobInterval:           InitDuration("JOB_INTERVAL", defaultJobInterval),
+		JobConcurrency:        InitInt("JOB_CONCURRENCY", defaultJobConcurrency),
+		JobTimeout:            InitDuration("JOB_TIMEOUT", defaultJobTimeout),
+		JobRetention:          InitDuration("JOB_RETENTION", defaultJobRetention),
+		ShutdownTimeout:       InitDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout),
+		ValidateRequests:      InitBool("VALIDATE_REQUESTS", true),
+		ValidateResponses:     InitBool("VALIDATE_RESPONSES", false),
+		APIDocs:               InitBool("API_DOCS", true),
+		BlobStorage:           InitBlobStorage(),
+		BlobDir:               InitString("BLOB_DIR", defaultBlobDir),
+		BlobBaseURL:           InitString("BLOB_BASE_URL", defaultBlobBaseURL),
+		S3Endpoint:            os.Getenv("S3_ENDPOINT"),
+		S3Region:              os.Getenv("S3_REGION"),
+		S3Bucket:              os.Getenv("S3_BUCKET"),
+		S3AccessKeyID:         os.Getenv("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:     os.Getenv("S3_SECRET_ACCESS_KEY"),
+		S3PublicURL:           os.Getenv("S3_PUBLIC_URL"),
+		AvatarMaxSize:         InitInt("AVATAR_MAX_SIZE", defaultAvatarMaxSize),
+		PhoneNumberUniqueness: InitPhoneNumberUniqueness(),
+		SMSSender:             InitSMSSender(),
+		SMSURL:                os.Getenv("SMS_URL"),
+		PhoneChangeCodeTTL:    InitDuration("PHONE_CHANGE_CODE_TTL", defaultPhoneChangeCodeTTL),
+		PhoneChangeCooldown:   InitDuration("PHONE_CHANGE_COOLDOWN", defaultPhoneChangeCooldown),
+		Keyring:               InitKeyring(),
 	}
 }
 
@@ -43,38 +292,311 @@ func InitKey() (*rsa.PrivateKey, *rsa.PublicKey) {
 	return privateKey, publicKey
 }
 
+// InitKeyring loads the keyring file at KEYRING_FILE. Personal data is not
+// encrypted when it is unset.
+func InitKeyring() *keyring.Keyring {
+	path := os.Getenv("KEYRING_FILE")
+	if path == `` {
+		return nil
+	}
+
+	k, err := keyring.Load(path)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return k
+}
+
+// InitDuration reads a duration such as "5m" from the given environment
+// variable, falling back to def when it is unset.
+func InitDuration(key string, def time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == `` {
+		return def
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return d
+}
+
+// InitBool reads a boolean such as "true" or "1" from the given environment
+// variable, falling back to def when it is unset.
+func InitBool(key string, def bool) bool {
+	value := os.Getenv(key)
+	if value == `` {
+		return def
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return b
+}
+
+// InitString reads the given environment variable, falling back to def
+// when it is unset.
+func InitString(key string, def string) string {
+	value := os.Getenv(key)
+	if value == `` {
+		return def
+	}
+
+	return value
+}
+
+// InitInt reads an integer from the given environment variable, falling
+// back to def when it is unset.
+func InitInt(key string, def int) int {
+	value := os.Getenv(key)
+	if value == `` {
+		return def
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return i
+}
+
+// InitCacheBackend reads the user cache backend from CACHE_BACKEND. The
+// cache is disabled when it is unset. The redis cache is shared, so it needs
+// a keyring to encrypt the cached users.
+func InitCacheBackend() string {
+	backend := os.Getenv("CACHE_BACKEND")
+	switch backend {
+	case ``, CacheMemory:
+		return backend
+	case CacheRedis:
+		if os.Getenv("CACHE_REDIS_ADDR") == `` {
+			log.Panic(`CACHE_REDIS_ADDR is required for the redis cache`)
+		}
+		if os.Getenv("KEYRING_FILE") == `` {
+			log.Panic(`KEYRING_FILE is required for the redis cache`)
+		}
+		return backend
+	}
+
+	log.Panicf(`unknown CACHE_BACKEND %s`, backend)
+	return ``
+}
+
+// InitOutboxSink reads the domain event sink from OUTBOX_SINK. Events are
+// not dispatched when it is unset.
+func InitOutboxSink() string {
+	sink := os.Getenv("OUTBOX_SINK")
+	switch sink {
+	case ``, OutboxSinkStdout:
+		return sink
+	case OutboxSinkFile:
+		if os.Getenv("OUTBOX_FILE") == `` {
+			log.Panic(`OUTBOX_FILE is required for the file sink`)
+		}
+		return sink
+	case OutboxSinkHTTP:
+		if os.Getenv("OUTBOX_URL") == `` {
+			log.Panic(`OUTBOX_URL is required for the http sink`)
+		}
+		return sink
+	}
+
+	log.Panicf(`unknown OUTBOX_SINK %s`, sink)
+	return ``
+}
+
+// InitPhoneNumberUniqueness reads PHONE_NUMBER_UNIQUENESS, defaulting to
+// phone numbers that are unique across all organizations.
+func InitPhoneNumberUniqueness() string {
+	uniqueness := os.Getenv("PHONE_NUMBER_UNIQUENESS")
+	switch uniqueness {
+	case ``:
+		return PhoneNumberUniqueGlobal
+	case PhoneNumberUniqueGlobal, PhoneNumberUniqueTenant:
+		return uniqueness
+	}
+
+	log.Panicf(`unknown PHONE_NUMBER_UNIQUENESS %s`, uniqueness)
+	return ``
+}
+
+// InitBlobStorage reads the file storage backend from BLOB_STORAGE,
+// defaulting to the local filesystem.
+func InitBlobStorage() string {
+	storage := os.Getenv("BLOB_STORAGE")
+	switch storage {
+	case ``:
+		return BlobStorageLocal
+	case BlobStorageLocal:
+		return storage
+	case BlobStorageS3:
+		for _, key := range []string{"S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY"} {
+			if os.Getenv(key) == `` {
+				log.Panicf(`%s is required for the s3 storage`, key)
+			}
+		}
+		return storage
+	}
+
+	log.Panicf(`unknown BLOB_STORAGE %s`, storage)
+	return ``
+}
+
+// InitSMSSender reads how text messages are sent from SMS_SENDER, which
+// defaults to the log.
+func InitSMSSender() string {
+	sender := os.Getenv("SMS_SENDER")
+	switch sender {
+	case ``:
+		return SMSSenderLog
+	case SMSSenderLog:
+		return sender
+	case SMSSenderHTTP:
+		if os.Getenv("SMS_URL") == `` {
+			log.Panic(`SMS_URL is required for the http sender`)
+		}
+		return sender
+	}
+
+	log.Panicf(`unknown SMS_SENDER %s`, sender)
+	return ``
+}
+
+// InitDriver reads the storage backend from DB_DRIVER, defaulting to
+// postgres.
+func InitDriver() string {
+	driver := os.Getenv("DB_DRIVER")
+	switch driver {
+	case ``:
+		return DriverPostgres
+	case DriverPostgres, DriverSQLite, DriverMemory:
+		return driver
+	}
+
+	log.Panicf(`unknown DB_DRIVER %s`, driver)
+	return ``
+}
+
+// InitDB opens the database selected by DB_DRIVER. SQLite reads the file
+// at SQLITE_PATH, Postgres is configured by the other DB_* variables.
 func InitDB() *gorm.DB {
-	dbLogger := logger.New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags),
-		logger.Config{
-			SlowThreshold: time.Second,
-			LogLevel:      logger.Silent,
-			Colorful:      true,
-		},
-	)
+	switch InitDriver() {
+	case DriverSQLite:
+		path := os.Getenv("SQLITE_PATH")
+		if path == `` {
+			path = defaultSQLitePath
+		}
 
-	dbDsn := fmt.Sprintf(
+		db, err := OpenSQLite(path)
+		if err != nil {
+			log.Panic(err)
+		}
+		return db
+	case DriverMemory:
+		log.Panic(`the memory driver has no database`)
+	}
+
+	db, err := OpenPostgres(postgresDSN(os.Getenv("DB_HOST"), os.Getenv("DB_PORT")))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	return db
+}
+
+// InitReplicas opens the read replicas listed in DB_REPLICA_HOSTS as comma
+// separated host or host:port entries. They share the credentials and
+// database name of the primary, and the port defaults to DB_PORT.
+func InitReplicas() []*gorm.DB {
+	value := os.Getenv("DB_REPLICA_HOSTS")
+	if value == `` {
+		return nil
+	}
+
+	var replicas []*gorm.DB
+	for _, addr := range strings.Split(value, `,`) {
+		addr = strings.TrimSpace(addr)
+		if addr == `` {
+			continue
+		}
+
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			host, port = addr, os.Getenv("DB_PORT")
+		}
+
+		db, err := OpenPostgres(postgresDSN(host, port))
+		if err != nil {
+			log.Panic(err)
+		}
+		replicas = append(replicas, db)
+	}
+
+	return replicas
+}
+
+func postgresDSN(host string, port string) string {
+	return fmt.Sprintf(
 		"user=%s password=%s dbname=%s host=%s port=%s sslmode=disable TimeZone=UTC+7",
 		os.Getenv("DB_USERNAME"),
 		os.Getenv("DB_PASSWORD"),
 		os.Getenv("DB_NAME"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
+		host,
+		port,
 	)
+}
 
-	dbc, err := sql.Open("postgres", dbDsn)
+// OpenPostgres opens a gorm connection to the given lib/pq data source.
+func OpenPostgres(dsn string) (*gorm.DB, error) {
+	dbc, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	db, err := gorm.Open(postgres.New(postgres.Config{
+	return gorm.Open(postgres.New(postgres.Config{
 		Conn: dbc,
 	}), &gorm.Config{
-		Logger: dbLogger,
+		Logger: newDBLogger(),
+	})
+}
+
+// OpenSQLite opens a gorm connection to the SQLite database file at path,
+// or a private in-memory database for ":memory:".
+func OpenSQLite(path string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open(path+`?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)`), &gorm.Config{
+		Logger:         newDBLogger(),
+		TranslateError: true,
 	})
 	if err != nil {
-		log.Panic(err)
+		return nil, err
 	}
 
-	return db
+	// SQLite allows a single writer. One connection serializes writes in
+	// process instead of failing them with "database is locked", and keeps
+	// an in-memory database from being split across connections.
+	dbc, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	dbc.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func newDBLogger() logger.Interface {
+	return logger.New(
+		log.New(os.Stdout, "\r\n", log.LstdFlags),
+		logger.Config{
+			SlowThreshold: time.Second,
+			LogLevel:      logger.Silent,
+			Colorful:      true,
+		},
+	)
 }
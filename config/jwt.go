@@ -2,15 +2,21 @@ package config
 
 import (
 	"crypto/rsa"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
 )
 
+// JWTVerify admits requests with a valid access token signed by
+// rsaPublicKey, and responds 401 when the token is missing, malformed or
+// expired.
 func JWTVerify(rsaPublicKey *rsa.PublicKey) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -19,16 +25,16 @@ func JWTVerify(rsaPublicKey *rsa.PublicKey) echo.MiddlewareFunc {
 			auth := header.Get("Authorization")
 
 			if len(auth) <= 0 {
-				return echo.NewHTTPError(http.StatusForbidden, "authorization is empty")
+				return shared.HttpError(c, shared.Unauthorized("Authorization is empty"))
 			}
 
 			splitToken := strings.Split(auth, " ")
 			if len(splitToken) < 2 {
-				return echo.NewHTTPError(http.StatusForbidden, "authorization is empty")
+				return shared.HttpError(c, shared.Unauthorized("Authorization is empty"))
 			}
 
 			if splitToken[0] != "Bearer" {
-				return echo.NewHTTPError(http.StatusForbidden, "authorization is invalid")
+				return shared.HttpError(c, shared.Unauthorized("Authorization is invalid"))
 			}
 
 			tokenStr := splitToken[1]
@@ -39,18 +45,59 @@ func JWTVerify(rsaPublicKey *rsa.PublicKey) echo.MiddlewareFunc {
 				return rsaPublicKey, nil
 			})
 
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				return shared.HttpError(c, shared.Unauthorized("Access token has expired"))
+			}
 			if err != nil {
-				return echo.NewHTTPError(http.StatusForbidden, err.Error())
+				// The cause is only logged, as it tells how the token was
+				// rejected.
+				log.Printf(`JWT verify error %s`, err.Error())
+				return shared.HttpError(c, shared.Unauthorized("Access token is invalid"))
 			}
 
 			if claims, ok := token.Claims.(*entity.AccessTokenClaim); token.Valid && ok {
+				orgID := claims.OrganizationID
+				if orgID == 0 {
+					orgID = tenant.DefaultID
+				}
+				// The repository scopes its queries to the organization
+				// carried by the request context.
+				c.SetRequest(req.WithContext(tenant.WithID(req.Context(), orgID)))
+
 				c.Set("UserID", claims.UserID)
 				c.Set("PhoneNumber", claims.PhoneNumber)
+				c.Set("OrganizationID", orgID)
+				c.Set("Role", claims.Role)
+				if claims.AuthTime != nil {
+					c.Set("AuthTime", claims.AuthTime.Time)
+				}
+				if claims.Actor != nil {
+					c.Set("ActorID", claims.Actor.UserID)
+				}
 
 				return next(c)
 			}
 
-			return echo.NewHTTPError(http.StatusForbidden, "Unknown token error")
+			return shared.HttpError(c, shared.Unauthorized("Access token is invalid"))
+		}
+	}
+}
+
+// RequireAdmin must be registered after JWTVerify. It admits admins of the
+// organization the token acts in. Impersonation tokens are rejected even
+// when the impersonated user is an admin.
+func RequireAdmin() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if role, _ := c.Get("Role").(string); role != entity.RoleAdmin {
+				return shared.HttpError(c, shared.Forbidden("Admin access is required"))
+			}
+
+			if c.Get("ActorID") != nil {
+				return shared.HttpError(c, shared.Forbidden("Admin access is not allowed while impersonating"))
+			}
+
+			return next(c)
 		}
 	}
 }
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PublicKey) {
+	signBytes, err := os.ReadFile(`app.rsa`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey, &privateKey.PublicKey
+}
+
+func TestJWTVerify(t *testing.T) {
+	privateKey, publicKey := testKeys(t)
+
+	sign := func(claim entity.AccessTokenClaim) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claim).SignedString(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return `Bearer ` + token
+	}
+	claim := func(role string, expiresAt time.Time) entity.AccessTokenClaim {
+		c := entity.AccessTokenClaim{UserID: 1, Role: role}
+		c.ExpiresAt = jwt.NewNumericDate(expiresAt)
+		return c
+	}
+	impersonating := claim(entity.RoleAdmin, time.Now().Add(time.Hour))
+	impersonating.Actor = &entity.ActorClaim{UserID: 2}
+
+	e := echo.New()
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET(`/profile`, ok, JWTVerify(publicKey))
+	e.GET(`/admin`, ok, JWTVerify(publicKey), RequireAdmin())
+
+	tests := []struct {
+		name          string
+		target        string
+		authorization string
+		wantCode      int
+		wantMessage   string
+	}{
+		{
+			name:        `Missing`,
+			target:      `/profile`,
+			wantCode:    http.StatusUnauthorized,
+			wantMessage: `Authorization is empty`,
+		},
+		{
+			name:          `NotBearer`,
+			target:        `/profile`,
+			authorization: `Basic dXNlcjpwYXNz`,
+			wantCode:      http.StatusUnauthorized,
+			wantMessage:   `Authorization is invalid`,
+		},
+		{
+			name:          `Malformed`,
+			target:        `/profile`,
+			authorization: `Bearer not-a-token`,
+			wantCode:      http.StatusUnauthorized,
+			wantMessage:   `Access token is invalid`,
+		},
+		{
+			name:          `Expired`,
+			target:        `/profile`,
+			authorization: sign(claim(entity.RoleUser, time.Now().Add(-time.Minute))),
+			wantCode:      http.StatusUnauthorized,
+			wantMessage:   `Access token has expired`,
+		},
+		{
+			name:          `Valid`,
+			target:        `/profile`,
+			authorization: sign(claim(entity.RoleUser, time.Now().Add(time.Hour))),
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          `NotAdmin`,
+			target:        `/admin`,
+			authorization: sign(claim(entity.RoleUser, time.Now().Add(time.Hour))),
+			wantCode:      http.StatusForbidden,
+			wantMessage:   `Admin access is required`,
+		},
+		{
+			name:          `Impersonating`,
+			target:        `/admin`,
+			authorization: sign(impersonating),
+			wantCode:      http.StatusForbidden,
+			wantMessage:   `Admin access is not allowed while impersonating`,
+		},
+		{
+			name:          `Admin`,
+			target:        `/admin`,
+			authorization: sign(claim(entity.RoleAdmin, time.Now().Add(time.Hour))),
+			wantCode:      http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.authorization != `` {
+				req.Header.Set(`Authorization`, tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantMessage == `` {
+				return
+			}
+
+			var body shared.ErrorMessage
+			if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body)) {
+				assert.Equal(t, shared.ErrorMessage{ErrorCode: tt.wantCode, ErrorMessage: tt.wantMessage}, body)
+			}
+		})
+	}
+}
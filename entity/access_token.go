@@ -2,8 +2,22 @@ package entity
 
 import "github.com/golang-jwt/jwt/v5"
 
+// AccessTokenClaim is the payload of an access token. OrganizationID is the
+// organization the token acts in and Role the role of the user there; tokens
+// issued before organizations existed have no org_id and act in the default
+// organization.
 type AccessTokenClaim struct {
 	jwt.RegisteredClaims
-	UserID      int    `json:"user_id"`
-	PhoneNumber string `json:"phone_number"`
+	UserID         int              `json:"user_id"`
+	PhoneNumber    string           `json:"phone_number"`
+	OrganizationID int              `json:"org_id,omitempty"`
+	Role           string           `json:"role,omitempty"`
+	AuthTime       *jwt.NumericDate `json:"auth_time,omitempty"`
+	Actor          *ActorClaim      `json:"act,omitempty"`
+}
+
+// ActorClaim identifies the admin acting on behalf of the token subject
+// while impersonating.
+type ActorClaim struct {
+	UserID int `json:"user_id"`
 }
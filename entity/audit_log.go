@@ -0,0 +1,55 @@
+package entity
+
+import "time"
+
+const (
+	AuditActionRegistered      = `user.registered`
+	AuditActionProfileUpdated  = `user.profile_updated`
+	AuditActionPasswordChanged = `user.password_changed`
+)
+
+// AuditRedacted stands in for the values of secret fields, such as the
+// password, in the changes of an audit log entry.
+const AuditRedacted = `[redacted]`
+
+// AuditLog records a change made to an account. ActorID is the user who
+// made the change, which is an admin when they were impersonating UserID.
+//
+// The changes carry personal data such as phone numbers, so when phone
+// numbers are encrypted the changes column is '{}' and they are stored in
+// ChangesCiphertext.
+type AuditLog struct {
+	ID             int    `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int    `json:"organization_id" gorm:"column:organization_id"`
+	ActorID        int    `json:"actor_id" gorm:"column:actor_id"`
+	ActorRole      string `json:"actor_role" gorm:"column:actor_role"`
+	Action         string `json:"action" gorm:"column:action"`
+	UserID         int    `json:"user_id" gorm:"column:user_id"`
+	// Changes holds the changed fields as a JSON object of AuditChange.
+	Changes           string    `json:"changes" gorm:"column:changes"`
+	ChangesCiphertext *string   `json:"-" gorm:"column:changes_ciphertext"`
+	IPAddress         string    `json:"ip_address" gorm:"column:ip_address"`
+	RequestID         string    `json:"request_id" gorm:"column:request_id"`
+	CreatedAt         time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *AuditLog) TableName() string {
+	return `audit_log`
+}
+
+// AuditChange is the value of a field before and after a change.
+type AuditChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// AuditLogFilter selects audit log entries. Zero fields do not filter.
+type AuditLogFilter struct {
+	ActorID int
+	UserID  int
+	Action  string
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Offset  int
+}
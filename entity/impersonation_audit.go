@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+type ImpersonationAudit struct {
+	ID             int       `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int       `json:"organization_id" gorm:"column:organization_id"`
+	ActorID        int       `json:"actor_id" gorm:"column:actor_id"`
+	UserID         int       `json:"user_id" gorm:"column:user_id"`
+	Method         string    `json:"method" gorm:"column:method"`
+	Path           string    `json:"path" gorm:"column:path"`
+	StatusCode     int       `json:"status_code" gorm:"column:status_code"`
+	IPAddress      string    `json:"ip_address" gorm:"column:ip_address"`
+	UserAgent      string    `json:"user_agent" gorm:"column:user_agent"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *ImpersonationAudit) TableName() string {
+	return `impersonation_audit`
+}
@@ -0,0 +1,46 @@
+package entity
+
+import "time"
+
+const (
+	JobPending   = `pending`
+	JobRunning   = `running`
+	JobSucceeded = `succeeded`
+	// JobDead is the dead-letter state of a job that failed every attempt
+	// or failed permanently. It is kept until someone looks into it.
+	JobDead = `dead`
+)
+
+// Job is a unit of background work of Type, whose handler decodes Payload.
+// A job is run once RunAt has passed, and again after each failure until it
+// has been attempted MaxAttempts times. LockedAt is set while a worker runs
+// it. At most one job exists for each UniqueKey.
+type Job struct {
+	ID             int        `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int        `json:"organization_id" gorm:"column:organization_id"`
+	Type           string     `json:"type" gorm:"column:type"`
+	Payload        string     `json:"payload" gorm:"column:payload"`
+	Status         string     `json:"status" gorm:"column:status"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	MaxAttempts    int        `json:"max_attempts" gorm:"column:max_attempts"`
+	RunAt          time.Time  `json:"run_at" gorm:"column:run_at"`
+	LockedAt       *time.Time `json:"locked_at" gorm:"column:locked_at"`
+	LastError      string     `json:"last_error" gorm:"column:last_error"`
+	UniqueKey      *string    `json:"unique_key" gorm:"column:unique_key"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	FinishedAt     *time.Time `json:"finished_at" gorm:"column:finished_at"`
+}
+
+func (e *Job) TableName() string {
+	return `job`
+}
+
+// JobClaim selects the jobs a worker takes: up to Limit jobs of Types that
+// are pending and due at Now, or still running with a lock taken before
+// LockedBefore by a worker that has presumably stopped.
+type JobClaim struct {
+	Types        []string
+	Now          time.Time
+	LockedBefore time.Time
+	Limit        int
+}
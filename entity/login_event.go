@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+const (
+	LoginFailureUnknownPhoneNumber = `unknown_phone_number`
+	LoginFailureWrongPassword      = `wrong_password`
+)
+
+// LoginEvent records a login attempt. UserID is nil when the phone number
+// is not registered, and FailureReason is empty for a successful login.
+//
+// When phone numbers are encrypted, the phone_number column is empty and
+// the number is stored in PhoneNumberCiphertext.
+type LoginEvent struct {
+	ID                    int       `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID        int       `json:"organization_id" gorm:"column:organization_id"`
+	UserID                *int      `json:"user_id" gorm:"column:user_id"`
+	PhoneNumber           string    `json:"phone_number" gorm:"column:phone_number"`
+	PhoneNumberCiphertext *string   `json:"-" gorm:"column:phone_number_ciphertext"`
+	Success               bool      `json:"success" gorm:"column:success"`
+	FailureReason         string    `json:"failure_reason" gorm:"column:failure_reason"`
+	IPAddress             string    `json:"ip_address" gorm:"column:ip_address"`
+	UserAgent             string    `json:"user_agent" gorm:"column:user_agent"`
+	CreatedAt             time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *LoginEvent) TableName() string {
+	return `login_event`
+}
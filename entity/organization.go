@@ -0,0 +1,28 @@
+package entity
+
+import "time"
+
+// Organization is a tenant. Users are members of one or more organizations
+// and only see the data of the one they are acting in.
+type Organization struct {
+	ID        int       `json:"id" gorm:"column:id;primary_key"`
+	Name      string    `json:"name" gorm:"column:name"`
+	CreatedAt time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *Organization) TableName() string {
+	return `organization`
+}
+
+// OrganizationMember makes a user a member of an organization with a role
+// that only applies within it.
+type OrganizationMember struct {
+	OrganizationID int       `json:"organization_id" gorm:"column:organization_id;primary_key"`
+	UserID         int       `json:"user_id" gorm:"column:user_id;primary_key"`
+	Role           string    `json:"role" gorm:"column:role"`
+	CreatedAt      time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *OrganizationMember) TableName() string {
+	return `organization_member`
+}
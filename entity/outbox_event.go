@@ -0,0 +1,60 @@
+package entity
+
+import "time"
+
+// Domain event types, published to other services through the outbox.
+const (
+	EventUserRegistered  = `UserRegistered`
+	EventProfileUpdated  = `ProfileUpdated`
+	EventUserLoggedIn    = `UserLoggedIn`
+	EventPasswordChanged = `PasswordChanged`
+)
+
+// OutboxEvent is a domain event waiting to be published. It is written in
+// the same transaction as the change it describes, and PublishedAt is set
+// once the dispatcher has delivered it. Events are delivered to sinks and
+// webhooks outside the service, so their phone numbers are masked to the
+// last 3 digits.
+type OutboxEvent struct {
+	ID             int    `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int    `json:"organization_id" gorm:"column:organization_id"`
+	Type           string `json:"type" gorm:"column:type"`
+	UserID         int    `json:"user_id" gorm:"column:user_id"`
+	// Payload holds one of the event structs below as JSON.
+	Payload     string     `json:"payload" gorm:"column:payload"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"column:created_at"`
+	PublishedAt *time.Time `json:"published_at" gorm:"column:published_at"`
+}
+
+func (e *OutboxEvent) TableName() string {
+	return `outbox_event`
+}
+
+type UserRegisteredEvent struct {
+	UserID      int    `json:"user_id"`
+	FullName    string `json:"full_name"`
+	PhoneNumber string `json:"phone_number"`
+}
+
+// ProfileUpdatedEvent carries the profile after the update, and the fields
+// that changed with their old and new values.
+type ProfileUpdatedEvent struct {
+	UserID            int                    `json:"user_id"`
+	FullName          string                 `json:"full_name"`
+	PhoneNumber       string                 `json:"phone_number"`
+	DateOfBirth       *string                `json:"date_of_birth"`
+	Gender            *string                `json:"gender"`
+	Address           *string                `json:"address"`
+	PreferredLanguage *string                `json:"preferred_language"`
+	Changes           map[string]AuditChange `json:"changes"`
+}
+
+type UserLoggedInEvent struct {
+	UserID    int    `json:"user_id"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+}
+
+type PasswordChangedEvent struct {
+	UserID int `json:"user_id"`
+}
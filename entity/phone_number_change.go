@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// PhoneNumberChange is a request of a user to change their phone number to
+// PhoneNumber, which takes effect once the code sent to it is confirmed.
+// CodeHash is the SHA-256 of the code and Attempts counts the codes entered,
+// right or wrong. ConfirmedAt is nil while the change is pending.
+//
+// Like the phone number of a user, PhoneNumber is NULL in the database and
+// stored in PhoneNumberCiphertext when phone numbers are encrypted.
+type PhoneNumberChange struct {
+	ID                    int        `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID        int        `json:"organization_id" gorm:"column:organization_id"`
+	UserID                int        `json:"user_id" gorm:"column:user_id"`
+	PhoneNumber           string     `json:"phone_number" gorm:"column:phone_number"`
+	PhoneNumberCiphertext *string    `json:"-" gorm:"column:phone_number_ciphertext"`
+	CodeHash              string     `json:"-" gorm:"column:code_hash"`
+	Attempts              int        `json:"attempts" gorm:"column:attempts"`
+	ExpiresAt             time.Time  `json:"expires_at" gorm:"column:expires_at"`
+	CreatedAt             time.Time  `json:"created_at" gorm:"column:created_at"`
+	ConfirmedAt           *time.Time `json:"confirmed_at" gorm:"column:confirmed_at"`
+}
+
+func (e *PhoneNumberChange) TableName() string {
+	return `phone_number_change`
+}
+
+// PhoneNumberChangeFilter selects the phone number changes of UserID. Zero
+// times do not filter: CreatedFrom selects the changes requested since, and
+// ConfirmedFrom those confirmed since.
+type PhoneNumberChangeFilter struct {
+	UserID        int
+	CreatedFrom   time.Time
+	ConfirmedFrom time.Time
+}
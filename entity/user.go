@@ -2,17 +2,88 @@ package entity
 
 import "time"
 
+const (
+	RoleUser  = `user`
+	RoleAdmin = `admin`
+)
+
+const (
+	GenderMale   = `male`
+	GenderFemale = `female`
+	GenderOther  = `other`
+)
+
+// DateOfBirthFormat is the ISO 8601 calendar date in which the date of
+// birth is stored and exchanged.
+const DateOfBirthFormat = `2006-01-02`
+
+// User is an account. FailedLoginCount counts the failed logins since the
+// last successful one. The optional profile fields are nil when not set.
+// AvatarKey is the blob storage key that the keys of the avatar variants are
+// derived from.
+//
+// OrganizationID is the organization the user was created in, and
+// PhoneNumberScope is 0 when the phone number is unique across all
+// organizations or OrganizationID when it only is within it. Role is read
+// from the membership in the organization the user was loaded for and is
+// never written with the user.
+//
+// When phone numbers are encrypted, the phone_number column is NULL and the
+// number is stored in PhoneNumberCiphertext and found by PhoneNumberIndex,
+// its blind index. PhoneNumber always holds the decrypted number.
 type User struct {
-	ID              int        `json:"id" gorm:"column:id;primary_key"`
-	FullName        string     `json:"full_name" gorm:"column:full_name"`
-	PhoneNumber     string     `json:"phone_number" gorm:"column:phone_number"`
-	Password        string     `json:"password" gorm:"column:password"`
-	AccountSalt     string     `json:"account_salt" gorm:"column:account_salt"`
-	SuccessfulLogin int        `json:"successfuul_login" gorm:"column:successful_login"`
-	CreatedAt       time.Time  `json:"created_at" gorm:"column:created_at"`
-	UpdatedAt       *time.Time `json:"updated_at" gorm:"column:updated_at"`
+	ID                    int        `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID        int        `json:"organization_id" gorm:"column:organization_id"`
+	PhoneNumberScope      int        `json:"phone_number_scope" gorm:"column:phone_number_scope"`
+	FullName              string     `json:"full_name" gorm:"column:full_name"`
+	PhoneNumber           string     `json:"phone_number" gorm:"column:phone_number"`
+	PhoneNumberCiphertext *string    `json:"phone_number_ciphertext" gorm:"column:phone_number_ciphertext"`
+	PhoneNumberIndex      *string    `json:"phone_number_index" gorm:"column:phone_number_index"`
+	Password              string     `json:"password" gorm:"column:password"`
+	AccountSalt           string     `json:"account_salt" gorm:"column:account_salt"`
+	SuccessfulLogin       int        `json:"successfuul_login" gorm:"column:successful_login"`
+	LastLoginAt           *time.Time `json:"last_login_at" gorm:"column:last_login_at"`
+	FailedLoginCount      int        `json:"failed_login_count" gorm:"column:failed_login_count"`
+	LastFailedLoginAt     *time.Time `json:"last_failed_login_at" gorm:"column:last_failed_login_at"`
+	DateOfBirth           *string    `json:"date_of_birth" gorm:"column:date_of_birth"`
+	Gender                *string    `json:"gender" gorm:"column:gender"`
+	Address               *string    `json:"address" gorm:"column:address"`
+	PreferredLanguage     *string    `json:"preferred_language" gorm:"column:preferred_language"`
+	AvatarKey             *string    `json:"avatar_key" gorm:"column:avatar_key"`
+	Role                  string     `json:"role" gorm:"column:role;->"`
+	Version               int        `json:"version" gorm:"column:version;default:1"`
+	CreatedAt             time.Time  `json:"created_at" gorm:"column:created_at"`
+	UpdatedAt             *time.Time `json:"updated_at" gorm:"column:updated_at"`
 }
 
 func (e *User) TableName() string {
 	return `user`
 }
+
+// UserFilter selects users in pages ordered by id: a page starts after the
+// user AfterID. Zero fields do not filter; the From times are inclusive and
+// the To times exclusive.
+type UserFilter struct {
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	UpdatedFrom time.Time
+	UpdatedTo   time.Time
+	AfterID     int
+	Limit       int
+}
+
+// UserSearch is a fuzzy search over the names and phone numbers of users.
+type UserSearch struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+// UserMatch is a user found by a search. Score tells how well the user
+// matched, from 0 to 1.
+type UserMatch struct {
+	ID          int     `gorm:"column:id"`
+	FullName    string  `gorm:"column:full_name"`
+	PhoneNumber string  `gorm:"column:phone_number"`
+	Score       float64 `gorm:"column:score"`
+}
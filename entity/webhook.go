@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = `pending`
+	WebhookDeliverySucceeded = `succeeded`
+	WebhookDeliveryFailed    = `failed`
+)
+
+// WebhookSubscription asks for the events of EventTypes to be posted to
+// URL, signed with Secret.
+type WebhookSubscription struct {
+	ID             int    `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int    `json:"organization_id" gorm:"column:organization_id"`
+	URL            string `json:"url" gorm:"column:url"`
+	// EventTypes is a comma separated list of domain event types.
+	EventTypes string    `json:"event_types" gorm:"column:event_types"`
+	Secret     string    `json:"-" gorm:"column:secret"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *WebhookSubscription) TableName() string {
+	return `webhook_subscription`
+}
+
+// Subscribes reports whether the subscription wants events of eventType.
+func (e *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range strings.Split(e.EventTypes, `,`) {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is an outbox event to be posted to one subscription.
+// Payload is the exact request body, so that a replay sends the same bytes.
+type WebhookDelivery struct {
+	ID             int        `json:"id" gorm:"column:id;primary_key"`
+	OrganizationID int        `json:"organization_id" gorm:"column:organization_id"`
+	SubscriptionID int        `json:"subscription_id" gorm:"column:subscription_id"`
+	EventID        int        `json:"event_id" gorm:"column:event_id"`
+	EventType      string     `json:"event_type" gorm:"column:event_type"`
+	Payload        string     `json:"payload" gorm:"column:payload"`
+	Status         string     `json:"status" gorm:"column:status"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"column:next_attempt_at"`
+	LastStatusCode int        `json:"last_status_code" gorm:"column:last_status_code"`
+	LastError      string     `json:"last_error" gorm:"column:last_error"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at" gorm:"column:delivered_at"`
+}
+
+func (e *WebhookDelivery) TableName() string {
+	return `webhook_delivery`
+}
+
+// WebhookAttempt records one request made for a delivery. StatusCode is 0
+// when no response was received.
+type WebhookAttempt struct {
+	ID         int       `json:"id" gorm:"column:id;primary_key"`
+	DeliveryID int       `json:"delivery_id" gorm:"column:delivery_id"`
+	StatusCode int       `json:"status_code" gorm:"column:status_code"`
+	Error      string    `json:"error" gorm:"column:error"`
+	DurationMS int       `json:"duration_ms" gorm:"column:duration_ms"`
+	CreatedAt  time.Time `json:"created_at" gorm:"column:created_at"`
+}
+
+func (e *WebhookAttempt) TableName() string {
+	return `webhook_attempt`
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sawitpro/technical_test/blob"
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+var errExportUsage = errors.New(`usage: main export [-format csv|jsonl] [-fields a,b] [-created-from t] [-created-to t] [-updated-from t] [-updated-to t] [-organization id] [-o FILE]`)
+
+// RunExport implements the `export` subcommand, which writes the members of
+// an organization to FILE, or to standard output by default.
+func RunExport(args []string) error {
+	form := new(user.ExportUsersRequest)
+
+	flags := flag.NewFlagSet(`export`, flag.ContinueOnError)
+	flags.StringVar(&form.Format, `format`, ``, `csv or jsonl, by default taken from the -o extension, or csv`)
+	flags.StringVar(&form.Fields, `fields`, ``, `comma separated fields to export, all of them by default`)
+	flags.StringVar(&form.CreatedFrom, `created-from`, ``, `only users created at or after this RFC 3339 time`)
+	flags.StringVar(&form.CreatedTo, `created-to`, ``, `only users created before this RFC 3339 time`)
+	flags.StringVar(&form.UpdatedFrom, `updated-from`, ``, `only users updated at or after this RFC 3339 time`)
+	flags.StringVar(&form.UpdatedTo, `updated-to`, ``, `only users updated before this RFC 3339 time`)
+	organizationID := flags.Int(`organization`, tenant.DefaultID, `organization whose members are exported`)
+	path := flags.String(`o`, ``, `file to write, standard output by default`)
+	if err := flags.Parse(args); err != nil {
+		return errExportUsage
+	}
+	if flags.NArg() != 0 || *organizationID <= 0 {
+		return errExportUsage
+	}
+
+	if form.Format == `` && *path != `` {
+		form.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*path)), `.`)
+	}
+
+	// Validate before creating the file, so that a mistyped flag does not
+	// truncate an earlier export.
+	if err := form.Validation(); err != nil {
+		return err
+	}
+
+	cfg := config.NewConfig()
+	repo := repository.NewRepository(cfg)
+	storage, err := blob.NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+	uc := usecase.NewUserUsecase(cfg, repo, storage)
+
+	var out io.Writer = os.Stdout
+	if *path != `` {
+		file, err := os.Create(*path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	w := bufio.NewWriter(out)
+	ctx := tenant.WithID(context.Background(), *organizationID)
+	if err := uc.ExportUsers(ctx, form, w); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// docsPage is Swagger UI, loaded from a CDN, browsing /openapi.json.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <title>User Service API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.11.0/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.11.0/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: '/openapi.json', dom_id: '#swagger-ui'});
+  </script>
+</body>
+</html>
+`
+
+// APIDocs serves the API specification, with its server URL pointing at
+// the host it was requested from, so that requests tried from the docs go
+// to the same deployment.
+type APIDocs struct {
+	spec *openapi3.T
+	// document is the YAML of the specification, kept as a node tree to
+	// serve it in its original order and with its comments.
+	document *yaml.Node
+}
+
+// NewAPIDocs serves spec, parsed from source.
+func NewAPIDocs(source []byte, spec *openapi3.T) (*APIDocs, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(source, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) != 1 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New(`the API specification is not a YAML mapping`)
+	}
+
+	return &APIDocs{spec: spec, document: &document}, nil
+}
+
+// YAML serves the specification as YAML.
+func (d *APIDocs) YAML(c echo.Context) error {
+	root := d.document.Content[0]
+	servers := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: `url`},
+			{Kind: yaml.ScalarNode, Value: serverURL(c)},
+		},
+	}}}
+
+	// The tree is shared by every request, so the root is copied rather
+	// than changed.
+	content := make([]*yaml.Node, 0, len(root.Content)+2)
+	found := false
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if key.Value == `servers` {
+			value, found = servers, true
+		}
+		content = append(content, key, value)
+	}
+	if !found {
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Value: `servers`}, servers)
+	}
+	rewritten := *root
+	rewritten.Content = content
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&rewritten); err != nil {
+		return err
+	}
+
+	return c.Blob(http.StatusOK, `application/yaml`, buf.Bytes())
+}
+
+// JSON serves the specification as JSON.
+func (d *APIDocs) JSON(c echo.Context) error {
+	spec := *d.spec
+	spec.Servers = openapi3.Servers{{URL: serverURL(c)}}
+
+	return c.JSON(http.StatusOK, &spec)
+}
+
+// Page serves an interactive page to browse the specification and try its
+// endpoints.
+func (d *APIDocs) Page(c echo.Context) error {
+	return c.HTML(http.StatusOK, docsPage)
+}
+
+// serverURL is the URL the request was made to, behind a proxy too when it
+// sets X-Forwarded-Proto and passes on the Host header.
+func serverURL(c echo.Context) string {
+	return c.Scheme() + `://` + c.Request().Host
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAPIDocs(t *testing.T) {
+	source, err := os.ReadFile(`../api.yaml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec, err := openapi3.NewLoader().LoadFromData(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := NewAPIDocs(source, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := echo.New()
+	e.GET(`/openapi.yaml`, docs.YAML)
+	e.GET(`/openapi.json`, docs.JSON)
+
+	type document struct {
+		OpenAPI string `json:"openapi" yaml:"openapi"`
+		Servers []struct {
+			URL string `json:"url" yaml:"url"`
+		} `json:"servers" yaml:"servers"`
+		Paths map[string]interface{} `json:"paths" yaml:"paths"`
+	}
+	for _, tt := range []struct {
+		target    string
+		unmarshal func([]byte, interface{}) error
+	}{
+		{target: `/openapi.yaml`, unmarshal: yaml.Unmarshal},
+		{target: `/openapi.json`, unmarshal: json.Unmarshal},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		req.Host = `api.example.com`
+		req.Header.Set(echo.HeaderXForwardedProto, `https`)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var got document
+		assert.Equal(t, http.StatusOK, rec.Code, tt.target)
+		assert.NoError(t, tt.unmarshal(rec.Body.Bytes(), &got), tt.target)
+		assert.Equal(t, `3.0.0`, got.OpenAPI, tt.target)
+		if assert.Len(t, got.Servers, 1, tt.target) {
+			assert.Equal(t, `https://api.example.com`, got.Servers[0].URL, tt.target)
+		}
+		assert.Len(t, got.Paths, len(spec.Paths.Map()), tt.target)
+	}
+
+	// The shared specification is left as it was.
+	assert.Equal(t, `http://localhost:8080`, spec.Servers[0].URL)
+}
@@ -1,8 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/sawitpro/technical_test/shared"
@@ -14,8 +19,9 @@ func (h *handler) Login(c echo.Context) error {
 
 	form := new(user.UserLoginRequest)
 	if err := c.Bind(form); err != nil {
-		return shared.HttpError(c, err)
+		return shared.HttpError(c, shared.Malformed(err))
 	}
+	form.RequestMeta = requestMeta(c)
 
 	result, err := h.userUsecase.UserLogin(reqCtx, form)
 	if err != nil {
@@ -31,13 +37,18 @@ func (h *handler) GetUserProfile(c echo.Context, id string) error {
 
 	userID, err := strconv.Atoi(id)
 	if err != nil {
-		return shared.HttpError(c, err)
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to view this user profile"))
 	}
 
 	result, err := h.userUsecase.GetUserProfile(reqCtx, userID)
 	if err != nil {
 		return shared.HttpError(c, err)
 	}
+	c.Response().Header().Set(`ETag`, shared.ETag(result.Version))
 
 	res := shared.JSONSuccess(`Success`, result)
 	return c.JSON(http.StatusOK, res)
@@ -48,11 +59,22 @@ func (h *handler) UpdateUserProfile(c echo.Context, id string) error {
 
 	userID, err := strconv.Atoi(id)
 	if err != nil {
-		return shared.HttpError(c, err)
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to update this user profile"))
 	}
 
 	form := new(user.UpdateProfileRequest)
 	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	form.ActorID, _ = c.Get("ActorID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	form.Version, err = ifMatchVersion(c)
+	if err != nil {
 		return shared.HttpError(c, err)
 	}
 
@@ -65,13 +87,86 @@ func (h *handler) UpdateUserProfile(c echo.Context, id string) error {
 	return c.JSON(http.StatusOK, res)
 }
 
+// PatchUserProfile only accepts application/merge-patch+json, which echo's
+// binder does not know, so the body is decoded here.
+func (h *handler) PatchUserProfile(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to update this user profile"))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	if mediaType != mergePatchMediaType {
+		return shared.HttpError(c, &shared.ErrorMessage{
+			ErrorCode:    http.StatusUnsupportedMediaType,
+			ErrorMessage: "Content-Type must be " + mergePatchMediaType,
+		})
+	}
+
+	form := new(user.PatchProfileRequest)
+	if err := json.NewDecoder(c.Request().Body).Decode(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	form.ActorID, _ = c.Get("ActorID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	form.Version, err = ifMatchVersion(c)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	err = h.userUsecase.PatchProfile(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, nil)
+	return c.JSON(http.StatusOK, res)
+}
+
+// UploadAvatar reads the image from the raw request body. Its content type
+// is sniffed from the data rather than taken from the Content-Type header.
+func (h *handler) UploadAvatar(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to change this user avatar"))
+	}
+
+	form := &user.UploadAvatarRequest{
+		Body:        c.Request().Body,
+		RequestMeta: requestMeta(c),
+	}
+	form.ActorID, _ = c.Get("ActorID").(int)
+
+	result, err := h.userUsecase.UploadAvatar(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
 func (h *handler) Registration(c echo.Context) error {
 	reqCtx := c.Request().Context()
 
 	form := new(user.UserRegistrationRequest)
 	if err := c.Bind(form); err != nil {
-		return shared.HttpError(c, err)
+		return shared.HttpError(c, shared.Malformed(err))
 	}
+	form.RequestMeta = requestMeta(c)
 
 	result, err := h.userUsecase.UserRegistration(reqCtx, form)
 	if err != nil {
@@ -81,3 +176,488 @@ func (h *handler) Registration(c echo.Context) error {
 	res := shared.JSONSuccess(`Success`, result)
 	return c.JSON(http.StatusOK, res)
 }
+
+func (h *handler) Reauthenticate(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	userID, _ := c.Get("UserID").(int)
+
+	form := new(user.ReauthenticateRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.Reauthenticate(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ChangePassword(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to change this user password"))
+	}
+
+	form := new(user.ChangePasswordRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	form.AuthTime, _ = c.Get("AuthTime").(time.Time)
+	form.ActorID, _ = c.Get("ActorID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	err = h.userUsecase.ChangePassword(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, nil)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) RequestPhoneNumberChange(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to change this user phone number"))
+	}
+
+	form := new(user.RequestPhoneNumberChangeRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	form.AuthTime, _ = c.Get("AuthTime").(time.Time)
+	form.ActorID, _ = c.Get("ActorID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	result, err := h.userUsecase.RequestPhoneNumberChange(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ConfirmPhoneNumberChange(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to change this user phone number"))
+	}
+
+	form := new(user.ConfirmPhoneNumberChangeRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	form.ActorID, _ = c.Get("ActorID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	err = h.userUsecase.ConfirmPhoneNumberChange(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, nil)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) Impersonate(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	adminID, _ := c.Get("UserID").(int)
+
+	form := new(user.ImpersonateRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.Impersonate(reqCtx, form, adminID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	// Refuse to hand out the token when its issuance cannot be audited.
+	audit := newImpersonationAudit(c, adminID, result.UserID, http.StatusOK)
+	if err = h.userUsecase.RecordImpersonation(reqCtx, audit); err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+// ImportUsers streams the request body to the usecase instead of binding it,
+// so the file is never held in memory as a whole.
+func (h *handler) ImportUsers(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.ImportUsersRequest)
+	if err := (&echo.DefaultBinder{}).BindQueryParams(c, form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+	if form.Format == `` {
+		form.Format = importFormat(c.Request().Header.Get(echo.HeaderContentType))
+	}
+	form.Body = c.Request().Body
+	form.ActorID, _ = c.Get("UserID").(int)
+	form.RequestMeta = requestMeta(c)
+
+	result, err := h.userUsecase.ImportUsers(reqCtx, form)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ExportUsers(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.ExportUsersRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	w := &exportResponseWriter{c: c, form: form}
+	err := h.userUsecase.ExportUsers(reqCtx, form, w)
+	if err != nil {
+		if !w.started {
+			return shared.HttpError(c, err)
+		}
+
+		// The status was sent with the first users; abort the response so
+		// that the client does not take it for a complete export.
+		log.Printf(`Export users error %s`, err.Error())
+		panic(http.ErrAbortHandler)
+	}
+
+	w.start()
+	return nil
+}
+
+func (h *handler) SearchUsers(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.SearchUsersRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.SearchUsers(reqCtx, form)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ListAuditLogs(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.ListAuditLogsRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.ListAuditLogs(reqCtx, form)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ListLogins(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	userID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	if tokenUserID, _ := c.Get("UserID").(int); tokenUserID != userID {
+		return shared.HttpError(c, shared.Forbidden("You are not allowed to view this user login history"))
+	}
+
+	form := new(user.ListLoginsRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.ListLogins(reqCtx, form, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) CreateWebhook(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.CreateWebhookRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.CreateWebhook(reqCtx, form)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ListWebhooks(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	result, err := h.userUsecase.ListWebhooks(reqCtx)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) DeleteWebhook(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	subscriptionID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	err = h.userUsecase.DeleteWebhook(reqCtx, subscriptionID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, nil)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ListWebhookDeliveries(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	subscriptionID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	form := new(user.ListWebhookDeliveriesRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.ListWebhookDeliveries(reqCtx, form, subscriptionID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) GetWebhookDelivery(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	deliveryID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.GetWebhookDelivery(reqCtx, deliveryID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+func (h *handler) ReplayWebhookDelivery(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	deliveryID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.ReplayWebhookDelivery(reqCtx, deliveryID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+// CreateOrganization creates an organization for an admin of the default one.
+func (h *handler) CreateOrganization(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	form := new(user.CreateOrganizationRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.CreateOrganization(reqCtx, form)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+// AddOrganizationMember adds a user to an organization with a role.
+func (h *handler) AddOrganizationMember(c echo.Context, id string) error {
+	reqCtx := c.Request().Context()
+
+	organizationID, err := strconv.Atoi(id)
+	if err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	form := new(user.AddOrganizationMemberRequest)
+	if err := c.Bind(form); err != nil {
+		return shared.HttpError(c, shared.Malformed(err))
+	}
+
+	result, err := h.userUsecase.AddOrganizationMember(reqCtx, form, organizationID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+// ListOrganizations lists the organizations the caller is a member of.
+func (h *handler) ListOrganizations(c echo.Context) error {
+	reqCtx := c.Request().Context()
+
+	userID, _ := c.Get("UserID").(int)
+
+	result, err := h.userUsecase.ListOrganizations(reqCtx, userID)
+	if err != nil {
+		return shared.HttpError(c, err)
+	}
+
+	res := shared.JSONSuccess(`Success`, result)
+	return c.JSON(http.StatusOK, res)
+}
+
+// requestMeta describes the request for the audit log and login history. The
+// request id is the one set by the RequestID middleware.
+func requestMeta(c echo.Context) user.RequestMeta {
+	role, _ := c.Get("Role").(string)
+
+	return user.RequestMeta{
+		Role:      role,
+		IPAddress: truncate(c.RealIP(), 45),
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		UserAgent: truncate(c.Request().UserAgent(), 255),
+	}
+}
+
+// mergePatchMediaType is the Content-Type of a JSON merge patch (RFC 7396).
+const mergePatchMediaType = `application/merge-patch+json`
+
+// ifMatchVersion returns the profile version from the If-Match header,
+// shared.AnyVersion for "*", or 0 when the header was not sent.
+func ifMatchVersion(c echo.Context) (int, error) {
+	ifMatch := c.Request().Header.Get(`If-Match`)
+	if ifMatch == `` {
+		return 0, nil
+	}
+
+	version, ok := shared.ParseETag(ifMatch)
+	if !ok {
+		return 0, &shared.ErrorMessage{
+			ErrorCode:    http.StatusPreconditionFailed,
+			ErrorMessage: "If-Match must be an ETag returned by the get profile endpoint",
+		}
+	}
+
+	return version, nil
+}
+
+// importFormat returns the import format for the Content-Type of the request,
+// or an empty string when it names neither format.
+func importFormat(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case `text/csv`:
+		return user.ImportFormatCSV
+	case `application/jsonl`, `application/x-ndjson`, `application/x-jsonlines`:
+		return user.ImportFormatJSONL
+	}
+
+	return ``
+}
+
+// exportResponseWriter sends the headers of an export with the first write,
+// so that an invalid request can still be answered with an error, and
+// flushes every write to the client.
+type exportResponseWriter struct {
+	c       echo.Context
+	form    *user.ExportUsersRequest
+	started bool
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	w.start()
+
+	n, err := w.c.Response().Write(p)
+	if err != nil {
+		return n, err
+	}
+	w.c.Response().Flush()
+
+	return n, nil
+}
+
+func (w *exportResponseWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	contentType := `text/csv; charset=utf-8`
+	if w.form.Format == user.ExportFormatJSONL {
+		contentType = `application/x-ndjson`
+	}
+
+	header := w.c.Response().Header()
+	header.Set(echo.HeaderContentType, contentType)
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="users.%s"`, w.form.Format))
+	w.c.Response().WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsers(t *testing.T) {
+	// The query is bound before the usecase is called.
+	h := NewHandler(nil)
+
+	e := echo.New()
+	e.POST(`/admin/users/import`, h.ImportUsers)
+
+	req := httptest.NewRequest(http.MethodPost, `/admin/users/import?dry_run=maybe`, strings.NewReader(``))
+	req.Header.Set(echo.HeaderContentType, `text/csv`)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestProfileOfAnotherUser(t *testing.T) {
+	// The token is checked before the usecase is called.
+	h := NewHandler(nil)
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("UserID", 1)
+			return next(c)
+		}
+	})
+	e.GET(`/profile/:id`, func(c echo.Context) error {
+		return h.GetUserProfile(c, c.Param(`id`))
+	})
+	e.PUT(`/profile/:id`, func(c echo.Context) error {
+		return h.UpdateUserProfile(c, c.Param(`id`))
+	})
+
+	tests := []struct {
+		method string
+		body   string
+	}{
+		{method: http.MethodGet},
+		{method: http.MethodPut, body: `{"full_name":"Renamed"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, `/profile/2`, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		})
+	}
+}
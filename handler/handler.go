@@ -16,9 +16,72 @@ type ServerInterface interface {
 	// Endpoint for update user profile.
 	// (PUT /profile/{id})
 	UpdateUserProfile(ctx echo.Context, id string) error
+	// Endpoint for partially updating the user profile with a JSON merge patch.
+	// (PATCH /profile/{id})
+	PatchUserProfile(ctx echo.Context, id string) error
+	// Endpoint for uploading the user avatar.
+	// (PUT /profile/{id}/avatar)
+	UploadAvatar(ctx echo.Context, id string) error
 	// Endpoint for user registration.
 	// (POST /registration)
 	Registration(ctx echo.Context) error
+	// Endpoint for re-entering the password to get a fresh access token.
+	// (POST /reauthenticate)
+	Reauthenticate(ctx echo.Context) error
+	// Endpoint for change user password.
+	// (PUT /profile/{id}/password)
+	ChangePassword(ctx echo.Context, id string) error
+	// Endpoint for sending a code to the phone number the user wants to change to.
+	// (POST /profile/{id}/phone-number)
+	RequestPhoneNumberChange(ctx echo.Context, id string) error
+	// Endpoint for changing the phone number with the code sent to it.
+	// (POST /profile/{id}/phone-number/confirm)
+	ConfirmPhoneNumberChange(ctx echo.Context, id string) error
+	// Endpoint for admins to get a short-lived token acting as another user.
+	// (POST /admin/impersonate)
+	Impersonate(ctx echo.Context) error
+	// Endpoint for admins to create users in bulk from a CSV or JSONL file.
+	// (POST /admin/users/import)
+	ImportUsers(ctx echo.Context) error
+	// Endpoint for admins to download users as CSV or JSONL.
+	// (GET /admin/users/export)
+	ExportUsers(ctx echo.Context) error
+	// Endpoint for admins to find users by part of their name or phone number.
+	// (GET /admin/users/search)
+	SearchUsers(ctx echo.Context) error
+	// Endpoint for admins to query the audit log of account changes.
+	// (GET /admin/audit-logs)
+	ListAuditLogs(ctx echo.Context) error
+	// Endpoint for users to review their login history.
+	// (GET /profile/{id}/logins)
+	ListLogins(ctx echo.Context, id string) error
+	// Endpoint for admins to subscribe a URL to domain events.
+	// (POST /admin/webhooks)
+	CreateWebhook(ctx echo.Context) error
+	// Endpoint for admins to list webhook subscriptions.
+	// (GET /admin/webhooks)
+	ListWebhooks(ctx echo.Context) error
+	// Endpoint for admins to remove a webhook subscription.
+	// (DELETE /admin/webhooks/{id})
+	DeleteWebhook(ctx echo.Context, id string) error
+	// Endpoint for admins to list the deliveries to a webhook.
+	// (GET /admin/webhooks/{id}/deliveries)
+	ListWebhookDeliveries(ctx echo.Context, id string) error
+	// Endpoint for admins to get a webhook delivery with its attempts.
+	// (GET /admin/webhook-deliveries/{id})
+	GetWebhookDelivery(ctx echo.Context, id string) error
+	// Endpoint for admins to send a webhook delivery again.
+	// (POST /admin/webhook-deliveries/{id}/replay)
+	ReplayWebhookDelivery(ctx echo.Context, id string) error
+	// Endpoint for admins of the default organization to create an organization.
+	// (POST /admin/organizations)
+	CreateOrganization(ctx echo.Context) error
+	// Endpoint for admins of the default organization to add a user to an organization.
+	// (POST /admin/organizations/{id}/members)
+	AddOrganizationMember(ctx echo.Context, id string) error
+	// Endpoint for users to list the organizations they can log in to.
+	// (GET /organizations)
+	ListOrganizations(ctx echo.Context) error
 }
 
 type handler struct {
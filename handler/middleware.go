@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/usecase"
+)
+
+// AuditImpersonation records every request made with an impersonation token.
+// It is registered on the server rather than on a route: JWTVerify only sets
+// ActorID further down the chain, so the check runs after the request has
+// been handled.
+func AuditImpersonation(userUsecase usecase.UserUsecase) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+
+			actorID, ok := c.Get("ActorID").(int)
+			if !ok {
+				return err
+			}
+			userID, _ := c.Get("UserID").(int)
+
+			statusCode := c.Response().Status
+			if httpErr, ok := err.(*echo.HTTPError); ok {
+				statusCode = httpErr.Code
+			}
+
+			audit := newImpersonationAudit(c, actorID, userID, statusCode)
+			if auditErr := userUsecase.RecordImpersonation(c.Request().Context(), audit); auditErr != nil {
+				log.Printf(`Record impersonation audit error %s`, auditErr.Error())
+			}
+
+			return err
+		}
+	}
+}
+
+// RequestID passes on the X-Request-Id header of the request, or generates
+// one, and echoes it in the response so that log entries and audit records
+// can be matched to the request.
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			requestID := c.Request().Header.Get(echo.HeaderXRequestID)
+			if requestID == `` {
+				requestID = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, truncate(requestID, 64))
+
+			return next(c)
+		}
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf(`Generate request id error %s`, err.Error())
+		return ``
+	}
+	return hex.EncodeToString(b)
+}
+
+// ReadYourWrites gives each request its own repository session, so reads
+// made after the request has written are served by the primary instead of a
+// replica that may not have the change yet.
+func ReadYourWrites() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(repository.WithSession(req.Context())))
+
+			return next(c)
+		}
+	}
+}
+
+func newImpersonationAudit(c echo.Context, actorID int, userID int, statusCode int) *entity.ImpersonationAudit {
+	req := c.Request()
+
+	return &entity.ImpersonationAudit{
+		ActorID:    actorID,
+		UserID:     userID,
+		Method:     req.Method,
+		Path:       truncate(req.URL.Path, 255),
+		StatusCode: statusCode,
+		IPAddress:  truncate(c.RealIP(), 45),
+		UserAgent:  truncate(req.UserAgent(), 255),
+	}
+}
+
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
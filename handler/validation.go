@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/labstack/echo/v4"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+func init() {
+	// Merge patches are JSON documents, checked against their schema like
+	// any other.
+	openapi3filter.RegisterBodyDecoder(mergePatchMediaType, openapi3filter.RegisteredBodyDecoder(echo.MIMEApplicationJSON))
+}
+
+// ValidateRequests rejects requests that do not match the operation spec
+// declares for their route with a 400 listing every mismatch, before they
+// reach the handler. Routes missing from spec, such as static files, are
+// passed through. Only JSON bodies are checked: uploads and imports are
+// streamed to their handlers, which also report bodies of a type the
+// operation does not accept.
+//
+// It is registered on the server, so a request is checked before its
+// access token.
+//
+// With validateResponses, JSON responses with a status the operation
+// declares are checked too, and mismatches are logged. The responses are
+// sent unchanged, so this is meant to catch drift during development.
+func ValidateRequests(spec *openapi3.T, validateResponses bool) echo.MiddlewareFunc {
+	routes := map[string]*routers.Route{}
+	for path, item := range spec.Paths.Map() {
+		for method, operation := range item.Operations() {
+			routes[method+` `+echoPath(path)] = &routers.Route{
+				Spec:      spec,
+				Path:      path,
+				PathItem:  item,
+				Method:    method,
+				Operation: operation,
+			}
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			route, ok := routes[req.Method+` `+c.Path()]
+			if !ok {
+				return next(c)
+			}
+
+			pathParams := map[string]string{}
+			for i, name := range c.ParamNames() {
+				pathParams[name] = c.ParamValues()[i]
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    req,
+				PathParams: pathParams,
+				Route:      route,
+				Options: &openapi3filter.Options{
+					ExcludeRequestBody:  !hasJSONBody(req, route.Operation),
+					MultiError:          true,
+					SkipSettingDefaults: true,
+				},
+			}
+			if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+				return shared.HttpError(c, &shared.ErrorMessage{
+					ErrorCode:    http.StatusBadRequest,
+					ErrorMessage: "Invalid request",
+					Errors:       requestFieldErrors(err),
+					Err:          err,
+				})
+			}
+
+			if !validateResponses {
+				return next(c)
+			}
+
+			res := c.Response()
+			recorder := &responseRecorder{ResponseWriter: res.Writer}
+			res.Writer = recorder
+			err := next(c)
+			res.Writer = recorder.ResponseWriter
+
+			if recorder.json && res.Committed {
+				responseInput := &openapi3filter.ResponseValidationInput{
+					RequestValidationInput: input,
+					Status:                 res.Status,
+					Header:                 res.Header(),
+					Body:                   io.NopCloser(&recorder.body),
+					Options:                &openapi3filter.Options{MultiError: true},
+				}
+				if validationErr := openapi3filter.ValidateResponse(req.Context(), responseInput); validationErr != nil {
+					log.Printf(`%s %s response does not match the API specification: %s`, req.Method, req.URL.Path, validationErr.Error())
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+// echoPath turns a path template such as /profile/{id} into the echo route
+// /profile/:id.
+func echoPath(path string) string {
+	parts := strings.Split(path, `/`)
+	for i, part := range parts {
+		if strings.HasPrefix(part, `{`) && strings.HasSuffix(part, `}`) {
+			parts[i] = `:` + part[1:len(part)-1]
+		}
+	}
+	return strings.Join(parts, `/`)
+}
+
+// hasJSONBody reports whether the request has a JSON body of a type
+// operation accepts.
+func hasJSONBody(req *http.Request, operation *openapi3.Operation) bool {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get(echo.HeaderContentType))
+	if err != nil || !isJSON(mediaType) {
+		return false
+	}
+	return operation.RequestBody.Value.Content.Get(mediaType) != nil
+}
+
+func isJSON(mediaType string) bool {
+	return mediaType == echo.MIMEApplicationJSON || strings.HasSuffix(mediaType, `+json`)
+}
+
+// requestFieldErrors lists the mismatches reported by ValidateRequest.
+func requestFieldErrors(err error) []shared.FieldError {
+	// The errors of a request are collected in a MultiError, which is not
+	// unwrapped: the body's schema errors are in one too.
+	if multi, ok := err.(openapi3.MultiError); ok {
+		var fieldErrs []shared.FieldError
+		for _, err := range multi {
+			fieldErrs = append(fieldErrs, requestFieldErrors(err)...)
+		}
+		return fieldErrs
+	}
+
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return []shared.FieldError{{In: `body`, Message: err.Error()}}
+	}
+
+	in, field := `body`, ``
+	if requestErr.Parameter != nil {
+		in, field = requestErr.Parameter.In, requestErr.Parameter.Name
+	}
+
+	var schemaErr *openapi3.SchemaError
+	switch {
+	case errors.As(requestErr.Err, &schemaErr):
+		return schemaFieldErrors(in, field, requestErr.Err)
+	case requestErr.Reason != ``:
+		return []shared.FieldError{{In: in, Field: field, Message: requestErr.Reason}}
+	case requestErr.Err != nil:
+		return []shared.FieldError{{In: in, Field: field, Message: requestErr.Err.Error()}}
+	default:
+		return []shared.FieldError{{In: in, Field: field, Message: `invalid value`}}
+	}
+}
+
+// schemaFieldErrors names the body property or parameter each schema error
+// in err is about.
+func schemaFieldErrors(in, field string, err error) []shared.FieldError {
+	if multi, ok := err.(openapi3.MultiError); ok {
+		var fieldErrs []shared.FieldError
+		for _, err := range multi {
+			fieldErrs = append(fieldErrs, schemaFieldErrors(in, field, err)...)
+		}
+		return fieldErrs
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return []shared.FieldError{{In: in, Field: field, Message: err.Error()}}
+	}
+
+	path := schemaErr.JSONPointer()
+	if field != `` {
+		path = append([]string{field}, path...)
+	}
+
+	message := schemaErr.Reason
+	if schemaErr.SchemaField == `required` {
+		message = `is required`
+	}
+	return []shared.FieldError{{In: in, Field: strings.Join(path, `.`), Message: message}}
+}
+
+// responseRecorder keeps a copy of JSON responses to validate them once
+// they were sent. Other responses, such as exports, are streamed without
+// being kept.
+type responseRecorder struct {
+	http.ResponseWriter
+	json bool
+	body bytes.Buffer
+
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.wroteHeader = true
+		mediaType, _, _ := mime.ParseMediaType(r.Header().Get(echo.HeaderContentType))
+		r.json = isJSON(mediaType)
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if r.json {
+		r.body.Write(b)
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New(`response writer does not support hijacking`)
+	}
+	return hijacker.Hijack()
+}
+
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
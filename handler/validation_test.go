@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+)
+
+// newValidatingServer serves a few routes of api.yaml with handlers that
+// echo the request body, or send response when it is set.
+func newValidatingServer(t *testing.T, validateResponses bool, response interface{}) *echo.Echo {
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromFile(`../api.yaml`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handle := func(c echo.Context) error {
+		if response != nil {
+			return c.JSON(http.StatusOK, response)
+		}
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.Blob(http.StatusOK, echo.MIMEApplicationJSON, body)
+	}
+
+	e := echo.New()
+	e.Use(ValidateRequests(spec, validateResponses))
+	e.POST(`/registration`, handle)
+	e.PATCH(`/profile/:id`, handle)
+	e.PUT(`/profile/:id/password`, handle)
+	e.GET(`/admin/users/search`, handle)
+	e.GET(`/health`, handle)
+	return e
+}
+
+func serve(e *echo.Echo, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != `` {
+		req.Header.Set(echo.HeaderContentType, contentType)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestValidateRequests(t *testing.T) {
+	e := newValidatingServer(t, false, nil)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		errors      []shared.FieldError
+	}{
+		{
+			name:        `ValidBody`,
+			method:      http.MethodPost,
+			target:      `/registration`,
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"phone_number":"+628123456789","full_name":"Budi","password":"Password1!"}`,
+		},
+		{
+			name:        `InvalidBody`,
+			method:      http.MethodPost,
+			target:      `/registration`,
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"phone_number":"08123456789","password":"Password1!"}`,
+			errors: []shared.FieldError{
+				{In: `body`, Field: `phone_number`, Message: `string doesn't match the regular expression "^\+62[0-9]+$"`},
+				{In: `body`, Field: `full_name`, Message: `is required`},
+			},
+		},
+		{
+			name:        `MalformedBody`,
+			method:      http.MethodPost,
+			target:      `/registration`,
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{`,
+			errors:      []shared.FieldError{{In: `body`, Message: `failed to decode request body`}},
+		},
+		{
+			name:        `MergePatch`,
+			method:      http.MethodPatch,
+			target:      `/profile/1`,
+			contentType: mergePatchMediaType + `; charset=utf-8`,
+			body:        `{"gender":"unknown","address":null}`,
+			errors: []shared.FieldError{
+				{In: `body`, Field: `gender`, Message: `value is not one of the allowed values ["male","female","other"]`},
+			},
+		},
+		{
+			// The handler reports media types the operation does not accept.
+			name:        `UndeclaredMediaType`,
+			method:      http.MethodPatch,
+			target:      `/profile/1`,
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"gender":"unknown"}`,
+		},
+		{
+			name:   `InvalidQuery`,
+			method: http.MethodGet,
+			target: `/admin/users/search?limit=1000`,
+			errors: []shared.FieldError{
+				{In: `query`, Field: `q`, Message: `value is required but missing`},
+				{In: `query`, Field: `limit`, Message: `number must be at most 100`},
+			},
+		},
+		{
+			name:   `UnknownRoute`,
+			method: http.MethodGet,
+			target: `/health?limit=1000`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(e, tt.method, tt.target, tt.contentType, tt.body)
+			if tt.errors == nil {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, tt.body, rec.Body.String())
+				return
+			}
+
+			var msg shared.ErrorMessage
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &msg))
+			assert.Equal(t, `Invalid request`, msg.ErrorMessage)
+			assert.Equal(t, tt.errors, msg.Errors)
+		})
+	}
+}
+
+// TestValidateRequestsAgreesWithUsecase checks that the specification and
+// the Validation of the usecase requests accept and reject the same bodies.
+// The usecase also checks what the specification cannot express, such as
+// the password complexity, so the fixtures only vary what both check.
+func TestValidateRequestsAgreesWithUsecase(t *testing.T) {
+	e := newValidatingServer(t, false, nil)
+
+	password := func(n int) string {
+		return `Pa1!` + strings.Repeat(`a`, n-4)
+	}
+	registration := func(phoneNumber, fullName, password string) string {
+		body, _ := json.Marshal(map[string]string{`phone_number`: phoneNumber, `full_name`: fullName, `password`: password})
+		return string(body)
+	}
+	changePassword := func(password string) string {
+		body, _ := json.Marshal(map[string]string{`password`: password})
+		return string(body)
+	}
+
+	tests := []struct {
+		method  string
+		target  string
+		newForm func() interface{ Validation() error }
+		bodies  []string
+	}{
+		{
+			method:  http.MethodPost,
+			target:  `/registration`,
+			newForm: func() interface{ Validation() error } { return new(user.UserRegistrationRequest) },
+			bodies: []string{
+				registration(`+628123456789`, `Budi`, password(6)),
+				registration(`+628123456789`, `Budi`, password(64)),
+				registration(`+628123456789`, `Budi`, password(5)),
+				registration(`+628123456789`, `Budi`, password(65)),
+				registration(`+62812345`, `Budi`, password(8)),
+				registration(`+6281234567890`, `Budi`, password(8)),
+				registration(`+62812345678901`, `Budi`, password(8)),
+				registration(`08123456789`, `Budi`, password(8)),
+				registration(`+628123456789`, `Bu`, password(8)),
+				registration(`+628123456789`, strings.Repeat(`a`, 60), password(8)),
+				registration(`+628123456789`, strings.Repeat(`a`, 61), password(8)),
+			},
+		},
+		{
+			method:  http.MethodPut,
+			target:  `/profile/1/password`,
+			newForm: func() interface{ Validation() error } { return new(user.ChangePasswordRequest) },
+			bodies: []string{
+				changePassword(password(5)),
+				changePassword(password(6)),
+				changePassword(password(64)),
+				changePassword(password(65)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, body := range tt.bodies {
+			form := tt.newForm()
+			if !assert.NoError(t, json.Unmarshal([]byte(body), form)) {
+				continue
+			}
+			err := form.Validation()
+
+			rec := serve(e, tt.method, tt.target, echo.MIMEApplicationJSON, body)
+			assert.Equal(t, err == nil, rec.Code == http.StatusOK, "%s %s\nusecase: %v\nmiddleware: %s", tt.target, body, err, rec.Body.String())
+		}
+	}
+}
+
+func TestValidateResponses(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	e := newValidatingServer(t, true, shared.JSONSuccess(`Success`, map[string]interface{}{`users`: nil}))
+	rec := serve(e, http.MethodGet, `/admin/users/search?q=budi`, ``, ``)
+
+	// The response is sent as it is, and the mismatch is logged.
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"code":200,"message":"Success","data":{"users":null}}`, rec.Body.String())
+	assert.Contains(t, logs.String(), `GET /admin/users/search response does not match the API specification`)
+	assert.Contains(t, logs.String(), `/data/users`)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sawitpro/technical_test/blob"
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+var errImportUsage = errors.New(`usage: main import [-format csv|jsonl] [-dry-run] [-batch-size n] [-organization id] FILE`)
+
+// RunImport implements the `import` subcommand, which creates the users read
+// from FILE, or from standard input when FILE is "-", in an organization.
+func RunImport(args []string) error {
+	flags := flag.NewFlagSet(`import`, flag.ContinueOnError)
+	format := flags.String(`format`, ``, `csv or jsonl, by default taken from the file extension`)
+	dryRun := flags.Bool(`dry-run`, false, `validate the file without creating any user`)
+	batchSize := flags.Int(`batch-size`, user.DefaultImportBatchSize, `number of users created per transaction`)
+	organizationID := flags.Int(`organization`, tenant.DefaultID, `organization the users are created in`)
+	if err := flags.Parse(args); err != nil {
+		return errImportUsage
+	}
+	if flags.NArg() != 1 || *organizationID <= 0 {
+		return errImportUsage
+	}
+
+	path := flags.Arg(0)
+	if *format == `` {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), `.`)
+	}
+
+	var body io.Reader = os.Stdin
+	if path != `-` {
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		body = file
+	}
+
+	cfg := config.NewConfig()
+	repo := repository.NewRepository(cfg)
+	storage, err := blob.NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+	uc := usecase.NewUserUsecase(cfg, repo, storage)
+
+	ctx := tenant.WithID(context.Background(), *organizationID)
+	if _, err := repo.GetOrganization(ctx, *organizationID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return fmt.Errorf(`organization %d does not exist`, *organizationID)
+		}
+		return err
+	}
+
+	res, err := uc.ImportUsers(ctx, &user.ImportUsersRequest{
+		Body:      body,
+		Format:    *format,
+		DryRun:    *dryRun,
+		BatchSize: *batchSize,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(res.Errors) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ROW\tPHONE NUMBER\tERROR")
+		for _, rowErr := range res.Errors {
+			fmt.Fprintf(w, "%d\t%s\t%s\n", rowErr.Row, rowErr.PhoneNumber, rowErr.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if res.DryRun {
+		fmt.Printf("Dry run: %d of %d rows can be imported\n", res.Imported, res.Total)
+	} else {
+		fmt.Printf("Imported %d of %d rows\n", res.Imported, res.Total)
+	}
+	if res.Failed > 0 {
+		return fmt.Errorf(`%d rows were not imported`, res.Failed)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a schedule in the five field crontab format: minute, hour, day of
+// month, month and day of week, each a *, a number, a range such as 1-5 or
+// a list of them, optionally followed by a /step. Sunday is 0 or 7. As in
+// cron, when both days are restricted a time matching either one matches.
+// The descriptors @yearly, @monthly, @weekly, @daily and @hourly are
+// accepted too.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+
+	// domAny and dowAny are set when the day fields are *.
+	domAny, dowAny bool
+}
+
+var cronDescriptors = map[string]string{
+	`@yearly`:   `0 0 1 1 *`,
+	`@annually`: `0 0 1 1 *`,
+	`@monthly`:  `0 0 1 * *`,
+	`@weekly`:   `0 0 * * 0`,
+	`@daily`:    `0 0 * * *`,
+	`@midnight`: `0 0 * * *`,
+	`@hourly`:   `0 * * * *`,
+}
+
+// ParseCron parses a crontab schedule.
+func ParseCron(spec string) (*Cron, error) {
+	if expanded, ok := cronDescriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf(`cron %q must have 5 fields`, spec)
+	}
+
+	var (
+		c   Cron
+		err error
+	)
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, err
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, err
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, err
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, err
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, err
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domAny = fields[2] == `*`
+	c.dowAny = fields[4] == `*`
+
+	return &c, nil
+}
+
+// parseCronField returns the set of values matched by field as a bitmask.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, `,`) {
+		values, step := part, 1
+		if i := strings.Index(part, `/`); i >= 0 {
+			var err error
+			values = part[:i]
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf(`invalid cron step %q`, part)
+			}
+		}
+
+		low, high := min, max
+		if values != `*` {
+			var err error
+			bounds := strings.SplitN(values, `-`, 2)
+			low, err = strconv.Atoi(bounds[0])
+			if err != nil {
+				return 0, fmt.Errorf(`invalid cron value %q`, part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf(`invalid cron value %q`, part)
+				}
+			} else if step > 1 {
+				// As in cron, 5/15 means from 5 to the end every 15.
+				high = max
+			}
+		}
+		if low < min || high > max || low > high {
+			return 0, fmt.Errorf(`cron value %q is out of range %d-%d`, part, min, max)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time after t matched by the schedule, in the
+// location of t, or the zero time when there is none within five years.
+func (c *Cron) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !c.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+func (c *Cron) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dow
+	case c.dowAny:
+		return dom
+	}
+	return dom || dow
+}
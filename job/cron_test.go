@@ -0,0 +1,50 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{``, `* * * *`, `60 * * * *`, `* 24 * * *`, `* * 0 * *`, `* * * 13 *`, `* * * * 8`, `*/0 * * * *`, `5-1 * * * *`, `a * * * *`} {
+		_, err := ParseCron(spec)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// A Wednesday, 30 seconds before midnight in UTC+7.
+	from := shared.UTC7(time.Date(2024, 1, 31, 16, 59, 30, 0, time.UTC))
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{spec: `* * * * *`, want: `2024-02-01T00:00:00+07:00`},
+		{spec: `@hourly`, want: `2024-02-01T00:00:00+07:00`},
+		{spec: `30 2 * * *`, want: `2024-02-01T02:30:00+07:00`},
+		{spec: `*/15 9-17 * * 1-5`, want: `2024-02-01T09:00:00+07:00`},
+		{spec: `0 0 * * 0`, want: `2024-02-04T00:00:00+07:00`},
+		{spec: `0 0 * * 7`, want: `2024-02-04T00:00:00+07:00`},
+		{spec: `0 0 30 * *`, want: `2024-03-30T00:00:00+07:00`},
+		{spec: `0 0 29 2 *`, want: `2024-02-29T00:00:00+07:00`},
+		{spec: `0 0 1,15 * 5`, want: `2024-02-01T00:00:00+07:00`},
+		{spec: `0 12 13 * 5`, want: `2024-02-02T12:00:00+07:00`},
+		{spec: `@yearly`, want: `2025-01-01T00:00:00+07:00`},
+	}
+	for _, tt := range tests {
+		cron, err := ParseCron(tt.spec)
+		if !assert.NoError(t, err, tt.spec) {
+			continue
+		}
+		assert.Equal(t, tt.want, cron.Next(from).Format(time.RFC3339), tt.spec)
+	}
+
+	cron, err := ParseCron(`0 0 31 2 *`)
+	if assert.NoError(t, err) {
+		assert.True(t, cron.Next(from).IsZero())
+	}
+}
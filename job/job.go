@@ -0,0 +1,145 @@
+// Package job runs background work queued in the database. Jobs are written
+// with the repository, so they can be enqueued in the same transaction as
+// the change that calls for them, and are taken by workers polling the job
+// table, which needs no broker besides the database itself.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	// DefaultMaxAttempts is how many times a job is run before it is dead
+	// when its Type does not say.
+	DefaultMaxAttempts = 5
+
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Type names a kind of job whose payload is a T, encoded as JSON.
+type Type[T any] struct {
+	Name string
+	// MaxAttempts is how many times a job is run before it is moved to the
+	// dead-letter state, DefaultMaxAttempts when 0.
+	MaxAttempts int
+}
+
+// Enqueue queues a job to run as soon as a worker is free.
+func (t Type[T]) Enqueue(ctx context.Context, repo repository.Repository, payload T) (*entity.Job, error) {
+	return t.EnqueueAt(ctx, repo, payload, repo.Now())
+}
+
+// EnqueueAt queues a job to run once runAt has passed.
+func (t Type[T]) EnqueueAt(ctx context.Context, repo repository.Repository, payload T, runAt time.Time) (*entity.Job, error) {
+	job, err := t.newJob(payload, shared.UTC7(runAt))
+	if err != nil {
+		return nil, err
+	}
+	job.CreatedAt = shared.UTC7(repo.Now())
+
+	if err = repo.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (t Type[T]) newJob(payload T, runAt time.Time) (*entity.Job, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := t.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	return &entity.Job{
+		Type:        t.Name,
+		Payload:     string(body),
+		Status:      entity.JobPending,
+		MaxAttempts: maxAttempts,
+		RunAt:       runAt,
+	}, nil
+}
+
+// Handler runs one job. An error fails the attempt, and the job is retried
+// with backoff unless it was the last attempt or the error is Permanent.
+// Handlers must return once ctx is done, and should be safe to run more
+// than once for the same job: a job whose worker stops while running it is
+// run again.
+type Handler func(ctx context.Context, job *entity.Job) error
+
+// Handle registers fn to run the jobs of t on w. At most concurrency jobs
+// of t run at once on w, or as many as w allows when it is 0.
+func Handle[T any](w *Worker, t Type[T], concurrency int, fn func(ctx context.Context, payload T) error) {
+	w.handle(t.Name, concurrency, func(ctx context.Context, job *entity.Job) error {
+		var payload T
+		if err := json.Unmarshal([]byte(job.Payload), &payload); err != nil {
+			return Permanent(err)
+		}
+
+		return fn(ctx, payload)
+	})
+}
+
+// Schedule enqueues a job of t with payload on w at the times matched by
+// the crontab spec, in UTC+7. Every worker with the same schedule may
+// enqueue it, but only one job of t is queued for each time.
+func Schedule[T any](w *Worker, spec string, t Type[T], payload T) error {
+	cron, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	w.schedule(t.Name, cron, func(at time.Time) (*entity.Job, error) {
+		return t.newJob(payload, at)
+	})
+	return nil
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent wraps an error that retrying cannot fix, such as a malformed
+// payload, so that the job is moved to the dead-letter state at once.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err is or wraps an error made by Permanent.
+func IsPermanent(err error) bool {
+	var permanentErr *permanentError
+	return errors.As(err, &permanentErr)
+}
+
+// Backoff returns the wait before retrying a job that has failed attempts
+// times: 10s doubling up to an hour.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
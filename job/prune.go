@@ -0,0 +1,39 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+type PruneJobsPayload struct {
+	// Retention is how long a succeeded job is kept after it finished.
+	Retention time.Duration `json:"retention"`
+}
+
+// PruneJobs deletes the jobs that succeeded longer than the retention ago.
+var PruneJobs = Type[PruneJobsPayload]{Name: `prune_jobs`}
+
+// SchedulePruning makes w delete succeeded jobs older than retention every
+// hour.
+func SchedulePruning(w *Worker, retention time.Duration) error {
+	Handle(w, PruneJobs, 1, func(ctx context.Context, payload PruneJobsPayload) error {
+		// The job runs in the default organization but prunes every one.
+		ctx = tenant.WithID(ctx, 0)
+		before := shared.UTC7(w.repo.Now()).Add(-payload.Retention)
+		deleted, err := w.repo.DeleteSucceededJobs(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		if deleted > 0 {
+			log.Printf(`Pruned %d succeeded jobs`, deleted)
+		}
+		return nil
+	})
+
+	return Schedule(w, `@hourly`, PruneJobs, PruneJobsPayload{Retention: retention})
+}
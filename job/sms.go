@@ -0,0 +1,84 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sawitpro/technical_test/keyring"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/sms"
+)
+
+// The fields naming the phone number and message of an SMS to the keyring.
+const (
+	smsPhoneNumberField = `job.send_sms.phone_number`
+	smsMessageField     = `job.send_sms.message`
+)
+
+var (
+	errSMSExpired   = errors.New(`the message expired before it could be sent`)
+	errSMSNoKeyring = errors.New(`the message is encrypted but no keyring is configured`)
+)
+
+// SendSMSPayload is a text message to a phone number. Messages carry
+// one-time codes, so they are encrypted when a keyring is configured, as
+// the phone number is.
+type SendSMSPayload struct {
+	PhoneNumber string `json:"phone_number"`
+	Message     string `json:"message"`
+	// Encrypted is set when PhoneNumber and Message are ciphertexts.
+	Encrypted bool `json:"encrypted,omitempty"`
+	// ExpiresAt is when the message is no longer worth sending, such as
+	// when the code it carries expires.
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SendSMS sends a text message, retrying while the gateway fails.
+var SendSMS = Type[SendSMSPayload]{Name: `send_sms`, MaxAttempts: 5}
+
+// NewSMS returns the payload sending message to phoneNumber, encrypted with
+// k unless it is nil.
+func NewSMS(k *keyring.Keyring, phoneNumber string, message string, expiresAt *time.Time) (SendSMSPayload, error) {
+	payload := SendSMSPayload{PhoneNumber: phoneNumber, Message: message, ExpiresAt: expiresAt}
+	if k == nil {
+		return payload, nil
+	}
+
+	var err error
+	if payload.PhoneNumber, err = k.Encrypt(smsPhoneNumberField, phoneNumber); err != nil {
+		return SendSMSPayload{}, err
+	}
+	if payload.Message, err = k.Encrypt(smsMessageField, message); err != nil {
+		return SendSMSPayload{}, err
+	}
+	payload.Encrypted = true
+
+	return payload, nil
+}
+
+// HandleSMS makes w send the messages of SendSMS jobs with sender,
+// decrypting them with k. A message is dropped once it has expired.
+func HandleSMS(w *Worker, sender sms.Sender, k *keyring.Keyring) {
+	Handle(w, SendSMS, 0, func(ctx context.Context, payload SendSMSPayload) error {
+		if payload.ExpiresAt != nil && !shared.UTC7(w.repo.Now()).Before(*payload.ExpiresAt) {
+			return Permanent(errSMSExpired)
+		}
+
+		if payload.Encrypted {
+			if k == nil {
+				return Permanent(errSMSNoKeyring)
+			}
+
+			var err error
+			if payload.PhoneNumber, err = k.Decrypt(smsPhoneNumberField, payload.PhoneNumber); err != nil {
+				return Permanent(err)
+			}
+			if payload.Message, err = k.Decrypt(smsMessageField, payload.Message); err != nil {
+				return Permanent(err)
+			}
+		}
+
+		return sender.Send(ctx, payload.PhoneNumber, payload.Message)
+	})
+}
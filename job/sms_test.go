@@ -0,0 +1,122 @@
+package job
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/keyring"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentSMS struct {
+	phoneNumber string
+	message     string
+}
+
+// recordingSender keeps the messages it is asked to send, failing while
+// fails is positive.
+type recordingSender struct {
+	sent  []sentSMS
+	fails int
+}
+
+func (s *recordingSender) Send(ctx context.Context, phoneNumber string, message string) error {
+	if s.fails > 0 {
+		s.fails--
+		return errors.New(`gateway unavailable`)
+	}
+
+	s.sent = append(s.sent, sentSMS{phoneNumber: phoneNumber, message: message})
+	return nil
+}
+
+func TestSendSMS(t *testing.T) {
+	ctx := context.Background()
+
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat(`a`, keyring.KeySize)))
+	k, err := keyring.Parse([]byte(fmt.Sprintf(`{"primary":"k1","keys":{"k1":%q},"index_key":%q}`, key, key)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(`Encrypted`, func(t *testing.T) {
+		w, repo := newTestWorker(4)
+		sender := &recordingSender{fails: 1}
+		HandleSMS(w, sender, k)
+
+		payload, err := NewSMS(k, `+628123456781`, `Your verification code is 123456.`, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		job, err := SendSMS.Enqueue(ctx, repo, payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// Neither the phone number nor the code are stored in the clear.
+		stored := getJob(t, repo, job.ID)
+		assert.NotContains(t, stored.Payload, `+628123456781`)
+		assert.NotContains(t, stored.Payload, `123456`)
+
+		// A failed send is retried.
+		assert.Equal(t, 1, poll(t, w))
+		assert.Empty(t, sender.sent)
+		repo.advance(Backoff(1))
+		assert.Equal(t, 1, poll(t, w))
+
+		assert.Equal(t, entity.JobSucceeded, getJob(t, repo, job.ID).Status)
+		assert.Equal(t, []sentSMS{{phoneNumber: `+628123456781`, message: `Your verification code is 123456.`}}, sender.sent)
+	})
+
+	t.Run(`Expired`, func(t *testing.T) {
+		w, repo := newTestWorker(4)
+		sender := &recordingSender{fails: 1}
+		HandleSMS(w, sender, nil)
+
+		expiresAt := repo.Now().Add(Backoff(1) / 2)
+		payload, err := NewSMS(nil, `+628123456781`, `Your verification code is 123456.`, &expiresAt)
+		if !assert.NoError(t, err) {
+			return
+		}
+		job, err := SendSMS.Enqueue(ctx, repo, payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// The retry comes too late for the code.
+		assert.Equal(t, 1, poll(t, w))
+		repo.advance(Backoff(1))
+		assert.Equal(t, 1, poll(t, w))
+
+		got := getJob(t, repo, job.ID)
+		assert.Equal(t, entity.JobDead, got.Status)
+		assert.Equal(t, errSMSExpired.Error(), got.LastError)
+		assert.Empty(t, sender.sent)
+	})
+
+	t.Run(`NoKeyring`, func(t *testing.T) {
+		w, repo := newTestWorker(4)
+		sender := &recordingSender{}
+		HandleSMS(w, sender, nil)
+
+		payload, err := NewSMS(k, `+628123456781`, `Your verification code is 123456.`, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		job, err := SendSMS.Enqueue(ctx, repo, payload)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, 1, poll(t, w))
+		got := getJob(t, repo, job.ID)
+		assert.Equal(t, entity.JobDead, got.Status)
+		assert.Equal(t, errSMSNoKeyring.Error(), got.LastError)
+		assert.Empty(t, sender.sent)
+	})
+}
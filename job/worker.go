@@ -0,0 +1,319 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+// lockGrace is how long after its timeout a running job may be taken by
+// another worker, by which time its handler has been cancelled.
+const lockGrace = time.Minute
+
+var errAbandoned = errors.New(`the worker running the last attempt stopped`)
+
+type registration struct {
+	handle      Handler
+	concurrency int
+	running     int
+}
+
+type schedule struct {
+	name   string
+	cron   *Cron
+	newJob func(at time.Time) (*entity.Job, error)
+	next   time.Time
+}
+
+// Worker runs the jobs of the types registered with Handle. Any number of
+// workers may share a database: each claim locks the jobs it takes, so a
+// job is run by one worker at a time. A job whose worker stops while
+// running it is taken again once its lock expires, timeout plus a minute
+// after it was claimed.
+type Worker struct {
+	repo            repository.Repository
+	interval        time.Duration
+	concurrency     int
+	timeout         time.Duration
+	shutdownTimeout time.Duration
+
+	handlers  map[string]*registration
+	schedules []*schedule
+
+	// jobCtx is the parent of the contexts of running jobs, cancelled
+	// when they outlive the shutdown timeout.
+	jobCtx     context.Context
+	cancelJobs context.CancelFunc
+	wg         sync.WaitGroup
+	// wake is signalled when a job finishes, so that a busy queue does not
+	// wait for the next poll to fill the free slot.
+	wake chan struct{}
+
+	// mu guards the running counts.
+	mu      sync.Mutex
+	running int
+}
+
+// NewWorker returns a worker polling for due jobs every interval and
+// running up to concurrency of them at once, each for at most timeout.
+func NewWorker(repo repository.Repository, interval time.Duration, concurrency int, timeout time.Duration, shutdownTimeout time.Duration) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Worker{
+		repo:            repo,
+		interval:        interval,
+		concurrency:     concurrency,
+		timeout:         timeout,
+		shutdownTimeout: shutdownTimeout,
+		handlers:        map[string]*registration{},
+		jobCtx:          jobCtx,
+		cancelJobs:      cancelJobs,
+		wake:            make(chan struct{}, 1),
+	}
+}
+
+func (w *Worker) handle(name string, concurrency int, handler Handler) {
+	w.handlers[name] = &registration{handle: handler, concurrency: concurrency}
+}
+
+func (w *Worker) schedule(name string, cron *Cron, newJob func(at time.Time) (*entity.Job, error)) {
+	w.schedules = append(w.schedules, &schedule{
+		name:   name,
+		cron:   cron,
+		newJob: newJob,
+		next:   cron.Next(shared.UTC7(w.repo.Now())),
+	})
+}
+
+// Run enqueues scheduled jobs and runs due ones every interval until ctx is
+// done. It then stops taking jobs and waits up to the shutdown timeout for
+// the running ones, whose contexts are cancelled after that. A job
+// interrupted this way is queued again without counting the attempt.
+func (w *Worker) Run(ctx context.Context) {
+	defer w.cancelJobs()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.enqueueScheduled(ctx)
+		if _, err := w.Poll(ctx); err != nil && ctx.Err() == nil {
+			log.Printf(`Job poll error %s`, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			w.shutdown()
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+func (w *Worker) shutdown() {
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(w.shutdownTimeout):
+		log.Printf(`Jobs still running after %s, cancelling them`, w.shutdownTimeout)
+		w.cancelJobs()
+		<-done
+	}
+}
+
+// Poll claims as many due jobs as there are free slots and starts them. It
+// returns how many were started.
+func (w *Worker) Poll(ctx context.Context) (int, error) {
+	now := shared.UTC7(w.repo.Now())
+
+	started := 0
+	for _, types := range w.claimGroups() {
+		limit := w.free(types)
+		if limit == 0 {
+			continue
+		}
+
+		jobs, err := w.repo.ClaimJobs(ctx, entity.JobClaim{
+			Types:        types,
+			Now:          now,
+			LockedBefore: now.Add(-w.timeout - lockGrace),
+			Limit:        limit,
+		})
+		if err != nil {
+			return started, err
+		}
+
+		for i := range jobs {
+			w.start(&jobs[i])
+		}
+		started += len(jobs)
+	}
+
+	return started, nil
+}
+
+// claimGroups returns the types with a concurrency limit one at a time,
+// followed by all the others together.
+func (w *Worker) claimGroups() [][]string {
+	var (
+		groups    [][]string
+		unlimited []string
+	)
+	for name, reg := range w.handlers {
+		if reg.concurrency > 0 {
+			groups = append(groups, []string{name})
+		} else {
+			unlimited = append(unlimited, name)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	if len(unlimited) > 0 {
+		sort.Strings(unlimited)
+		groups = append(groups, unlimited)
+	}
+
+	return groups
+}
+
+// free returns how many jobs of types may be started now.
+func (w *Worker) free(types []string) int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	free := w.concurrency - w.running
+	if reg := w.handlers[types[0]]; len(types) == 1 && reg.concurrency > 0 && reg.concurrency-reg.running < free {
+		free = reg.concurrency - reg.running
+	}
+	if free < 0 {
+		return 0
+	}
+	return free
+}
+
+func (w *Worker) start(job *entity.Job) {
+	reg := w.handlers[job.Type]
+
+	w.mu.Lock()
+	w.running++
+	reg.running++
+	w.mu.Unlock()
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		w.run(job, reg.handle)
+
+		w.mu.Lock()
+		w.running--
+		reg.running--
+		w.mu.Unlock()
+
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}()
+}
+
+// run runs job in the organization it was enqueued in and saves the
+// outcome.
+func (w *Worker) run(job *entity.Job, handle Handler) {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		job.Attempts = job.MaxAttempts
+		err = errAbandoned
+	} else {
+		err = w.call(tenant.WithID(w.jobCtx, job.OrganizationID), job, handle)
+	}
+
+	now := shared.UTC7(w.repo.Now())
+	job.LockedAt = nil
+	switch {
+	case err == nil:
+		job.Status = entity.JobSucceeded
+		job.LastError = ``
+		job.FinishedAt = &now
+	case w.jobCtx.Err() != nil && !errors.Is(err, errAbandoned):
+		job.Status = entity.JobPending
+		job.Attempts--
+	case IsPermanent(err) || errors.Is(err, errAbandoned) || job.Attempts >= job.MaxAttempts:
+		job.Status = entity.JobDead
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		log.Printf(`Job %d %s is dead after %d attempts: %s`, job.ID, job.Type, job.Attempts, job.LastError)
+	default:
+		job.Status = entity.JobPending
+		job.LastError = err.Error()
+		job.RunAt = now.Add(Backoff(job.Attempts))
+	}
+
+	// The outcome is saved even while shutting down, or the job would only
+	// be run again once its lock expires.
+	if err = w.repo.UpdateJob(context.Background(), job); err != nil {
+		log.Printf(`Save job %d error %s`, job.ID, err.Error())
+	}
+}
+
+// call runs handle for at most the job timeout, turning a panic into an
+// error.
+func (w *Worker) call(ctx context.Context, job *entity.Job, handle Handler) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf(`panic: %v`, p)
+		}
+	}()
+
+	return handle(ctx, job)
+}
+
+// enqueueScheduled enqueues a job for every schedule whose time has come.
+// The job is keyed by its schedule and time, so that other workers with the
+// same schedule do not enqueue it again.
+func (w *Worker) enqueueScheduled(ctx context.Context) {
+	now := shared.UTC7(w.repo.Now())
+
+	for _, s := range w.schedules {
+		if s.next.IsZero() || now.Before(s.next) {
+			continue
+		}
+
+		job, err := s.newJob(s.next)
+		if err == nil {
+			key := s.name + `@` + s.next.Format(time.RFC3339)
+			job.UniqueKey = &key
+			job.CreatedAt = now
+			err = w.repo.CreateJob(ctx, job)
+		}
+		if err != nil {
+			// The time is kept, so the job is enqueued on the next poll.
+			log.Printf(`Enqueue scheduled job %s error %s`, s.name, err.Error())
+			continue
+		}
+
+		s.next = s.cron.Next(now)
+	}
+}
@@ -0,0 +1,307 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// clockRepository is a memory repository whose clock the test moves.
+type clockRepository struct {
+	repository.Repository
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (r *clockRepository) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+func (r *clockRepository) advance(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = r.now.Add(d)
+}
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+var greet = Type[greeting]{Name: `greet`, MaxAttempts: 3}
+
+func newTestWorker(concurrency int) (*Worker, *clockRepository) {
+	repo := &clockRepository{Repository: repository.NewMemoryRepository(), now: shared.UTC7(time.Now())}
+	return NewWorker(repo, time.Hour, concurrency, time.Minute, time.Minute), repo
+}
+
+// poll runs one poll and waits for the jobs it started.
+func poll(t *testing.T, w *Worker) int {
+	n, err := w.Poll(context.Background())
+	assert.NoError(t, err)
+	w.wg.Wait()
+	return n
+}
+
+func getJob(t *testing.T, repo repository.Repository, jobID int) *entity.Job {
+	job, err := repo.GetJob(context.Background(), jobID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return job
+}
+
+func TestWorkerRetriesWithBackoff(t *testing.T) {
+	ctx := tenant.WithID(context.Background(), 2)
+	w, repo := newTestWorker(4)
+
+	var (
+		names []string
+		orgs  []int
+		fails = 1
+	)
+	Handle(w, greet, 0, func(ctx context.Context, payload greeting) error {
+		names = append(names, payload.Name)
+		orgID, _ := tenant.ID(ctx)
+		orgs = append(orgs, orgID)
+		if fails > 0 {
+			fails--
+			return errors.New(`unavailable`)
+		}
+		return nil
+	})
+
+	job, err := greet.Enqueue(ctx, repo, greeting{Name: `Budi`})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, 1, poll(t, w))
+	got := getJob(t, repo, job.ID)
+	assert.Equal(t, entity.JobPending, got.Status)
+	assert.Equal(t, 1, got.Attempts)
+	assert.Equal(t, `unavailable`, got.LastError)
+	assert.Nil(t, got.LockedAt)
+	assert.True(t, got.RunAt.Equal(repo.Now().Add(Backoff(1))))
+
+	// The retry is not due before its backoff has passed.
+	assert.Equal(t, 0, poll(t, w))
+	repo.advance(Backoff(1))
+	assert.Equal(t, 1, poll(t, w))
+
+	got = getJob(t, repo, job.ID)
+	assert.Equal(t, entity.JobSucceeded, got.Status)
+	assert.Equal(t, 2, got.Attempts)
+	assert.Empty(t, got.LastError)
+	assert.NotNil(t, got.FinishedAt)
+	assert.Equal(t, []string{`Budi`, `Budi`}, names)
+	assert.Equal(t, []int{2, 2}, orgs)
+}
+
+func TestWorkerDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	w, repo := newTestWorker(4)
+
+	Handle(w, greet, 0, func(ctx context.Context, payload greeting) error {
+		switch payload.Name {
+		case `permanent`:
+			return Permanent(errors.New(`unknown user`))
+		case `panic`:
+			panic(`boom`)
+		}
+		return errors.New(`unavailable`)
+	})
+
+	failing, _ := greet.Enqueue(ctx, repo, greeting{Name: `failing`})
+	permanent, _ := greet.Enqueue(ctx, repo, greeting{Name: `permanent`})
+	panicking, _ := greet.Enqueue(ctx, repo, greeting{Name: `panic`})
+	malformed := &entity.Job{Type: greet.Name, Payload: `{`, Status: entity.JobPending, MaxAttempts: 3, RunAt: repo.Now()}
+	assert.NoError(t, repo.CreateJob(ctx, malformed))
+
+	for i := 0; i < greet.MaxAttempts; i++ {
+		poll(t, w)
+		repo.advance(maxBackoff)
+	}
+
+	for _, tt := range []struct {
+		job      *entity.Job
+		attempts int
+		err      string
+	}{
+		{job: failing, attempts: 3, err: `unavailable`},
+		{job: permanent, attempts: 1, err: `unknown user`},
+		{job: panicking, attempts: 3, err: `panic: boom`},
+		{job: malformed, attempts: 1, err: `unexpected end of JSON input`},
+	} {
+		got := getJob(t, repo, tt.job.ID)
+		assert.Equal(t, entity.JobDead, got.Status, tt.err)
+		assert.Equal(t, tt.attempts, got.Attempts, tt.err)
+		assert.Equal(t, tt.err, got.LastError)
+		assert.NotNil(t, got.FinishedAt, tt.err)
+	}
+}
+
+func TestWorkerConcurrency(t *testing.T) {
+	ctx := context.Background()
+	w, repo := newTestWorker(3)
+
+	release := make(chan struct{})
+	handler := func(ctx context.Context, payload greeting) error {
+		<-release
+		return nil
+	}
+	limited := Type[greeting]{Name: `limited`}
+	Handle(w, limited, 1, handler)
+	Handle(w, greet, 0, handler)
+
+	for i := 0; i < 3; i++ {
+		_, err := limited.Enqueue(ctx, repo, greeting{})
+		assert.NoError(t, err)
+		_, err = greet.Enqueue(ctx, repo, greeting{})
+		assert.NoError(t, err)
+	}
+
+	n, err := w.Poll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, 1, w.handlers[limited.Name].running)
+	assert.Equal(t, 2, w.handlers[greet.Name].running)
+
+	// Every slot is taken.
+	n, err = w.Poll(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	// One limited job is left waiting for the next poll.
+	close(release)
+	w.wg.Wait()
+	assert.Equal(t, 2, poll(t, w))
+	assert.Equal(t, 1, poll(t, w))
+}
+
+func TestWorkerAbandonedJob(t *testing.T) {
+	ctx := context.Background()
+	w, repo := newTestWorker(1)
+
+	Handle(w, greet, 0, func(ctx context.Context, payload greeting) error {
+		return errors.New(`unavailable`)
+	})
+
+	// A worker claimed the last attempt and stopped before saving it.
+	lockedAt := repo.Now()
+	job := &entity.Job{Type: greet.Name, Payload: `{}`, Status: entity.JobRunning, Attempts: 3, MaxAttempts: 3, RunAt: lockedAt, LockedAt: &lockedAt}
+	assert.NoError(t, repo.CreateJob(ctx, job))
+
+	assert.Equal(t, 0, poll(t, w))
+	repo.advance(w.timeout + lockGrace)
+	assert.Equal(t, 1, poll(t, w))
+
+	got := getJob(t, repo, job.ID)
+	assert.Equal(t, entity.JobDead, got.Status)
+	assert.Equal(t, 3, got.Attempts)
+	assert.Equal(t, errAbandoned.Error(), got.LastError)
+}
+
+func TestWorkerSchedule(t *testing.T) {
+	ctx := context.Background()
+	w, repo := newTestWorker(1)
+	other := NewWorker(repo, time.Hour, 1, time.Minute, time.Minute)
+
+	for _, worker := range []*Worker{w, other} {
+		assert.NoError(t, Schedule(worker, `@hourly`, greet, greeting{Name: `hourly`}))
+	}
+	assert.Error(t, Schedule(w, `@fortnightly`, greet, greeting{}))
+
+	next := w.schedules[0].next
+	w.enqueueScheduled(ctx)
+	repo.advance(next.Sub(repo.Now()))
+	w.enqueueScheduled(ctx)
+	other.enqueueScheduled(ctx)
+
+	claimed, err := repo.ClaimJobs(ctx, entity.JobClaim{Types: []string{greet.Name}, Now: repo.Now(), Limit: 10})
+	if assert.NoError(t, err) && assert.Len(t, claimed, 1) {
+		assert.Equal(t, `{"name":"hourly"}`, claimed[0].Payload)
+		assert.True(t, claimed[0].RunAt.Equal(next))
+		assert.Equal(t, `greet@`+next.Format(time.RFC3339), *claimed[0].UniqueKey)
+	}
+	assert.True(t, w.schedules[0].next.Equal(next.Add(time.Hour)))
+}
+
+func TestWorkerShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, repo := newTestWorker(2)
+	w.shutdownTimeout = 10 * time.Millisecond
+
+	started := make(chan struct{}, 2)
+	Handle(w, greet, 0, func(ctx context.Context, payload greeting) error {
+		started <- struct{}{}
+		if payload.Name == `quick` {
+			return nil
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	quick, _ := greet.Enqueue(ctx, repo, greeting{Name: `quick`})
+	slow, _ := greet.Enqueue(ctx, repo, greeting{Name: `slow`})
+
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	<-started
+	<-started
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`Run did not return`)
+	}
+
+	assert.Equal(t, entity.JobSucceeded, getJob(t, repo, quick.ID).Status)
+
+	// The interrupted job is queued again without counting the attempt.
+	got := getJob(t, repo, slow.ID)
+	assert.Equal(t, entity.JobPending, got.Status)
+	assert.Equal(t, 0, got.Attempts)
+	assert.Nil(t, got.LockedAt)
+}
+
+func TestSchedulePruning(t *testing.T) {
+	ctx := context.Background()
+	w, repo := newTestWorker(1)
+	assert.NoError(t, SchedulePruning(w, time.Hour))
+
+	now := repo.Now()
+	old := now.Add(-2 * time.Hour)
+	jobs := []*entity.Job{
+		{OrganizationID: 2, Type: greet.Name, Payload: `{}`, Status: entity.JobSucceeded, MaxAttempts: 3, RunAt: old, FinishedAt: &old},
+		{Type: greet.Name, Payload: `{}`, Status: entity.JobSucceeded, MaxAttempts: 3, RunAt: now, FinishedAt: &now},
+		{Type: greet.Name, Payload: `{}`, Status: entity.JobDead, MaxAttempts: 3, RunAt: old, FinishedAt: &old},
+	}
+	for _, job := range jobs {
+		assert.NoError(t, repo.CreateJob(ctx, job))
+	}
+
+	_, err := PruneJobs.Enqueue(ctx, repo, PruneJobsPayload{Retention: time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, poll(t, w))
+
+	_, err = repo.GetJob(ctx, jobs[0].ID)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	for _, job := range jobs[1:] {
+		_, err = repo.GetJob(ctx, job.ID)
+		assert.NoError(t, err)
+	}
+}
@@ -0,0 +1,200 @@
+// Package keyring encrypts personal data fields with AES-256-GCM and
+// computes the blind indexes that let encrypted fields be found by value.
+//
+// Keys form a two-level hierarchy. The keyring file holds master keys, of
+// which the primary encrypts new values while the others only decrypt the
+// values not yet re-encrypted. A field is never encrypted with a master key
+// directly but with a data key derived from it for that field with HKDF, so
+// a ciphertext cannot be moved to another field. Blind indexes are HMAC
+// digests keyed by a key derived the same way from the separate index key,
+// which cannot be rotated without recomputing every index.
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// KeySize is the size of every key in the keyring, in bytes.
+const KeySize = 32
+
+var (
+	ErrUnknownKey = errors.New(`keyring: unknown key`)
+	ErrMalformed  = errors.New(`keyring: malformed ciphertext`)
+)
+
+// file is the JSON layout of a keyring file. Keys are base64 encoded.
+type file struct {
+	Primary  string            `json:"primary"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+type Keyring struct {
+	primary  string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+// Load reads the keyring file at path, such as:
+//
+//	{
+//	  "primary": "2024-06",
+//	  "keys": {"2024-01": "<base64>", "2024-06": "<base64>"},
+//	  "index_key": "<base64>"
+//	}
+func Load(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Parse(data)
+}
+
+// Parse reads a keyring in the format of Load.
+func Parse(data []byte) (*Keyring, error) {
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf(`keyring: %w`, err)
+	}
+
+	k := &Keyring{
+		primary: f.Primary,
+		keys:    make(map[string][]byte, len(f.Keys)),
+	}
+	for id, encoded := range f.Keys {
+		if id == `` || strings.Contains(id, `:`) {
+			return nil, fmt.Errorf(`keyring: key id %q must be non-empty and cannot contain ":"`, id)
+		}
+
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf(`keyring: key %q: %w`, id, err)
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[k.primary]; !ok {
+		return nil, fmt.Errorf(`keyring: primary key %q is not in keys`, k.primary)
+	}
+
+	key, err := decodeKey(f.IndexKey)
+	if err != nil {
+		return nil, fmt.Errorf(`keyring: index key: %w`, err)
+	}
+	k.indexKey = key
+
+	return k, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf(`must be %d bytes, got %d`, KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// Primary returns the id of the key new values are encrypted with.
+func (k *Keyring) Primary() string {
+	return k.primary
+}
+
+// Encrypt encrypts the value of field with the primary key. The result
+// starts with the id of the key followed by a colon.
+func (k *Keyring) Encrypt(field string, plaintext string) (string, error) {
+	aead, err := k.aead(k.primary, field)
+	if err != nil {
+		return ``, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return ``, err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(field))
+
+	return k.primary + `:` + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value of field encrypted by Encrypt with any key of
+// the keyring.
+func (k *Keyring) Decrypt(field string, ciphertext string) (string, error) {
+	id, encoded, ok := strings.Cut(ciphertext, `:`)
+	if !ok {
+		return ``, ErrMalformed
+	}
+
+	aead, err := k.aead(id, field)
+	if err != nil {
+		return ``, err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return ``, ErrMalformed
+	}
+
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(field))
+	if err != nil {
+		return ``, fmt.Errorf(`keyring: decrypt %s: %w`, field, err)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsPrimary reports whether ciphertext was encrypted with the primary key,
+// so that re-encrypting it would not change the key.
+func (k *Keyring) IsPrimary(ciphertext string) bool {
+	return strings.HasPrefix(ciphertext, k.primary+`:`)
+}
+
+// BlindIndex returns the hex HMAC-SHA256 of the value of field. Equal values
+// have equal indexes, so an encrypted field can be looked up or kept unique
+// through its index without being decrypted.
+func (k *Keyring) BlindIndex(field string, value string) string {
+	mac := hmac.New(sha256.New, derive(k.indexKey, `index:`+field))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (k *Keyring) aead(id string, field string) (cipher.AEAD, error) {
+	master, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf(`%w %q`, ErrUnknownKey, id)
+	}
+
+	block, err := aes.NewCipher(derive(master, `encrypt:`+field))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// derive returns the key for purpose derived from key.
+func derive(key []byte, purpose string) []byte {
+	derived := make([]byte, KeySize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, nil, []byte(purpose)), derived); err != nil {
+		panic(err)
+	}
+
+	return derived
+}
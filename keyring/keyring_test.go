@@ -0,0 +1,110 @@
+package keyring
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), KeySize)))
+}
+
+func testKeyring(t *testing.T, primary string) *Keyring {
+	k, err := Parse([]byte(fmt.Sprintf(`{"primary":%q,"keys":{"k1":%q,"k2":%q},"index_key":%q}`,
+		primary, testKey('a'), testKey('b'), testKey('i'))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func TestEncrypt(t *testing.T) {
+	k1 := testKeyring(t, `k1`)
+
+	t.Run(`RoundTrip`, func(t *testing.T) {
+		ciphertext, err := k1.Encrypt(`user.phone_number`, `+628123456781`)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(ciphertext, `k1:`))
+		assert.NotContains(t, ciphertext, `628123456781`)
+
+		plaintext, err := k1.Decrypt(`user.phone_number`, ciphertext)
+		assert.NoError(t, err)
+		assert.Equal(t, `+628123456781`, plaintext)
+
+		again, _ := k1.Encrypt(`user.phone_number`, `+628123456781`)
+		assert.NotEqual(t, ciphertext, again)
+	})
+
+	t.Run(`Rotation`, func(t *testing.T) {
+		old, _ := k1.Encrypt(`user.phone_number`, `+628123456781`)
+
+		k2 := testKeyring(t, `k2`)
+		assert.False(t, k2.IsPrimary(old))
+		plaintext, err := k2.Decrypt(`user.phone_number`, old)
+		assert.NoError(t, err)
+		assert.Equal(t, `+628123456781`, plaintext)
+
+		rotated, _ := k2.Encrypt(`user.phone_number`, plaintext)
+		assert.True(t, k2.IsPrimary(rotated))
+	})
+
+	t.Run(`Rejects`, func(t *testing.T) {
+		ciphertext, _ := k1.Encrypt(`user.phone_number`, `+628123456781`)
+
+		_, err := k1.Decrypt(`user.address`, ciphertext)
+		assert.Error(t, err)
+
+		tampered := ciphertext[:len(ciphertext)-2] + `AA`
+		if tampered == ciphertext {
+			tampered = ciphertext[:len(ciphertext)-2] + `BB`
+		}
+		_, err = k1.Decrypt(`user.phone_number`, tampered)
+		assert.Error(t, err)
+
+		_, err = k1.Decrypt(`user.phone_number`, `k3:`+strings.TrimPrefix(ciphertext, `k1:`))
+		assert.ErrorIs(t, err, ErrUnknownKey)
+
+		_, err = k1.Decrypt(`user.phone_number`, `+628123456781`)
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
+
+func TestBlindIndex(t *testing.T) {
+	k1 := testKeyring(t, `k1`)
+	k2 := testKeyring(t, `k2`)
+
+	index := k1.BlindIndex(`user.phone_number`, `+628123456781`)
+	assert.Len(t, index, 64)
+	assert.Equal(t, index, k2.BlindIndex(`user.phone_number`, `+628123456781`))
+	assert.NotEqual(t, index, k1.BlindIndex(`user.phone_number`, `+628123456782`))
+	assert.NotEqual(t, index, k1.BlindIndex(`user.address`, `+628123456781`))
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `keyring.json`)
+	data := fmt.Sprintf(`{"primary":"k1","keys":{"k1":%q},"index_key":%q}`, testKey('a'), testKey('i'))
+	assert.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	k, err := Load(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `k1`, k.Primary())
+	}
+
+	invalid := []string{
+		`{`,
+		fmt.Sprintf(`{"primary":"k2","keys":{"k1":%q},"index_key":%q}`, testKey('a'), testKey('i')),
+		fmt.Sprintf(`{"primary":"k:1","keys":{"k:1":%q},"index_key":%q}`, testKey('a'), testKey('i')),
+		fmt.Sprintf(`{"primary":"k1","keys":{"k1":"c2hvcnQ="},"index_key":%q}`, testKey('i')),
+		fmt.Sprintf(`{"primary":"k1","keys":{"k1":%q}}`, testKey('a')),
+	}
+	for _, data := range invalid {
+		_, err := Parse([]byte(data))
+		assert.Error(t, err, data)
+	}
+}
@@ -1,28 +1,66 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq"
 	"github.com/oapi-codegen/runtime"
+	"github.com/sawitpro/technical_test/blob"
 	"github.com/sawitpro/technical_test/config"
 	"github.com/sawitpro/technical_test/handler"
+	"github.com/sawitpro/technical_test/outbox"
 	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/usecase"
+	"github.com/sawitpro/technical_test/webhook"
 )
 
 func main() {
-	InitServer()
+	if len(os.Args) < 2 {
+		InitServer()
+		return
+	}
+
+	var err error
+	switch os.Args[1] {
+	case `migrate`:
+		err = RunMigrate(os.Args[2:])
+	case `import`:
+		err = RunImport(os.Args[2:])
+	case `export`:
+		err = RunExport(os.Args[2:])
+	case `reencrypt`:
+		err = RunReencrypt(os.Args[2:])
+	case `worker`:
+		err = RunWorker(os.Args[2:])
+	default:
+		err = fmt.Errorf(`unknown command %s`, os.Args[1])
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
+// InitServer serves the API until it is interrupted, then stops taking
+// requests and waits for the running requests and background jobs.
 func InitServer() {
 	echoServer := echo.New()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg := config.NewConfig()
 
 	serverPort, err := strconv.Atoi(os.Getenv("SERVER_PORT"))
@@ -31,14 +69,103 @@ func InitServer() {
 	}
 
 	repo := repository.NewRepository(cfg)
-	uc := usecase.NewUserUsecase(cfg, repo)
+	storage, err := blob.NewStorage(cfg)
+	if err != nil {
+		log.Panic(err)
+	}
+	uc := usecase.NewUserUsecase(cfg, repo, storage)
 	hand := handler.NewHandler(uc)
 
+	var sinks []outbox.Sink
+	if cfg.Webhooks {
+		sinks = append(sinks, webhook.NewSink(repo))
+
+		deliverer := webhook.NewDeliverer(repo, webhook.NewClient(cfg.WebhookTimeout), cfg.WebhookInterval, cfg.WebhookMaxAttempts)
+		go deliverer.Run(ctx)
+	}
+	sink, err := outbox.NewSink(cfg)
+	if err != nil {
+		log.Panic(err)
+	}
+	if sink != nil {
+		sinks = append(sinks, sink)
+	}
+	if len(sinks) > 0 {
+		dispatcher := outbox.NewDispatcher(repo, outbox.NewMultiSink(sinks...), cfg.OutboxInterval, cfg.OutboxBatchSize)
+		go dispatcher.Run(ctx)
+	}
+
+	workerDone := make(chan struct{})
+	if cfg.JobWorker {
+		worker, err := NewWorker(cfg, repo)
+		if err != nil {
+			log.Panic(err)
+		}
+		go func() {
+			worker.Run(ctx)
+			close(workerDone)
+		}()
+	} else {
+		close(workerDone)
+	}
+
+	// Local files are served by the application unless BLOB_BASE_URL points
+	// somewhere else, such as a reverse proxy serving BLOB_DIR.
+	if cfg.BlobStorage == config.BlobStorageLocal && strings.HasPrefix(cfg.BlobBaseURL, `/`) {
+		echoServer.Static(cfg.BlobBaseURL, cfg.BlobDir)
+	}
+
+	if cfg.DebugVars {
+		echoServer.GET("/debug/vars", echo.WrapHandler(expvar.Handler()))
+	}
+
+	var spec *openapi3.T
+	if cfg.ValidateRequests || cfg.APIDocs {
+		spec, err = LoadSpec()
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
+	if cfg.APIDocs {
+		docs, err := handler.NewAPIDocs(apiSpec, spec)
+		if err != nil {
+			log.Panic(err)
+		}
+		echoServer.GET("/openapi.yaml", docs.YAML)
+		echoServer.GET("/openapi.json", docs.JSON)
+		echoServer.GET("/docs", docs.Page)
+	}
+
+	echoServer.Use(handler.RequestID())
+	echoServer.Use(handler.ReadYourWrites())
+	echoServer.Use(handler.AuditImpersonation(uc))
+	if cfg.ValidateRequests {
+		echoServer.Use(handler.ValidateRequests(spec, cfg.ValidateResponses))
+	}
 	RegisterHandlers(echoServer, hand, cfg)
 
-	if err := echoServer.Start(fmt.Sprintf(":%d", serverPort)); err != nil {
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		if err := echoServer.Shutdown(shutdownCtx); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	if err := echoServer.Start(fmt.Sprintf(":%d", serverPort)); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Println(err)
 	}
+
+	// The worker stops once ctx is done, which is also the case when the
+	// server failed to start.
+	stop()
+	<-serverDone
+	<-workerDone
 }
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
@@ -87,6 +214,38 @@ func (w *ServerInterfaceWrapper) UpdateUserProfile(ctx echo.Context) error {
 	return err
 }
 
+// PatchUserProfile converts echo context to params.
+func (w *ServerInterfaceWrapper) PatchUserProfile(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.PatchUserProfile(ctx, id)
+	return err
+}
+
+// UploadAvatar converts echo context to params.
+func (w *ServerInterfaceWrapper) UploadAvatar(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UploadAvatar(ctx, id)
+	return err
+}
+
 // Registration converts echo context to params.
 func (w *ServerInterfaceWrapper) Registration(ctx echo.Context) error {
 	var err error
@@ -96,6 +255,240 @@ func (w *ServerInterfaceWrapper) Registration(ctx echo.Context) error {
 	return err
 }
 
+// Reauthenticate converts echo context to params.
+func (w *ServerInterfaceWrapper) Reauthenticate(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.Reauthenticate(ctx)
+	return err
+}
+
+// ChangePassword converts echo context to params.
+func (w *ServerInterfaceWrapper) ChangePassword(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ChangePassword(ctx, id)
+	return err
+}
+
+// RequestPhoneNumberChange converts echo context to params.
+func (w *ServerInterfaceWrapper) RequestPhoneNumberChange(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.RequestPhoneNumberChange(ctx, id)
+	return err
+}
+
+// ConfirmPhoneNumberChange converts echo context to params.
+func (w *ServerInterfaceWrapper) ConfirmPhoneNumberChange(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ConfirmPhoneNumberChange(ctx, id)
+	return err
+}
+
+// Impersonate converts echo context to params.
+func (w *ServerInterfaceWrapper) Impersonate(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.Impersonate(ctx)
+	return err
+}
+
+// ImportUsers converts echo context to params.
+func (w *ServerInterfaceWrapper) ImportUsers(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ImportUsers(ctx)
+	return err
+}
+
+// ExportUsers converts echo context to params.
+func (w *ServerInterfaceWrapper) ExportUsers(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ExportUsers(ctx)
+	return err
+}
+
+// SearchUsers converts echo context to params.
+func (w *ServerInterfaceWrapper) SearchUsers(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.SearchUsers(ctx)
+	return err
+}
+
+// ListAuditLogs converts echo context to params.
+func (w *ServerInterfaceWrapper) ListAuditLogs(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListAuditLogs(ctx)
+	return err
+}
+
+// ListLogins converts echo context to params.
+func (w *ServerInterfaceWrapper) ListLogins(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListLogins(ctx, id)
+	return err
+}
+
+// CreateWebhook converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateWebhook(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateWebhook(ctx)
+	return err
+}
+
+// ListWebhooks converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhooks(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhooks(ctx)
+	return err
+}
+
+// DeleteWebhook converts echo context to params.
+func (w *ServerInterfaceWrapper) DeleteWebhook(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DeleteWebhook(ctx, id)
+	return err
+}
+
+// ListWebhookDeliveries converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWebhookDeliveries(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWebhookDeliveries(ctx, id)
+	return err
+}
+
+// GetWebhookDelivery converts echo context to params.
+func (w *ServerInterfaceWrapper) GetWebhookDelivery(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.GetWebhookDelivery(ctx, id)
+	return err
+}
+
+// ReplayWebhookDelivery converts echo context to params.
+func (w *ServerInterfaceWrapper) ReplayWebhookDelivery(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ReplayWebhookDelivery(ctx, id)
+	return err
+}
+
+// CreateOrganization converts echo context to params.
+func (w *ServerInterfaceWrapper) CreateOrganization(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.CreateOrganization(ctx)
+	return err
+}
+
+// AddOrganizationMember converts echo context to params.
+func (w *ServerInterfaceWrapper) AddOrganizationMember(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.AddOrganizationMember(ctx, id)
+	return err
+}
+
+// ListOrganizations converts echo context to params.
+func (w *ServerInterfaceWrapper) ListOrganizations(ctx echo.Context) error {
+	var err error
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListOrganizations(ctx)
+	return err
+}
+
 // This is a simple interface which specifies echo.Route addition functions which
 // are present on both echo.Echo and echo.Group, since we want to allow using
 // either of them for path registration
@@ -127,6 +520,27 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si handler.ServerInterface,
 	router.POST(baseURL+"/login", wrapper.Login)
 	router.GET(baseURL+"/profile/:id", wrapper.GetUserProfile, config.JWTVerify(cfg.PublicKey))
 	router.PUT(baseURL+"/profile/:id", wrapper.UpdateUserProfile, config.JWTVerify(cfg.PublicKey))
+	router.PATCH(baseURL+"/profile/:id", wrapper.PatchUserProfile, config.JWTVerify(cfg.PublicKey))
 	router.POST(baseURL+"/registration", wrapper.Registration)
+	router.POST(baseURL+"/reauthenticate", wrapper.Reauthenticate, config.JWTVerify(cfg.PublicKey))
+	router.PUT(baseURL+"/profile/:id/password", wrapper.ChangePassword, config.JWTVerify(cfg.PublicKey))
+	router.POST(baseURL+"/profile/:id/phone-number", wrapper.RequestPhoneNumberChange, config.JWTVerify(cfg.PublicKey))
+	router.POST(baseURL+"/profile/:id/phone-number/confirm", wrapper.ConfirmPhoneNumberChange, config.JWTVerify(cfg.PublicKey))
+	router.PUT(baseURL+"/profile/:id/avatar", wrapper.UploadAvatar, config.JWTVerify(cfg.PublicKey))
+	router.GET(baseURL+"/profile/:id/logins", wrapper.ListLogins, config.JWTVerify(cfg.PublicKey))
+	router.POST(baseURL+"/admin/impersonate", wrapper.Impersonate, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.POST(baseURL+"/admin/users/import", wrapper.ImportUsers, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/users/export", wrapper.ExportUsers, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/users/search", wrapper.SearchUsers, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/audit-logs", wrapper.ListAuditLogs, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.POST(baseURL+"/admin/webhooks", wrapper.CreateWebhook, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/webhooks", wrapper.ListWebhooks, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.DELETE(baseURL+"/admin/webhooks/:id", wrapper.DeleteWebhook, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/webhooks/:id/deliveries", wrapper.ListWebhookDeliveries, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/admin/webhook-deliveries/:id", wrapper.GetWebhookDelivery, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.POST(baseURL+"/admin/webhook-deliveries/:id/replay", wrapper.ReplayWebhookDelivery, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.POST(baseURL+"/admin/organizations", wrapper.CreateOrganization, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.POST(baseURL+"/admin/organizations/:id/members", wrapper.AddOrganizationMember, config.JWTVerify(cfg.PublicKey), config.RequireAdmin())
+	router.GET(baseURL+"/organizations", wrapper.ListOrganizations, config.JWTVerify(cfg.PublicKey))
 
 }
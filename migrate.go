@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/migration"
+)
+
+var errMigrateUsage = errors.New(`usage: main migrate up|down|status`)
+
+// RunMigrate implements the `migrate up|down|status` subcommand.
+func RunMigrate(args []string) error {
+	if len(args) != 1 {
+		return errMigrateUsage
+	}
+
+	switch args[0] {
+	case `up`:
+		applied, err := migration.Up(config.InitDB())
+		for _, m := range applied {
+			fmt.Printf("Applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println(`No pending migrations`)
+		}
+	case `down`:
+		reverted, err := migration.Down(config.InitDB())
+		if err != nil {
+			return err
+		}
+		if reverted == nil {
+			fmt.Println(`No applied migrations`)
+			return nil
+		}
+		fmt.Printf("Reverted %04d_%s\n", reverted.Version, reverted.Name)
+	case `status`:
+		statuses, err := migration.Statuses(config.InitDB())
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := `pending`
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errMigrateUsage
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package migration
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*/*.sql
+var files embed.FS
+
+// fileFormat matches names such as 0001_create_user_table.up.sql.
+var fileFormat = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const createSchemaMigrations = `CREATE TABLE IF NOT EXISTS schema_migrations (
+  version int NOT NULL,
+  name varchar(255) NOT NULL DEFAULT '',
+  applied_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (version)
+)`
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type schemaMigration struct {
+	Version   int       `gorm:"column:version;primary_key"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (e *schemaMigration) TableName() string {
+	return `schema_migrations`
+}
+
+// Load returns the embedded migrations for a gorm dialect, such as postgres
+// or sqlite, ordered by version. Every version must have both an up and a
+// down file.
+func Load(dialect string) ([]Migration, error) {
+	dir := path.Join(`sql`, dialect)
+	entries, err := fs.ReadDir(files, dir)
+	if err != nil {
+		return nil, fmt.Errorf(`no migrations for dialect %s: %w`, dialect, err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileFormat.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf(`invalid migration file name %s`, entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(files, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf(`migration %04d has conflicting names %s and %s`, version, m.Name, match[2])
+		}
+
+		if match[3] == `up` {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == `` || m.Down == `` {
+			return nil, fmt.Errorf(`migration %04d_%s must have both up and down files`, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones that were applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(m.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&schemaMigration{
+				Version:   m.Version,
+				Name:      m.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf(`apply migration %04d_%s: %w`, m.Version, m.Name, err)
+		}
+
+		done = append(done, m)
+	}
+
+	return done, nil
+}
+
+// Down reverts the most recently applied migration. It returns nil when
+// there is nothing to revert.
+func Down(db *gorm.DB) (*Migration, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	latest := 0
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+	if latest == 0 {
+		return nil, nil
+	}
+
+	var target *Migration
+	for i := range migrations {
+		if migrations[i].Version == latest {
+			target = &migrations[i]
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf(`migration %04d is applied but not known to this binary`, latest)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(target.Down).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&schemaMigration{}, `version = ?`, target.Version).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`revert migration %04d_%s: %w`, target.Version, target.Name, err)
+	}
+
+	return target, nil
+}
+
+// Statuses lists the embedded migrations together with when they were
+// applied, if at all.
+func Statuses(db *gorm.DB) ([]Status, error) {
+	migrations, applied, err := load(db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	for _, m := range migrations {
+		status := Status{Migration: m}
+		if record, ok := applied[m.Version]; ok {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+func load(db *gorm.DB) ([]Migration, map[int]schemaMigration, error) {
+	migrations, err := Load(db.Dialector.Name())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = db.Exec(createSchemaMigrations).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var records []schemaMigration
+	if err = db.Find(&records).Error; err != nil {
+		return nil, nil, err
+	}
+
+	applied := make(map[int]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+
+	return migrations, applied, nil
+}
@@ -0,0 +1,43 @@
+package migration
+
+import "testing"
+
+func TestLoad(t *testing.T) {
+	postgres, err := Load(`postgres`)
+	if err != nil {
+		t.Fatalf("Load(postgres) error = %v", err)
+	}
+
+	if len(postgres) == 0 {
+		t.Fatal("Load(postgres) returned no migrations")
+	}
+
+	for i, m := range postgres {
+		if m.Version != i+1 {
+			t.Errorf("Load(postgres) migration %d has version %d, want versions numbered without gaps", i, m.Version)
+		}
+	}
+
+	if postgres[0].Name != `create_user_table` {
+		t.Errorf("Load(postgres) first migration = %s, want create_user_table", postgres[0].Name)
+	}
+
+	sqlite, err := Load(`sqlite`)
+	if err != nil {
+		t.Fatalf("Load(sqlite) error = %v", err)
+	}
+
+	if len(sqlite) != len(postgres) {
+		t.Fatalf("Load(sqlite) returned %d migrations, want the same %d as postgres", len(sqlite), len(postgres))
+	}
+
+	for i := range postgres {
+		if sqlite[i].Version != postgres[i].Version || sqlite[i].Name != postgres[i].Name {
+			t.Errorf("Load(sqlite) migration %04d_%s, want %04d_%s", sqlite[i].Version, sqlite[i].Name, postgres[i].Version, postgres[i].Name)
+		}
+	}
+
+	if _, err = Load(`mysql`); err == nil {
+		t.Error("Load(mysql) error = nil, want an error for an unknown dialect")
+	}
+}
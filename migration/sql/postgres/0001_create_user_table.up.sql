@@ -1,4 +1,4 @@
-CREATE TABLE "user" (
+CREATE TABLE IF NOT EXISTS "user" (
   "id" SERIAL NOT NULL,
   "full_name" varchar(60) NOT NULL DEFAULT '',
   "phone_number" varchar(15) NOT NULL DEFAULT '',
@@ -9,4 +9,4 @@ CREATE TABLE "user" (
   "updated_at" timestamp NULL DEFAULT NULL,
   PRIMARY KEY ("id"),
   UNIQUE ("phone_number")
-);
\ No newline at end of file
+);
@@ -0,0 +1,17 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "role" varchar(10) NOT NULL DEFAULT 'user';
+
+CREATE TABLE IF NOT EXISTS "impersonation_audit" (
+  "id" SERIAL NOT NULL,
+  "actor_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "method" varchar(10) NOT NULL DEFAULT '',
+  "path" varchar(255) NOT NULL DEFAULT '',
+  "status_code" int NOT NULL DEFAULT 0,
+  "ip_address" varchar(45) NOT NULL DEFAULT '',
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "impersonation_audit_actor_id_idx" ON "impersonation_audit" ("actor_id");
+CREATE INDEX IF NOT EXISTS "impersonation_audit_user_id_idx" ON "impersonation_audit" ("user_id");
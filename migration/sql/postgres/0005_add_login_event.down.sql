@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "login_event";
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "last_failed_login_at";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "failed_login_count";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "last_login_at";
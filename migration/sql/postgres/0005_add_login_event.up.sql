@@ -0,0 +1,17 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "last_login_at" timestamp NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "failed_login_count" int NOT NULL DEFAULT 0;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "last_failed_login_at" timestamp NULL;
+
+CREATE TABLE IF NOT EXISTS "login_event" (
+  "id" SERIAL NOT NULL,
+  "user_id" int NULL,
+  "phone_number" varchar(13) NOT NULL DEFAULT '',
+  "success" boolean NOT NULL,
+  "failure_reason" varchar(50) NOT NULL DEFAULT '',
+  "ip_address" varchar(45) NOT NULL DEFAULT '',
+  "user_agent" varchar(255) NOT NULL DEFAULT '',
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "login_event_user_id_idx" ON "login_event" ("user_id", "id");
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "outbox_event" (
+  "id" SERIAL NOT NULL,
+  "type" varchar(50) NOT NULL,
+  "user_id" int NOT NULL,
+  "payload" text NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "published_at" timestamp NULL,
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "outbox_event_pending_idx" ON "outbox_event" ("id") WHERE "published_at" IS NULL;
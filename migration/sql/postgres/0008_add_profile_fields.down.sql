@@ -0,0 +1,4 @@
+ALTER TABLE "user" DROP COLUMN "preferred_language";
+ALTER TABLE "user" DROP COLUMN "address";
+ALTER TABLE "user" DROP COLUMN "gender";
+ALTER TABLE "user" DROP COLUMN "date_of_birth";
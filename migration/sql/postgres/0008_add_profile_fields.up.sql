@@ -0,0 +1,4 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "date_of_birth" varchar(10) NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "gender" varchar(10) NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "address" varchar(255) NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "preferred_language" varchar(35) NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS "user_phone_number_trgm_idx";
+DROP INDEX IF EXISTS "user_full_name_trgm_idx";
+DROP FUNCTION IF EXISTS immutable_unaccent(text);
+-- The extensions are left installed, since other schemas may use them.
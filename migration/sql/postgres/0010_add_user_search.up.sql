@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+-- unaccent is only STABLE, since its dictionary can change, so it cannot be
+-- used in an index expression; this wrapper pins the dictionary.
+CREATE OR REPLACE FUNCTION immutable_unaccent(text) RETURNS text
+	AS $$ SELECT public.unaccent('public.unaccent', $1) $$
+	LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+CREATE INDEX IF NOT EXISTS "user_full_name_trgm_idx" ON "user" USING gin (lower(immutable_unaccent("full_name")) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS "user_phone_number_trgm_idx" ON "user" USING gin ("phone_number" gin_trgm_ops);
@@ -0,0 +1,25 @@
+DROP INDEX IF EXISTS "webhook_subscription_organization_id_idx";
+DROP INDEX IF EXISTS "audit_log_organization_id_idx";
+
+ALTER TABLE "webhook_delivery" DROP COLUMN IF EXISTS "organization_id";
+ALTER TABLE "webhook_subscription" DROP COLUMN IF EXISTS "organization_id";
+ALTER TABLE "outbox_event" DROP COLUMN IF EXISTS "organization_id";
+ALTER TABLE "login_event" DROP COLUMN IF EXISTS "organization_id";
+ALTER TABLE "audit_log" DROP COLUMN IF EXISTS "organization_id";
+ALTER TABLE "impersonation_audit" DROP COLUMN IF EXISTS "organization_id";
+
+-- Fails while a phone number is used in more than one organization.
+ALTER TABLE "user" DROP CONSTRAINT IF EXISTS "user_phone_number_key";
+ALTER TABLE "user" ADD CONSTRAINT "user_phone_number_key" UNIQUE ("phone_number");
+
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "role" varchar(10) NOT NULL DEFAULT 'user';
+UPDATE "user" SET "role" = "organization_member"."role"
+FROM "organization_member"
+WHERE "organization_member"."user_id" = "user"."id"
+  AND "organization_member"."organization_id" = "user"."organization_id";
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "phone_number_scope";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "organization_id";
+
+DROP TABLE IF EXISTS "organization_member";
+DROP TABLE IF EXISTS "organization";
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS "organization" (
+  "id" SERIAL NOT NULL,
+  "name" varchar(100) NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("id")
+);
+
+-- Every existing user and record belongs to the default organization.
+INSERT INTO "organization" ("id", "name") VALUES (1, 'Default') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('organization', 'id'), (SELECT MAX("id") FROM "organization"));
+
+CREATE TABLE IF NOT EXISTS "organization_member" (
+  "organization_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "role" varchar(10) NOT NULL DEFAULT 'user',
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("organization_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS "organization_member_user_id_idx" ON "organization_member" ("user_id");
+
+INSERT INTO "organization_member" ("organization_id", "user_id", "role", "created_at")
+SELECT 1, "id", "role", "created_at" FROM "user"
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE "user" DROP COLUMN IF EXISTS "role";
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+-- 0 while phone numbers are unique across organizations, otherwise the
+-- organization they are unique in.
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "phone_number_scope" int NOT NULL DEFAULT 0;
+ALTER TABLE "user" DROP CONSTRAINT IF EXISTS "user_phone_number_key";
+ALTER TABLE "user" ADD CONSTRAINT "user_phone_number_key" UNIQUE ("phone_number_scope", "phone_number");
+
+ALTER TABLE "impersonation_audit" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "audit_log" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "login_event" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "outbox_event" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "webhook_subscription" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "webhook_delivery" ADD COLUMN IF NOT EXISTS "organization_id" int NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS "audit_log_organization_id_idx" ON "audit_log" ("organization_id", "id");
+CREATE INDEX IF NOT EXISTS "webhook_subscription_organization_id_idx" ON "webhook_subscription" ("organization_id");
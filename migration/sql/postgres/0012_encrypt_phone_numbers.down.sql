@@ -0,0 +1,7 @@
+-- Fails while a phone number is encrypted; run "reencrypt -decrypt" first.
+ALTER TABLE "user" ALTER COLUMN "phone_number" SET DEFAULT '';
+ALTER TABLE "user" ALTER COLUMN "phone_number" SET NOT NULL;
+
+ALTER TABLE "user" DROP CONSTRAINT IF EXISTS "user_phone_number_index_key";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "phone_number_index";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "phone_number_ciphertext";
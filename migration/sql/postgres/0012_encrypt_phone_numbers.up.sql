@@ -0,0 +1,10 @@
+-- With a keyring configured the phone number is stored encrypted, with
+-- "phone_number" left NULL, and is looked up and kept unique through its
+-- blind index. Users written without a keyring keep it in plain text until
+-- they are re-encrypted.
+ALTER TABLE "user" ALTER COLUMN "phone_number" DROP NOT NULL;
+ALTER TABLE "user" ALTER COLUMN "phone_number" DROP DEFAULT;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "phone_number_ciphertext" text NULL;
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS "phone_number_index" varchar(64) NULL;
+ALTER TABLE "user" DROP CONSTRAINT IF EXISTS "user_phone_number_index_key";
+ALTER TABLE "user" ADD CONSTRAINT "user_phone_number_index_key" UNIQUE ("phone_number_scope", "phone_number_index");
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "phone_number_change";
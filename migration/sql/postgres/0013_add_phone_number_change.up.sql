@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "phone_number_change" (
+  "id" SERIAL NOT NULL,
+  "organization_id" int NOT NULL DEFAULT 1,
+  "user_id" int NOT NULL,
+  "phone_number" varchar(13) NULL,
+  "phone_number_ciphertext" text NULL,
+  "code_hash" varchar(64) NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "expires_at" timestamp NOT NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "confirmed_at" timestamp NULL,
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "phone_number_change_user_id_idx" ON "phone_number_change" ("user_id", "id");
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS "job" (
+  "id" SERIAL NOT NULL,
+  "organization_id" int NOT NULL DEFAULT 1,
+  "type" varchar(100) NOT NULL,
+  "payload" text NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "max_attempts" int NOT NULL,
+  "run_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "locked_at" timestamp NULL,
+  "last_error" text NOT NULL DEFAULT '',
+  "unique_key" varchar(255) NULL,
+  "created_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "finished_at" timestamp NULL,
+  PRIMARY KEY ("id")
+);
+
+CREATE INDEX IF NOT EXISTS "job_due_idx" ON "job" ("run_at", "id") WHERE "status" = 'pending';
+CREATE INDEX IF NOT EXISTS "job_locked_at_idx" ON "job" ("locked_at") WHERE "status" = 'running';
+CREATE INDEX IF NOT EXISTS "job_finished_at_idx" ON "job" ("finished_at") WHERE "status" = 'succeeded';
+CREATE UNIQUE INDEX IF NOT EXISTS "job_unique_key_idx" ON "job" ("unique_key");
@@ -0,0 +1,3 @@
+-- Rows written with a keyring lose their phone number and changes.
+ALTER TABLE "audit_log" DROP COLUMN IF EXISTS "changes_ciphertext";
+ALTER TABLE "login_event" DROP COLUMN IF EXISTS "phone_number_ciphertext";
@@ -0,0 +1,6 @@
+-- With a keyring configured the phone number of a login attempt and the
+-- changes of an audit log entry are stored encrypted, leaving "phone_number"
+-- empty and "changes" '{}'. Rows written without a keyring keep them in
+-- plain text.
+ALTER TABLE "login_event" ADD COLUMN IF NOT EXISTS "phone_number_ciphertext" text NULL;
+ALTER TABLE "audit_log" ADD COLUMN IF NOT EXISTS "changes_ciphertext" text NULL;
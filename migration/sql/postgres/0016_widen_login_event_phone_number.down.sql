@@ -0,0 +1,2 @@
+-- Longer phone numbers of failed logins are truncated.
+ALTER TABLE "login_event" ALTER COLUMN "phone_number" TYPE varchar(13) USING left("phone_number", 13);
@@ -0,0 +1,3 @@
+-- A failed login records the phone number as entered, which is not limited
+-- to the length of a registered one.
+ALTER TABLE "login_event" ALTER COLUMN "phone_number" TYPE text;
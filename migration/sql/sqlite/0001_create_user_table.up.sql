@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS "user" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "full_name" varchar(60) NOT NULL DEFAULT '',
+  "phone_number" varchar(15) NOT NULL DEFAULT '',
+  "password" varchar(64) NOT NULL DEFAULT '',
+  "account_salt" varchar(15) NOT NULL DEFAULT '',
+  "successful_login" int NOT NULL DEFAULT 0,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime NULL DEFAULT NULL,
+  UNIQUE ("phone_number")
+);
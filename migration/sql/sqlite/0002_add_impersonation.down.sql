@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "impersonation_audit";
+
+ALTER TABLE "user" DROP COLUMN "role";
@@ -0,0 +1 @@
+ALTER TABLE "user" ADD COLUMN "version" int NOT NULL DEFAULT 1;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS "audit_log" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "actor_id" int NOT NULL,
+  "actor_role" varchar(10) NOT NULL DEFAULT '',
+  "action" varchar(50) NOT NULL,
+  "user_id" int NOT NULL,
+  "changes" text NOT NULL DEFAULT '{}',
+  "ip_address" varchar(45) NOT NULL DEFAULT '',
+  "request_id" varchar(64) NOT NULL DEFAULT '',
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "audit_log_actor_id_idx" ON "audit_log" ("actor_id");
+CREATE INDEX IF NOT EXISTS "audit_log_user_id_idx" ON "audit_log" ("user_id");
+CREATE INDEX IF NOT EXISTS "audit_log_created_at_idx" ON "audit_log" ("created_at");
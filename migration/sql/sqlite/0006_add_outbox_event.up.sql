@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS "outbox_event" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "type" varchar(50) NOT NULL,
+  "user_id" int NOT NULL,
+  "payload" text NOT NULL,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "published_at" datetime NULL
+);
+
+CREATE INDEX IF NOT EXISTS "outbox_event_pending_idx" ON "outbox_event" ("id") WHERE "published_at" IS NULL;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS "webhook_subscription" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "url" varchar(2048) NOT NULL,
+  "event_types" varchar(255) NOT NULL,
+  "secret" varchar(255) NOT NULL,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS "webhook_delivery" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "subscription_id" int NOT NULL,
+  "event_id" int NOT NULL,
+  "event_type" varchar(50) NOT NULL,
+  "payload" text NOT NULL,
+  "status" varchar(20) NOT NULL DEFAULT 'pending',
+  "attempts" int NOT NULL DEFAULT 0,
+  "next_attempt_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "last_status_code" int NOT NULL DEFAULT 0,
+  "last_error" varchar(255) NOT NULL DEFAULT '',
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "delivered_at" datetime NULL
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_delivery_due_idx" ON "webhook_delivery" ("next_attempt_at") WHERE "status" = 'pending';
+CREATE INDEX IF NOT EXISTS "webhook_delivery_subscription_id_idx" ON "webhook_delivery" ("subscription_id", "id");
+CREATE INDEX IF NOT EXISTS "webhook_delivery_event_id_idx" ON "webhook_delivery" ("event_id");
+
+CREATE TABLE IF NOT EXISTS "webhook_attempt" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "delivery_id" int NOT NULL,
+  "status_code" int NOT NULL DEFAULT 0,
+  "error" varchar(255) NOT NULL DEFAULT '',
+  "duration_ms" int NOT NULL DEFAULT 0,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS "webhook_attempt_delivery_id_idx" ON "webhook_attempt" ("delivery_id");
@@ -0,0 +1,4 @@
+ALTER TABLE "user" ADD COLUMN "date_of_birth" varchar(10) NULL;
+ALTER TABLE "user" ADD COLUMN "gender" varchar(10) NULL;
+ALTER TABLE "user" ADD COLUMN "address" varchar(255) NULL;
+ALTER TABLE "user" ADD COLUMN "preferred_language" varchar(35) NULL;
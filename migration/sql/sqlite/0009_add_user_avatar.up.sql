@@ -0,0 +1 @@
+ALTER TABLE "user" ADD COLUMN "avatar_key" varchar(255) NULL;
@@ -0,0 +1,4 @@
+-- SQLite has no trigram indexes; user search scores every user in the
+-- application instead. The migration keeps the versions of both dialects
+-- aligned.
+SELECT 1;
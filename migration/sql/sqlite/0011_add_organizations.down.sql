@@ -0,0 +1,52 @@
+DROP INDEX IF EXISTS "webhook_subscription_organization_id_idx";
+DROP INDEX IF EXISTS "audit_log_organization_id_idx";
+
+ALTER TABLE "webhook_delivery" DROP COLUMN "organization_id";
+ALTER TABLE "webhook_subscription" DROP COLUMN "organization_id";
+ALTER TABLE "outbox_event" DROP COLUMN "organization_id";
+ALTER TABLE "login_event" DROP COLUMN "organization_id";
+ALTER TABLE "audit_log" DROP COLUMN "organization_id";
+ALTER TABLE "impersonation_audit" DROP COLUMN "organization_id";
+
+-- Fails while a phone number is used in more than one organization.
+CREATE TABLE "user_old" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "full_name" varchar(60) NOT NULL DEFAULT '',
+  "phone_number" varchar(15) NOT NULL DEFAULT '',
+  "password" varchar(64) NOT NULL DEFAULT '',
+  "account_salt" varchar(15) NOT NULL DEFAULT '',
+  "successful_login" int NOT NULL DEFAULT 0,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime NULL DEFAULT NULL,
+  "role" varchar(10) NOT NULL DEFAULT 'user',
+  "version" int NOT NULL DEFAULT 1,
+  "last_login_at" datetime NULL,
+  "failed_login_count" int NOT NULL DEFAULT 0,
+  "last_failed_login_at" datetime NULL,
+  "date_of_birth" varchar(10) NULL,
+  "gender" varchar(10) NULL,
+  "address" varchar(255) NULL,
+  "preferred_language" varchar(35) NULL,
+  "avatar_key" varchar(255) NULL,
+  UNIQUE ("phone_number")
+);
+
+INSERT INTO "user_old" (
+  "id", "full_name", "phone_number", "password", "account_salt", "successful_login",
+  "created_at", "updated_at", "role", "version", "last_login_at", "failed_login_count",
+  "last_failed_login_at", "date_of_birth", "gender", "address", "preferred_language", "avatar_key"
+)
+SELECT
+  "user"."id", "full_name", "phone_number", "password", "account_salt", "successful_login",
+  "user"."created_at", "updated_at", COALESCE("organization_member"."role", 'user'), "version", "last_login_at", "failed_login_count",
+  "last_failed_login_at", "date_of_birth", "gender", "address", "preferred_language", "avatar_key"
+FROM "user"
+LEFT JOIN "organization_member"
+  ON "organization_member"."user_id" = "user"."id"
+  AND "organization_member"."organization_id" = "user"."organization_id";
+
+DROP TABLE "user";
+ALTER TABLE "user_old" RENAME TO "user";
+
+DROP TABLE IF EXISTS "organization_member";
+DROP TABLE IF EXISTS "organization";
@@ -0,0 +1,72 @@
+CREATE TABLE IF NOT EXISTS "organization" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "name" varchar(100) NOT NULL,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Every existing user and record belongs to the default organization.
+INSERT OR IGNORE INTO "organization" ("id", "name") VALUES (1, 'Default');
+
+CREATE TABLE IF NOT EXISTS "organization_member" (
+  "organization_id" int NOT NULL,
+  "user_id" int NOT NULL,
+  "role" varchar(10) NOT NULL DEFAULT 'user',
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY ("organization_id", "user_id")
+);
+
+CREATE INDEX IF NOT EXISTS "organization_member_user_id_idx" ON "organization_member" ("user_id");
+
+INSERT OR IGNORE INTO "organization_member" ("organization_id", "user_id", "role", "created_at")
+SELECT 1, "id", "role", "created_at" FROM "user";
+
+-- SQLite cannot change a table constraint, so the user table is rebuilt
+-- with the phone number unique within its scope: 0 while phone numbers are
+-- unique across organizations, otherwise the organization they are unique
+-- in.
+CREATE TABLE "user_new" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "organization_id" int NOT NULL DEFAULT 1,
+  "full_name" varchar(60) NOT NULL DEFAULT '',
+  "phone_number" varchar(15) NOT NULL DEFAULT '',
+  "phone_number_scope" int NOT NULL DEFAULT 0,
+  "password" varchar(64) NOT NULL DEFAULT '',
+  "account_salt" varchar(15) NOT NULL DEFAULT '',
+  "successful_login" int NOT NULL DEFAULT 0,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime NULL DEFAULT NULL,
+  "version" int NOT NULL DEFAULT 1,
+  "last_login_at" datetime NULL,
+  "failed_login_count" int NOT NULL DEFAULT 0,
+  "last_failed_login_at" datetime NULL,
+  "date_of_birth" varchar(10) NULL,
+  "gender" varchar(10) NULL,
+  "address" varchar(255) NULL,
+  "preferred_language" varchar(35) NULL,
+  "avatar_key" varchar(255) NULL,
+  UNIQUE ("phone_number_scope", "phone_number")
+);
+
+INSERT INTO "user_new" (
+  "id", "full_name", "phone_number", "password", "account_salt", "successful_login",
+  "created_at", "updated_at", "version", "last_login_at", "failed_login_count",
+  "last_failed_login_at", "date_of_birth", "gender", "address", "preferred_language", "avatar_key"
+)
+SELECT
+  "id", "full_name", "phone_number", "password", "account_salt", "successful_login",
+  "created_at", "updated_at", "version", "last_login_at", "failed_login_count",
+  "last_failed_login_at", "date_of_birth", "gender", "address", "preferred_language", "avatar_key"
+FROM "user";
+
+DROP TABLE "user";
+ALTER TABLE "user_new" RENAME TO "user";
+
+ALTER TABLE "impersonation_audit" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "audit_log" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "login_event" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "outbox_event" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "webhook_subscription" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+ALTER TABLE "webhook_delivery" ADD COLUMN "organization_id" int NOT NULL DEFAULT 1;
+
+CREATE INDEX IF NOT EXISTS "audit_log_organization_id_idx" ON "audit_log" ("organization_id", "id");
+CREATE INDEX IF NOT EXISTS "webhook_subscription_organization_id_idx" ON "webhook_subscription" ("organization_id");
@@ -0,0 +1,39 @@
+-- Fails while a phone number is encrypted; run "reencrypt -decrypt" first.
+CREATE TABLE "user_old" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "organization_id" int NOT NULL DEFAULT 1,
+  "full_name" varchar(60) NOT NULL DEFAULT '',
+  "phone_number" varchar(15) NOT NULL DEFAULT '',
+  "phone_number_scope" int NOT NULL DEFAULT 0,
+  "password" varchar(64) NOT NULL DEFAULT '',
+  "account_salt" varchar(15) NOT NULL DEFAULT '',
+  "successful_login" int NOT NULL DEFAULT 0,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "updated_at" datetime NULL DEFAULT NULL,
+  "version" int NOT NULL DEFAULT 1,
+  "last_login_at" datetime NULL,
+  "failed_login_count" int NOT NULL DEFAULT 0,
+  "last_failed_login_at" datetime NULL,
+  "date_of_birth" varchar(10) NULL,
+  "gender" varchar(10) NULL,
+  "address" varchar(255) NULL,
+  "preferred_language" varchar(35) NULL,
+  "avatar_key" varchar(255) NULL,
+  UNIQUE ("phone_number_scope", "phone_number")
+);
+
+INSERT INTO "user_old" (
+  "id", "organization_id", "full_name", "phone_number", "phone_number_scope", "password",
+  "account_salt", "successful_login", "created_at", "updated_at", "version", "last_login_at",
+  "failed_login_count", "last_failed_login_at", "date_of_birth", "gender", "address",
+  "preferred_language", "avatar_key"
+)
+SELECT
+  "id", "organization_id", "full_name", "phone_number", "phone_number_scope", "password",
+  "account_salt", "successful_login", "created_at", "updated_at", "version", "last_login_at",
+  "failed_login_count", "last_failed_login_at", "date_of_birth", "gender", "address",
+  "preferred_language", "avatar_key"
+FROM "user";
+
+DROP TABLE "user";
+ALTER TABLE "user_old" RENAME TO "user";
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS "phone_number_change" (
+  "id" INTEGER PRIMARY KEY AUTOINCREMENT,
+  "organization_id" int NOT NULL DEFAULT 1,
+  "user_id" int NOT NULL,
+  "phone_number" varchar(13) NULL,
+  "phone_number_ciphertext" text NULL,
+  "code_hash" varchar(64) NOT NULL,
+  "attempts" int NOT NULL DEFAULT 0,
+  "expires_at" datetime NOT NULL,
+  "created_at" datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  "confirmed_at" datetime NULL
+);
+
+CREATE INDEX IF NOT EXISTS "phone_number_change_user_id_idx" ON "phone_number_change" ("user_id", "id");
@@ -0,0 +1,4 @@
+-- SQLite does not enforce the length of a varchar column, so phone numbers of
+-- any length are already stored. The migration only keeps the versions in
+-- step with postgres.
+SELECT 1;
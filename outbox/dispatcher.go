@@ -0,0 +1,81 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+// Dispatcher publishes the events written to the outbox, oldest first. An
+// event is marked as published only after the sink has accepted it, so it is
+// delivered at least once. A failed event stops the batch and is retried on
+// the next poll, which keeps later events from overtaking it.
+//
+// Only one dispatcher should run per database; several would each publish
+// the same pending events.
+type Dispatcher struct {
+	repo      repository.Repository
+	sink      Sink
+	interval  time.Duration
+	batchSize int
+}
+
+func NewDispatcher(repo repository.Repository, sink Sink, interval time.Duration, batchSize int) *Dispatcher {
+	return &Dispatcher{
+		repo:      repo,
+		sink:      sink,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+// Run polls the outbox every interval until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick.
+		for {
+			n, err := d.Dispatch(ctx)
+			if err != nil {
+				log.Printf(`Outbox dispatch error %s`, err.Error())
+			}
+			if err != nil || n < d.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch publishes one batch of pending events and returns how many were
+// published.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.repo.ListPendingOutboxEvents(ctx, d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range events {
+		if err = d.sink.Publish(ctx, NewMessage(event)); err != nil {
+			return i, err
+		}
+
+		// A failure here publishes the event again on the next poll.
+		err = d.repo.MarkOutboxEventPublished(ctx, event.ID, shared.UTC7(d.repo.Now()))
+		if err != nil {
+			return i + 1, err
+		}
+	}
+
+	return len(events), nil
+}
@@ -0,0 +1,99 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingSink remembers the published message ids and fails the ids in
+// failing.
+type recordingSink struct {
+	published []int
+	failing   map[int]bool
+}
+
+func (s *recordingSink) Publish(ctx context.Context, msg Message) error {
+	if s.failing[msg.ID] {
+		return errors.New(`sink unavailable`)
+	}
+	s.published = append(s.published, msg.ID)
+	return nil
+}
+
+func TestDispatcher(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, n int) repository.Repository {
+		repo := repository.NewMemoryRepository()
+		for i := 1; i <= n; i++ {
+			event := &entity.OutboxEvent{Type: entity.EventUserLoggedIn, UserID: i, Payload: `{}`}
+			if err := repo.CreateOutboxEvent(ctx, event); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return repo
+	}
+
+	t.Run(`PublishesInOrderOnce`, func(t *testing.T) {
+		repo := setup(t, 3)
+		sink := &recordingSink{}
+		d := NewDispatcher(repo, sink, time.Second, 2)
+
+		n, err := d.Dispatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		n, err = d.Dispatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		n, err = d.Dispatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, n)
+
+		assert.Equal(t, []int{1, 2, 3}, sink.published)
+	})
+
+	t.Run(`FailureStopsBatchAndRetries`, func(t *testing.T) {
+		repo := setup(t, 3)
+		sink := &recordingSink{failing: map[int]bool{2: true}}
+		d := NewDispatcher(repo, sink, time.Second, 10)
+
+		n, err := d.Dispatch(ctx)
+		assert.Error(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []int{1}, sink.published)
+
+		sink.failing = nil
+		n, err = d.Dispatch(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []int{1, 2, 3}, sink.published)
+	})
+
+	t.Run(`RunDrainsUntilCancelled`, func(t *testing.T) {
+		repo := setup(t, 5)
+		sink := &recordingSink{}
+		d := NewDispatcher(repo, sink, time.Hour, 2)
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			d.Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool {
+			pending, _ := repo.ListPendingOutboxEvents(ctx, 10)
+			return len(pending) == 0
+		}, time.Second, 10*time.Millisecond)
+
+		cancel()
+		<-done
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, sink.published)
+	})
+}
@@ -0,0 +1,156 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+)
+
+// Sink publishes domain events to their consumers. Publish returns nil only
+// once the event has been accepted; an event may be published again after
+// a failure or a restart, so consumers must deduplicate by Message.ID.
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// Message is an outbox event as it is published, along with the
+// organization it happened in.
+type Message struct {
+	ID             int             `json:"id"`
+	Type           string          `json:"type"`
+	OrganizationID int             `json:"organization_id"`
+	UserID         int             `json:"user_id"`
+	Payload        json.RawMessage `json:"payload"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+}
+
+// NewMessage converts a stored outbox event to the published message.
+func NewMessage(event entity.OutboxEvent) Message {
+	return Message{
+		ID:             event.ID,
+		Type:           event.Type,
+		OrganizationID: event.OrganizationID,
+		UserID:         event.UserID,
+		Payload:        json.RawMessage(event.Payload),
+		OccurredAt:     event.CreatedAt,
+	}
+}
+
+// writerSink writes each message as a line of JSON.
+type writerSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a Sink writing JSON lines to w, such as os.Stdout.
+func NewWriterSink(w io.Writer) Sink {
+	return &writerSink{w: w}
+}
+
+// NewFileSink returns a Sink appending JSON lines to the file at path, which
+// is created if needed. The file stays open for the life of the process.
+func NewFileSink(path string) (Sink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWriterSink(f), nil
+}
+
+func (s *writerSink) Publish(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// httpSink posts each message to a URL.
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink returns a Sink posting each message as JSON to url. Any 2xx
+// response accepts the message. The event id is also sent in the
+// Idempotency-Key header.
+func NewHTTPSink(url string, client *http.Client) Sink {
+	return &httpSink{url: url, client: client}
+}
+
+func (s *httpSink) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(`Content-Type`, `application/json`)
+	req.Header.Set(`Idempotency-Key`, strconv.Itoa(msg.ID))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf(`publish event %d: %s responded %s`, msg.ID, s.url, res.Status)
+	}
+
+	return nil
+}
+
+// NewSink returns the Sink selected by cfg.OutboxSink, or nil when events
+// are not dispatched.
+func NewSink(cfg *config.Config) (Sink, error) {
+	switch cfg.OutboxSink {
+	case config.OutboxSinkStdout:
+		return NewWriterSink(os.Stdout), nil
+	case config.OutboxSinkFile:
+		return NewFileSink(cfg.OutboxFile)
+	case config.OutboxSinkHTTP:
+		return NewHTTPSink(cfg.OutboxURL, &http.Client{Timeout: 10 * time.Second}), nil
+	}
+
+	return nil, nil
+}
+
+// multiSink publishes every message to each of its sinks in turn.
+type multiSink []Sink
+
+// NewMultiSink returns a Sink publishing to each of sinks in order. A
+// message is accepted once all of them accept it; after a failure it is
+// published again to every sink.
+func NewMultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (s multiSink) Publish(ctx context.Context, msg Message) error {
+	for _, sink := range s {
+		if err := sink.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMessage(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	msg := NewMessage(entity.OutboxEvent{
+		ID:             3,
+		Type:           entity.EventPasswordChanged,
+		OrganizationID: 2,
+		UserID:         1,
+		Payload:        `{"user_id":1}`,
+		CreatedAt:      at,
+	})
+
+	line, err := json.Marshal(msg)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":3,"type":"PasswordChanged","organization_id":2,"user_id":1,"payload":{"user_id":1},"occurred_at":"2024-01-01T10:00:00Z"}`, string(line))
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sink := NewWriterSink(buf)
+
+	assert.NoError(t, sink.Publish(context.Background(), Message{ID: 1, Type: entity.EventUserLoggedIn, Payload: json.RawMessage(`{}`)}))
+	assert.NoError(t, sink.Publish(context.Background(), Message{ID: 2, Type: entity.EventUserLoggedIn, Payload: json.RawMessage(`{}`)}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if assert.Len(t, lines, 2) {
+		var msg Message
+		assert.NoError(t, json.Unmarshal(lines[1], &msg))
+		assert.Equal(t, 2, msg.ID)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `events.jsonl`)
+
+	for id := 1; id <= 2; id++ {
+		sink, err := NewFileSink(path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, sink.Publish(context.Background(), Message{ID: id, Payload: json.RawMessage(`{}`)}))
+	}
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		body   []byte
+		header http.Header
+		status = http.StatusAccepted
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, server.Client())
+	msg := Message{ID: 7, Type: entity.EventUserRegistered, UserID: 1, Payload: json.RawMessage(`{"user_id":1}`)}
+
+	assert.NoError(t, sink.Publish(context.Background(), msg))
+	assert.Equal(t, `application/json`, header.Get(`Content-Type`))
+	assert.Equal(t, `7`, header.Get(`Idempotency-Key`))
+	var got Message
+	assert.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, msg.Type, got.Type)
+
+	status = http.StatusServiceUnavailable
+	assert.Error(t, sink.Publish(context.Background(), msg))
+}
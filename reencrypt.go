@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/repository"
+)
+
+var errReencryptUsage = errors.New(`usage: main reencrypt [-batch-size n] [-decrypt]`)
+
+// RunReencrypt implements the `reencrypt` subcommand, which encrypts the
+// phone number of every user with the primary key of the keyring, after a
+// key is rotated or the keyring first configured. With -decrypt it stores
+// them in plain text again instead, so the keyring can be removed.
+func RunReencrypt(args []string) error {
+	flags := flag.NewFlagSet(`reencrypt`, flag.ContinueOnError)
+	batchSize := flags.Int(`batch-size`, 500, `users read at a time`)
+	decrypt := flags.Bool(`decrypt`, false, `store the phone numbers in plain text`)
+	if err := flags.Parse(args); err != nil {
+		return errReencryptUsage
+	}
+	if flags.NArg() != 0 || *batchSize <= 0 {
+		return errReencryptUsage
+	}
+
+	cfg := config.NewConfig()
+	repo := repository.NewRepository(cfg)
+
+	// Without a tenant every user is rewritten, whatever their organization.
+	ctx := context.Background()
+
+	var afterID, total int
+	for {
+		lastID, rewritten, err := repo.ReencryptUsers(ctx, afterID, *batchSize, *decrypt)
+		total += rewritten
+		if err != nil {
+			return err
+		}
+		if lastID == 0 {
+			break
+		}
+		afterID = lastID
+	}
+
+	fmt.Printf("Rewrote %d users\n", total)
+	return nil
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRUCache(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run(`EvictsLeastRecentlyUsed`, func(t *testing.T) {
+		cache := NewLRUCache(2)
+
+		assert.NoError(t, cache.Set(ctx, `a`, []byte(`1`), time.Minute))
+		assert.NoError(t, cache.Set(ctx, `b`, []byte(`2`), time.Minute))
+		_, ok, _ := cache.Get(ctx, `a`)
+		assert.True(t, ok)
+
+		assert.NoError(t, cache.Set(ctx, `c`, []byte(`3`), time.Minute))
+
+		_, ok, _ = cache.Get(ctx, `b`)
+		assert.False(t, ok)
+		value, ok, _ := cache.Get(ctx, `a`)
+		assert.True(t, ok)
+		assert.Equal(t, []byte(`1`), value)
+		_, ok, _ = cache.Get(ctx, `c`)
+		assert.True(t, ok)
+	})
+
+	t.Run(`ExpiresAfterTTL`, func(t *testing.T) {
+		cache := NewLRUCache(2)
+
+		assert.NoError(t, cache.Set(ctx, `a`, []byte(`1`), -time.Second))
+
+		_, ok, err := cache.Get(ctx, `a`)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run(`SetReplacesValue`, func(t *testing.T) {
+		cache := NewLRUCache(2)
+
+		assert.NoError(t, cache.Set(ctx, `a`, []byte(`1`), time.Minute))
+		assert.NoError(t, cache.Set(ctx, `a`, []byte(`2`), time.Minute))
+
+		value, ok, _ := cache.Get(ctx, `a`)
+		assert.True(t, ok)
+		assert.Equal(t, []byte(`2`), value)
+	})
+
+	t.Run(`Delete`, func(t *testing.T) {
+		cache := NewLRUCache(2)
+
+		assert.NoError(t, cache.Set(ctx, `a`, []byte(`1`), time.Minute))
+		assert.NoError(t, cache.Delete(ctx, `a`, `missing`))
+
+		_, ok, _ := cache.Get(ctx, `a`)
+		assert.False(t, ok)
+	})
+}
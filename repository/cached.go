@@ -0,0 +1,443 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/keyring"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+// cacheStats counts user cache lookups for every cached repository in the
+// process. It is published as "user_cache" at /debug/vars.
+var cacheStats = expvar.NewMap(`user_cache`)
+
+// cachedRepository serves user lookups from a Cache and passes everything
+// else to the wrapped Repository. Writes delete the entries they make stale.
+// An entry written by another instance, or read from a lagging replica, may
+// still be served until its TTL expires.
+//
+// Users are cached once for every tenant, along with their memberships, so
+// that a write only has to delete one entry. A lookup in a tenant the user
+// is not a member of finds nothing, as the wrapped Repository would.
+//
+// With a keyring, the phone number, password hash and salt of a cached user
+// are only kept encrypted, and users are cached by the blind index of their
+// phone number, so that a shared cache holds none of them in plain text.
+type cachedRepository struct {
+	Repository
+	cache   Cache
+	ttl     time.Duration
+	keyring *keyring.Keyring
+}
+
+// cachedUserField names the secrets of a cached user to the keyring.
+const cachedUserField = `cache.user`
+
+// cachedUser is a user as cached. With a keyring, its phone number and
+// credentials are cleared and kept encrypted in Secrets instead.
+type cachedUser struct {
+	entity.User
+	Secrets *string `json:"secrets,omitempty"`
+}
+
+// userSecrets are the fields of a user that are encrypted in the cache.
+type userSecrets struct {
+	PhoneNumber string `json:"phone_number"`
+	Password    string `json:"password"`
+	AccountSalt string `json:"account_salt"`
+}
+
+type cacheTxKey struct{}
+
+// cacheTx collects the keys written inside a transaction. Concurrent
+// readers may cache the old values until the transaction commits, so the
+// keys are deleted again once it has finished.
+type cacheTx struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+// NewCachedRepository returns repo with GetUserByID and GetUserByPhoneNumber
+// served from cache for up to ttl. The personal data of the cached users is
+// encrypted with k unless it is nil, which is only meant for a cache in the
+// process.
+func NewCachedRepository(repo Repository, cache Cache, ttl time.Duration, k *keyring.Keyring) Repository {
+	return &cachedRepository{
+		Repository: repo,
+		cache:      cache,
+		ttl:        ttl,
+		keyring:    k,
+	}
+}
+
+func (r *cachedRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(cacheTxKey{}).(*cacheTx); ok {
+		return r.Repository.WithTransaction(ctx, fn)
+	}
+
+	tx := &cacheTx{}
+	err := r.Repository.WithTransaction(context.WithValue(ctx, cacheTxKey{}, tx), fn)
+	r.delete(ctx, tx.keys...)
+
+	return err
+}
+
+func (r *cachedRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	if inCacheTx(ctx) {
+		return r.Repository.GetUserByID(ctx, userID)
+	}
+
+	if user, members, ok := r.get(ctx, userID); ok {
+		cacheStats.Add(`hits`, 1)
+		return foundTenantUser(ctx, user, members)
+	}
+	cacheStats.Add(`misses`, 1)
+
+	// The user is loaded outside the tenant, so that the entry serves
+	// every tenant.
+	user, err := r.Repository.GetUserByID(tenant.WithID(ctx, 0), userID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := r.Repository.ListMemberships(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	r.set(ctx, user, members)
+	return foundTenantUser(ctx, user, members)
+}
+
+// GetUserByPhoneNumber caches the user ID for the phone number in the tenant
+// and reuses the entry cached by ID, so that a profile update only has to
+// delete the ID entry. A user whose phone number no longer matches counts as
+// a miss.
+func (r *cachedRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
+	if inCacheTx(ctx) {
+		return r.Repository.GetUserByPhoneNumber(ctx, phoneNumber)
+	}
+
+	if userID, ok := r.getUserID(ctx, phoneNumber); ok {
+		if user, members, ok := r.get(ctx, userID); ok && user.PhoneNumber == phoneNumber {
+			if user := tenantUser(ctx, user, members); user != nil {
+				cacheStats.Add(`hits`, 1)
+				return user, nil
+			}
+		}
+	}
+	cacheStats.Add(`misses`, 1)
+
+	user, err := r.Repository.GetUserByPhoneNumber(ctx, phoneNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	r.setUserID(ctx, user)
+	return user, nil
+}
+
+func (r *cachedRepository) Create(ctx context.Context, user *entity.User) error {
+	err := r.Repository.Create(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, userIDKey(user.ID), membershipsKey(user.ID), r.phoneNumberKey(ctx, user.PhoneNumber))
+	return nil
+}
+
+func (r *cachedRepository) CreateUsers(ctx context.Context, users []*entity.User) error {
+	err := r.Repository.CreateUsers(ctx, users)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, 3*len(users))
+	for _, user := range users {
+		keys = append(keys, userIDKey(user.ID), membershipsKey(user.ID), r.phoneNumberKey(ctx, user.PhoneNumber))
+	}
+	r.invalidate(ctx, keys...)
+	return nil
+}
+
+func (r *cachedRepository) AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error {
+	err := r.Repository.AddOrganizationMember(ctx, member)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, membershipsKey(member.UserID))
+	return nil
+}
+
+func (r *cachedRepository) IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error {
+	err := r.Repository.IncrementSuccessfulLogin(ctx, userID, at)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, userIDKey(userID))
+	return nil
+}
+
+func (r *cachedRepository) IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error {
+	err := r.Repository.IncrementFailedLogin(ctx, userID, at)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, userIDKey(userID))
+	return nil
+}
+
+func (r *cachedRepository) UpdateProfile(ctx context.Context, user *entity.User) error {
+	err := r.Repository.UpdateProfile(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, userIDKey(user.ID), r.phoneNumberKey(ctx, user.PhoneNumber))
+	return nil
+}
+
+// UpdatePassword also invalidates, since the cached user carries the
+// password hash that login checks against.
+func (r *cachedRepository) UpdatePassword(ctx context.Context, user *entity.User) error {
+	err := r.Repository.UpdatePassword(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	r.invalidate(ctx, userIDKey(user.ID))
+	return nil
+}
+
+// get returns the cached user and their memberships, and false unless both
+// are cached.
+func (r *cachedRepository) get(ctx context.Context, userID int) (*entity.User, []entity.OrganizationMember, bool) {
+	var (
+		cached  cachedUser
+		members []entity.OrganizationMember
+	)
+	if !r.getJSON(ctx, userIDKey(userID), &cached) || !r.getJSON(ctx, membershipsKey(userID), &members) {
+		return nil, nil, false
+	}
+
+	user, err := r.openUser(&cached)
+	if err != nil {
+		log.Printf(`Cache decrypt user %d error %s`, userID, err.Error())
+		return nil, nil, false
+	}
+
+	return user, members, true
+}
+
+// sealUser returns user as cached, with their secrets encrypted when there
+// is a keyring.
+func (r *cachedRepository) sealUser(user *entity.User) (*cachedUser, error) {
+	cached := &cachedUser{User: *user}
+	if r.keyring == nil {
+		return cached, nil
+	}
+
+	secrets, err := json.Marshal(userSecrets{
+		PhoneNumber: user.PhoneNumber,
+		Password:    user.Password,
+		AccountSalt: user.AccountSalt,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := r.keyring.Encrypt(cachedUserField, string(secrets))
+	if err != nil {
+		return nil, err
+	}
+
+	cached.PhoneNumber, cached.Password, cached.AccountSalt = ``, ``, ``
+	cached.Secrets = &ciphertext
+	return cached, nil
+}
+
+// openUser returns the user cached as cached, decrypting their secrets.
+func (r *cachedRepository) openUser(cached *cachedUser) (*entity.User, error) {
+	user := cached.User
+	if cached.Secrets == nil {
+		return &user, nil
+	}
+	if r.keyring == nil {
+		return nil, errNoKeyring
+	}
+
+	plaintext, err := r.keyring.Decrypt(cachedUserField, *cached.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	var secrets userSecrets
+	if err = json.Unmarshal([]byte(plaintext), &secrets); err != nil {
+		return nil, err
+	}
+
+	user.PhoneNumber, user.Password, user.AccountSalt = secrets.PhoneNumber, secrets.Password, secrets.AccountSalt
+	return &user, nil
+}
+
+func (r *cachedRepository) getJSON(ctx context.Context, key string, v interface{}) bool {
+	value, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		cacheStats.Add(`errors`, 1)
+		log.Printf(`Cache get error %s`, err.Error())
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	if err := json.Unmarshal(value, v); err != nil {
+		log.Printf(`Cache decode error %s`, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (r *cachedRepository) getUserID(ctx context.Context, phoneNumber string) (int, bool) {
+	value, ok, err := r.cache.Get(ctx, r.phoneNumberKey(ctx, phoneNumber))
+	if err != nil {
+		cacheStats.Add(`errors`, 1)
+		log.Printf(`Cache get error %s`, err.Error())
+		return 0, false
+	}
+	if !ok {
+		return 0, false
+	}
+
+	userID, err := strconv.Atoi(string(value))
+	return userID, err == nil
+}
+
+// set caches the user and their memberships, and the user ID for the phone
+// number when the user is a member of the tenant.
+func (r *cachedRepository) set(ctx context.Context, user *entity.User, members []entity.OrganizationMember) {
+	cached, err := r.sealUser(user)
+	if err != nil {
+		log.Printf(`Cache encrypt user %d error %s`, user.ID, err.Error())
+		return
+	}
+	value, err := json.Marshal(cached)
+	if err != nil {
+		log.Printf(`Cache encode error %s`, err.Error())
+		return
+	}
+	membersValue, err := json.Marshal(members)
+	if err != nil {
+		log.Printf(`Cache encode error %s`, err.Error())
+		return
+	}
+
+	err = r.cache.Set(ctx, userIDKey(user.ID), value, r.ttl)
+	if err == nil {
+		err = r.cache.Set(ctx, membershipsKey(user.ID), membersValue, r.ttl)
+	}
+	if err != nil {
+		cacheStats.Add(`errors`, 1)
+		log.Printf(`Cache set error %s`, err.Error())
+		return
+	}
+
+	if tenantUser(ctx, user, members) != nil {
+		r.setUserID(ctx, user)
+	}
+}
+
+func (r *cachedRepository) setUserID(ctx context.Context, user *entity.User) {
+	err := r.cache.Set(ctx, r.phoneNumberKey(ctx, user.PhoneNumber), []byte(strconv.Itoa(user.ID)), r.ttl)
+	if err != nil {
+		cacheStats.Add(`errors`, 1)
+		log.Printf(`Cache set error %s`, err.Error())
+	}
+}
+
+// invalidate deletes keys now and, inside a transaction, again once the
+// transaction has finished.
+func (r *cachedRepository) invalidate(ctx context.Context, keys ...string) {
+	if tx, ok := ctx.Value(cacheTxKey{}).(*cacheTx); ok {
+		tx.mu.Lock()
+		tx.keys = append(tx.keys, keys...)
+		tx.mu.Unlock()
+	}
+
+	r.delete(ctx, keys...)
+}
+
+func (r *cachedRepository) delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		cacheStats.Add(`errors`, 1)
+		log.Printf(`Cache delete error %s`, err.Error())
+	}
+}
+
+func inCacheTx(ctx context.Context) bool {
+	_, ok := ctx.Value(cacheTxKey{}).(*cacheTx)
+	return ok
+}
+
+// tenantUser returns a copy of user with their role in the tenant carried
+// by ctx, like tenantMembership, or nil when they are not a member of it.
+func tenantUser(ctx context.Context, user *entity.User, members []entity.OrganizationMember) *entity.User {
+	orgID, ok := tenant.ID(ctx)
+	if !ok {
+		orgID = user.OrganizationID
+	}
+
+	for _, member := range members {
+		if member.OrganizationID == orgID {
+			scoped := *user
+			scoped.Role = member.Role
+			return &scoped
+		}
+	}
+
+	return nil
+}
+
+// foundTenantUser is tenantUser returning ErrNotFound instead of nil.
+func foundTenantUser(ctx context.Context, user *entity.User, members []entity.OrganizationMember) (*entity.User, error) {
+	if user := tenantUser(ctx, user, members); user != nil {
+		return user, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func userIDKey(userID int) string {
+	return fmt.Sprintf(`user:id:%d`, userID)
+}
+
+func membershipsKey(userID int) string {
+	return fmt.Sprintf(`user:memberships:%d`, userID)
+}
+
+// phoneNumberKey is specific to the tenant, since the phone number may
+// belong to a different user in each of them. Lookups without a tenant use
+// organization 0. With a keyring, the key holds the blind index of the phone
+// number rather than the number.
+func (r *cachedRepository) phoneNumberKey(ctx context.Context, phoneNumber string) string {
+	orgID, _ := tenant.ID(ctx)
+	if r.keyring != nil {
+		return fmt.Sprintf(`user:phone_number_index:%d:%s`, orgID, r.keyring.BlindIndex(phoneNumberField, phoneNumber))
+	}
+
+	return fmt.Sprintf(`user:phone_number:%d:%s`, orgID, phoneNumber)
+}
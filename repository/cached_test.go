@@ -0,0 +1,217 @@
+package repository
+
+import (
+	"context"
+	"expvar"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedRepository(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Repository {
+		return NewCachedRepository(NewMemoryRepository(), NewLRUCache(100), time.Minute, nil)
+	})
+}
+
+func TestEncryptedCachedRepository(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Repository {
+		return NewCachedRepository(NewMemoryRepository(), NewLRUCache(100), time.Minute, testKeyring(t, `k1`))
+	})
+}
+
+// recordingCache is a Cache keeping every key and value it is given.
+type recordingCache struct {
+	Cache
+	written []string
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.written = append(c.written, key, string(value))
+	return c.Cache.Set(ctx, key, value, ttl)
+}
+
+func TestCachedRepositoryEncryption(t *testing.T) {
+	ctx := context.Background()
+	cache := &recordingCache{Cache: NewLRUCache(100)}
+	repo := NewCachedRepository(NewMemoryRepository(), cache, time.Minute, testKeyring(t, `k1`))
+
+	user := &entity.User{FullName: `User123`, PhoneNumber: `+62123456789`, Password: `password-hash`, AccountSalt: `account-salt`}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first lookups fill the cache and the second ones are served from it.
+	for i := 0; i < 2; i++ {
+		got, err := repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+		if assert.NoError(t, err) {
+			assert.Equal(t, user.ID, got.ID)
+			assert.Equal(t, `+62123456789`, got.PhoneNumber)
+			assert.Equal(t, `password-hash`, got.Password)
+			assert.Equal(t, `account-salt`, got.AccountSalt)
+		}
+
+		got, err = repo.GetUserByID(ctx, user.ID)
+		if assert.NoError(t, err) {
+			assert.Equal(t, `+62123456789`, got.PhoneNumber)
+			assert.Equal(t, `password-hash`, got.Password)
+		}
+	}
+
+	assert.NotEmpty(t, cache.written)
+	for _, written := range cache.written {
+		assert.NotContains(t, written, `123456789`)
+		assert.NotContains(t, written, `password-hash`)
+		assert.NotContains(t, written, `account-salt`)
+	}
+}
+
+func TestCachedRepositoryCaching(t *testing.T) {
+	ctx := context.Background()
+
+	// setup returns a cached repository whose backing store can be changed
+	// behind the cache's back, to tell cached reads from fresh ones.
+	setup := func(t *testing.T) (Repository, *memoryRepository, *entity.User) {
+		inner := NewMemoryRepository().(*memoryRepository)
+		repo := NewCachedRepository(inner, NewLRUCache(100), time.Minute, nil)
+
+		user := &entity.User{FullName: `User123`, PhoneNumber: `+62123456789`}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		return repo, inner, user
+	}
+
+	rename := func(inner *memoryRepository, userID int, fullName string) {
+		inner.mu.Lock()
+		defer inner.mu.Unlock()
+
+		user := inner.data.users[userID]
+		user.FullName = fullName
+		inner.data.users[userID] = user
+	}
+
+	stat := func(name string) int64 {
+		if v, ok := cacheStats.Get(name).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+
+	t.Run(`LookupsAreCachedAndCounted`, func(t *testing.T) {
+		repo, inner, user := setup(t)
+		hits, misses := stat(`hits`), stat(`misses`)
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, `User123`, got.FullName)
+
+		rename(inner, user.ID, `Changed Behind Cache`)
+
+		got, _ = repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, `User123`, got.FullName)
+		got, _ = repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+		assert.Equal(t, `User123`, got.FullName)
+
+		assert.Equal(t, int64(2), stat(`hits`)-hits)
+		assert.Equal(t, int64(1), stat(`misses`)-misses)
+	})
+
+	t.Run(`MissesAreNotCached`, func(t *testing.T) {
+		repo, _, _ := setup(t)
+
+		got, err := repo.GetUserByPhoneNumber(ctx, `+62123456780`)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		user := &entity.User{PhoneNumber: `+62123456780`}
+		assert.NoError(t, repo.Create(ctx, user))
+
+		got, _ = repo.GetUserByPhoneNumber(ctx, `+62123456780`)
+		assert.NotNil(t, got)
+	})
+
+	invalidations := []struct {
+		name  string
+		write func(repo Repository, user *entity.User) error
+	}{
+		{
+			name: `IncrementSuccessfulLogin`,
+			write: func(repo Repository, user *entity.User) error {
+				return repo.IncrementSuccessfulLogin(ctx, user.ID, time.Now())
+			},
+		},
+		{
+			name: `IncrementFailedLogin`,
+			write: func(repo Repository, user *entity.User) error {
+				return repo.IncrementFailedLogin(ctx, user.ID, time.Now())
+			},
+		},
+		{
+			name: `UpdateProfile`,
+			write: func(repo Repository, user *entity.User) error {
+				return repo.UpdateProfile(ctx, &entity.User{ID: user.ID, Version: user.Version})
+			},
+		},
+		{
+			name: `UpdatePassword`,
+			write: func(repo Repository, user *entity.User) error {
+				return repo.UpdatePassword(ctx, &entity.User{ID: user.ID, Password: `new-hash`})
+			},
+		},
+		{
+			name: `WriteInTransaction`,
+			write: func(repo Repository, user *entity.User) error {
+				return repo.WithTransaction(ctx, func(ctx context.Context) error {
+					return repo.IncrementSuccessfulLogin(ctx, user.ID, time.Now())
+				})
+			},
+		},
+	}
+	for _, tt := range invalidations {
+		t.Run(tt.name+`Invalidates`, func(t *testing.T) {
+			repo, inner, user := setup(t)
+
+			cached, _ := repo.GetUserByID(ctx, user.ID)
+			rename(inner, user.ID, `Changed Behind Cache`)
+
+			assert.NoError(t, tt.write(repo, cached))
+
+			got, _ := repo.GetUserByID(ctx, user.ID)
+			assert.Equal(t, `Changed Behind Cache`, got.FullName)
+			got, _ = repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+			assert.Equal(t, `Changed Behind Cache`, got.FullName)
+		})
+	}
+
+	t.Run(`ChangedPhoneNumberIsNotServedFromCache`, func(t *testing.T) {
+		repo, _, user := setup(t)
+
+		cached, _ := repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+		cached.PhoneNumber = `+62123456780`
+		assert.NoError(t, repo.UpdateProfile(ctx, cached))
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, `+62123456780`, got.PhoneNumber)
+
+		got, err := repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`ReadsInTransactionBypassCache`, func(t *testing.T) {
+		repo, inner, user := setup(t)
+
+		_, _ = repo.GetUserByID(ctx, user.ID)
+		rename(inner, user.ID, `Changed Behind Cache`)
+
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			got, err := repo.GetUserByID(ctx, user.ID)
+			assert.Equal(t, `Changed Behind Cache`, got.FullName)
+			return err
+		})
+		assert.NoError(t, err)
+	})
+}
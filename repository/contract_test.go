@@ -0,0 +1,1011 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+// runContractTests checks the behaviour every Repository implementation must
+// share. newRepo must return an empty repository for each call.
+func runContractTests(t *testing.T, newRepo func(t *testing.T) Repository) {
+	ctx := context.Background()
+
+	newUser := func(phoneNumber string) *entity.User {
+		now := time.Now()
+		return &entity.User{
+			FullName:    `User123`,
+			PhoneNumber: phoneNumber,
+			Password:    `hashed`,
+			AccountSalt: `salt`,
+			CreatedAt:   now,
+			UpdatedAt:   &now,
+		}
+	}
+
+	t.Run(`NotFoundReturnsErrNotFound`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		got, err := repo.GetUserByID(ctx, 1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		got, err = repo.GetUserByPhoneNumber(ctx, `+62123456789`)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`CreateAssignsIDsAndDefaults`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := newUser(`+62123456781`)
+		second := newUser(`+62123456782`)
+		if !assert.NoError(t, repo.Create(ctx, first)) || !assert.NoError(t, repo.Create(ctx, second)) {
+			return
+		}
+		assert.Greater(t, first.ID, 0)
+		assert.Greater(t, second.ID, first.ID)
+
+		got, err := repo.GetUserByID(ctx, second.ID)
+		if !assert.NoError(t, err) || !assert.NotNil(t, got) {
+			return
+		}
+		assert.Equal(t, second.PhoneNumber, got.PhoneNumber)
+		assert.Equal(t, `User123`, got.FullName)
+		assert.Equal(t, entity.RoleUser, got.Role)
+		assert.Equal(t, 1, got.Version)
+
+		got, err = repo.GetUserByPhoneNumber(ctx, first.PhoneNumber)
+		if !assert.NoError(t, err) || !assert.NotNil(t, got) {
+			return
+		}
+		assert.Equal(t, first.ID, got.ID)
+	})
+
+	t.Run(`CreateDuplicatePhoneNumberConflicts`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		assert.NoError(t, repo.Create(ctx, newUser(`+62123456789`)))
+
+		err := repo.Create(ctx, newUser(`+62123456789`))
+		assert.True(t, IsConflict(err), `expected conflict, got %v`, err)
+	})
+
+	t.Run(`ConcurrentCreateOnlyOneWins`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			created   int
+			conflicts int
+		)
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := repo.Create(ctx, newUser(`+62123456789`))
+
+				mu.Lock()
+				defer mu.Unlock()
+				if err == nil {
+					created++
+				} else if IsConflict(err) {
+					conflicts++
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, 1, created)
+		assert.Equal(t, 7, conflicts)
+	})
+
+	t.Run(`CreateUsers`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		users := []*entity.User{newUser(`+62123456781`), newUser(`+62123456782`)}
+		if !assert.NoError(t, repo.CreateUsers(ctx, users)) {
+			return
+		}
+		assert.Greater(t, users[0].ID, 0)
+		assert.Greater(t, users[1].ID, users[0].ID)
+
+		got, err := repo.GetUserByPhoneNumber(ctx, `+62123456782`)
+		if !assert.NoError(t, err) || !assert.NotNil(t, got) {
+			return
+		}
+		assert.Equal(t, users[1].ID, got.ID)
+		assert.Equal(t, entity.RoleUser, got.Role)
+		assert.Equal(t, 1, got.Version)
+
+		taken, err := repo.ListTakenPhoneNumbers(ctx, []string{`+62123456781`, `+62123456783`, `+62123456782`})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{`+62123456781`, `+62123456782`}, taken)
+	})
+
+	t.Run(`CreateUsersIsAllOrNothing`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		assert.NoError(t, repo.Create(ctx, newUser(`+62123456782`)))
+
+		err := repo.CreateUsers(ctx, []*entity.User{newUser(`+62123456781`), newUser(`+62123456782`)})
+		assert.True(t, IsConflict(err), `expected conflict, got %v`, err)
+
+		got, err := repo.GetUserByPhoneNumber(ctx, `+62123456781`)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`ListUsers`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i, phoneNumber := range []string{`+62123456781`, `+62123456782`, `+62123456783`} {
+			user := newUser(phoneNumber)
+			user.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+			updatedAt := base.Add(time.Duration(i) * 24 * time.Hour)
+			user.UpdatedAt = &updatedAt
+			if !assert.NoError(t, repo.Create(ctx, user)) {
+				return
+			}
+		}
+
+		phoneNumbers := func(users []entity.User) []string {
+			var got []string
+			for _, user := range users {
+				got = append(got, user.PhoneNumber)
+			}
+			return got
+		}
+
+		page, err := repo.ListUsers(ctx, entity.UserFilter{Limit: 2})
+		if !assert.NoError(t, err) || !assert.Len(t, page, 2) {
+			return
+		}
+		assert.Equal(t, []string{`+62123456781`, `+62123456782`}, phoneNumbers(page))
+
+		page, err = repo.ListUsers(ctx, entity.UserFilter{AfterID: page[1].ID, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456783`}, phoneNumbers(page))
+
+		page, err = repo.ListUsers(ctx, entity.UserFilter{CreatedFrom: base.Add(time.Hour), CreatedTo: base.Add(2 * time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456782`}, phoneNumbers(page))
+
+		page, err = repo.ListUsers(ctx, entity.UserFilter{UpdatedFrom: base.Add(24 * time.Hour)})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456782`, `+62123456783`}, phoneNumbers(page))
+	})
+
+	t.Run(`SearchUsers`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		for phoneNumber, fullName := range map[string]string{
+			`+62123456781`: `Budi Santoso`,
+			`+62123456782`: `José Santosa`,
+			`+62987654323`: `Siti Aisyah`,
+		} {
+			user := newUser(phoneNumber)
+			user.FullName = fullName
+			if !assert.NoError(t, repo.Create(ctx, user)) {
+				return
+			}
+		}
+
+		phoneNumbers := func(matches []entity.UserMatch) []string {
+			var got []string
+			for _, match := range matches {
+				got = append(got, match.PhoneNumber)
+			}
+			return got
+		}
+
+		matches, err := repo.SearchUsers(ctx, entity.UserSearch{Query: `SANTOSO`, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456781`, `+62123456782`}, phoneNumbers(matches))
+		if len(matches) == 2 {
+			assert.Greater(t, matches[0].Score, matches[1].Score)
+		}
+
+		matches, err = repo.SearchUsers(ctx, entity.UserSearch{Query: `jose`, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456782`}, phoneNumbers(matches))
+
+		matches, err = repo.SearchUsers(ctx, entity.UserSearch{Query: `santoso`, Limit: 1, Offset: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62123456782`}, phoneNumbers(matches))
+
+		matches, err = repo.SearchUsers(ctx, entity.UserSearch{Query: `0987`, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{`+62987654323`}, phoneNumbers(matches))
+
+		matches, err = repo.SearchUsers(ctx, entity.UserSearch{Query: `agus`, Limit: 10})
+		assert.NoError(t, err)
+		assert.Empty(t, matches)
+	})
+
+	t.Run(`IncrementSuccessfulLogin`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+		failedAt := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+		loginAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		assert.NoError(t, repo.IncrementSuccessfulLogin(ctx, user.ID, loginAt))
+		assert.NoError(t, repo.IncrementFailedLogin(ctx, user.ID, failedAt))
+		assert.NoError(t, repo.IncrementFailedLogin(ctx, user.ID, failedAt))
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		if !assert.NoError(t, err) || !assert.NotNil(t, got) {
+			return
+		}
+		assert.Equal(t, 1, got.SuccessfulLogin)
+		assert.Equal(t, 2, got.FailedLoginCount)
+		if assert.NotNil(t, got.LastFailedLoginAt) {
+			assert.True(t, failedAt.Equal(*got.LastFailedLoginAt))
+		}
+
+		assert.NoError(t, repo.IncrementSuccessfulLogin(ctx, user.ID, loginAt))
+
+		got, _ = repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, 2, got.SuccessfulLogin)
+		assert.Equal(t, 0, got.FailedLoginCount)
+		if assert.NotNil(t, got.LastLoginAt) {
+			assert.True(t, loginAt.Equal(*got.LastLoginAt))
+		}
+	})
+
+	t.Run(`UpdateProfileChecksVersion`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+
+		first, _ := repo.GetUserByID(ctx, user.ID)
+		second, _ := repo.GetUserByID(ctx, user.ID)
+
+		first.FullName = `First Writer`
+		if !assert.NoError(t, repo.UpdateProfile(ctx, first)) {
+			return
+		}
+		assert.Equal(t, 2, first.Version)
+
+		second.FullName = `Second Writer`
+		err := repo.UpdateProfile(ctx, second)
+		assert.True(t, errors.Is(err, ErrVersionConflict), `expected version conflict, got %v`, err)
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, `First Writer`, got.FullName)
+		assert.Equal(t, 2, got.Version)
+	})
+
+	t.Run(`UpdateProfilePhoneNumber`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser(`+62123456781`)
+		other := newUser(`+62123456782`)
+		if !assert.NoError(t, repo.Create(ctx, user)) || !assert.NoError(t, repo.Create(ctx, other)) {
+			return
+		}
+
+		taken, _ := repo.GetUserByID(ctx, user.ID)
+		taken.PhoneNumber = other.PhoneNumber
+		err := repo.UpdateProfile(ctx, taken)
+		assert.True(t, IsConflict(err), `expected conflict, got %v`, err)
+
+		changed, _ := repo.GetUserByID(ctx, user.ID)
+		changed.PhoneNumber = `+62123456783`
+		if !assert.NoError(t, repo.UpdateProfile(ctx, changed)) {
+			return
+		}
+
+		got, _ := repo.GetUserByPhoneNumber(ctx, `+62123456783`)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, user.ID, got.ID)
+		}
+		got, err = repo.GetUserByPhoneNumber(ctx, `+62123456781`)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`UpdateProfileOptionalFields`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+
+		dateOfBirth, gender, address, avatarKey := `1990-01-02`, entity.GenderFemale, `Jl. Sudirman 1`, `avatars/1/a.jpg`
+		set, _ := repo.GetUserByID(ctx, user.ID)
+		set.DateOfBirth = &dateOfBirth
+		set.Gender = &gender
+		set.Address = &address
+		set.AvatarKey = &avatarKey
+		if !assert.NoError(t, repo.UpdateProfile(ctx, set)) {
+			return
+		}
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		if assert.NotNil(t, got.DateOfBirth) && assert.NotNil(t, got.Gender) && assert.NotNil(t, got.Address) {
+			assert.Equal(t, dateOfBirth, *got.DateOfBirth)
+			assert.Equal(t, gender, *got.Gender)
+			assert.Equal(t, address, *got.Address)
+		}
+		if assert.NotNil(t, got.AvatarKey) {
+			assert.Equal(t, avatarKey, *got.AvatarKey)
+		}
+		assert.Nil(t, got.PreferredLanguage)
+		assert.Equal(t, `User123`, got.FullName)
+
+		got.Address = nil
+		if !assert.NoError(t, repo.UpdateProfile(ctx, got)) {
+			return
+		}
+
+		got, _ = repo.GetUserByID(ctx, user.ID)
+		assert.Nil(t, got.Address)
+		if assert.NotNil(t, got.Gender) {
+			assert.Equal(t, gender, *got.Gender)
+		}
+	})
+
+	t.Run(`UpdatePassword`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		user := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+
+		user.Password = `new-hash`
+		user.AccountSalt = `new-salt`
+		assert.NoError(t, repo.UpdatePassword(ctx, user))
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		assert.Equal(t, `new-hash`, got.Password)
+		assert.Equal(t, `new-salt`, got.AccountSalt)
+	})
+
+	t.Run(`TransactionRollsBackOnError`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		errAbort := errors.New(`abort`)
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, newUser(`+62123456789`)); err != nil {
+				return err
+			}
+
+			got, err := repo.GetUserByPhoneNumber(ctx, `+62123456789`)
+			if err != nil || got == nil {
+				t.Errorf(`user created in the transaction is not visible inside it: %v`, err)
+			}
+
+			return errAbort
+		})
+		assert.Equal(t, errAbort, err)
+
+		got, err := repo.GetUserByPhoneNumber(ctx, `+62123456789`)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`TransactionCommits`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		var user *entity.User
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			user = newUser(`+62123456789`)
+			if err := repo.Create(ctx, user); err != nil {
+				return err
+			}
+			return repo.IncrementSuccessfulLogin(ctx, user.ID, time.Now())
+		})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		got, _ := repo.GetUserByID(ctx, user.ID)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, 1, got.SuccessfulLogin)
+		}
+	})
+
+	t.Run(`CreateImpersonationAudit`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		audit := &entity.ImpersonationAudit{
+			ActorID:    1,
+			UserID:     2,
+			Method:     `GET`,
+			Path:       `/profile/2`,
+			StatusCode: 200,
+			CreatedAt:  time.Now(),
+		}
+		assert.NoError(t, repo.CreateImpersonationAudit(ctx, audit))
+		assert.Greater(t, audit.ID, 0)
+	})
+
+	t.Run(`ListAuditLogsFilters`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		entries := []*entity.AuditLog{
+			{ActorID: 1, ActorRole: entity.RoleUser, Action: entity.AuditActionRegistered, UserID: 1, Changes: `{}`, CreatedAt: base},
+			{ActorID: 1, ActorRole: entity.RoleUser, Action: entity.AuditActionProfileUpdated, UserID: 1, Changes: `{}`, CreatedAt: base.Add(time.Hour)},
+			{ActorID: 2, ActorRole: entity.RoleAdmin, Action: entity.AuditActionProfileUpdated, UserID: 1, Changes: `{}`, CreatedAt: base.Add(2 * time.Hour)},
+			{ActorID: 3, ActorRole: entity.RoleUser, Action: entity.AuditActionRegistered, UserID: 3, Changes: `{}`, CreatedAt: base.Add(3 * time.Hour)},
+		}
+		for _, entry := range entries {
+			if !assert.NoError(t, repo.CreateAuditLog(ctx, entry)) {
+				return
+			}
+			assert.Greater(t, entry.ID, 0)
+		}
+
+		ids := func(filter entity.AuditLogFilter) []int {
+			got, err := repo.ListAuditLogs(ctx, filter)
+			assert.NoError(t, err)
+
+			ids := []int{}
+			for _, entry := range got {
+				ids = append(ids, entry.ID)
+			}
+			return ids
+		}
+
+		all := []int{entries[3].ID, entries[2].ID, entries[1].ID, entries[0].ID}
+		assert.Equal(t, all, ids(entity.AuditLogFilter{}))
+		assert.Equal(t, []int{entries[2].ID, entries[1].ID, entries[0].ID}, ids(entity.AuditLogFilter{UserID: 1}))
+		assert.Equal(t, []int{entries[2].ID}, ids(entity.AuditLogFilter{ActorID: 2}))
+		assert.Equal(t, []int{entries[2].ID, entries[1].ID}, ids(entity.AuditLogFilter{Action: entity.AuditActionProfileUpdated}))
+		assert.Equal(t, []int{entries[2].ID, entries[1].ID}, ids(entity.AuditLogFilter{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)}))
+		assert.Equal(t, []int{entries[2].ID, entries[1].ID}, ids(entity.AuditLogFilter{Limit: 2, Offset: 1}))
+		assert.Equal(t, []int{}, ids(entity.AuditLogFilter{Offset: 4}))
+	})
+
+	t.Run(`AuditLogRollsBackWithTransaction`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		errAbort := errors.New(`abort`)
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			user := newUser(`+62123456789`)
+			if err := repo.Create(ctx, user); err != nil {
+				return err
+			}
+			if err := repo.CreateAuditLog(ctx, &entity.AuditLog{ActorID: user.ID, Action: entity.AuditActionRegistered, UserID: user.ID, Changes: `{}`}); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		assert.Equal(t, errAbort, err)
+
+		got, err := repo.ListAuditLogs(ctx, entity.AuditLogFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run(`ListLoginEvents`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		userID, otherID := 1, 2
+		events := []*entity.LoginEvent{
+			{UserID: &userID, PhoneNumber: `+62123456781`, Success: true, CreatedAt: time.Now()},
+			{UserID: &otherID, PhoneNumber: `+62123456782`, Success: true, CreatedAt: time.Now()},
+			{UserID: nil, PhoneNumber: `+62123456783`, FailureReason: entity.LoginFailureUnknownPhoneNumber, CreatedAt: time.Now()},
+			{UserID: &userID, PhoneNumber: `+62123456781`, FailureReason: entity.LoginFailureWrongPassword, IPAddress: `127.0.0.1`, UserAgent: `curl`, CreatedAt: time.Now()},
+			{UserID: &userID, PhoneNumber: `+62123456781`, Success: true, CreatedAt: time.Now()},
+		}
+		for _, event := range events {
+			if !assert.NoError(t, repo.CreateLoginEvent(ctx, event)) {
+				return
+			}
+			assert.Greater(t, event.ID, 0)
+		}
+
+		got, err := repo.ListLoginEvents(ctx, userID, 10, 0)
+		if !assert.NoError(t, err) || !assert.Len(t, got, 3) {
+			return
+		}
+		assert.Equal(t, events[4].ID, got[0].ID)
+		assert.Equal(t, events[3].ID, got[1].ID)
+		assert.Equal(t, events[0].ID, got[2].ID)
+		assert.False(t, got[1].Success)
+		assert.Equal(t, entity.LoginFailureWrongPassword, got[1].FailureReason)
+		assert.Equal(t, `127.0.0.1`, got[1].IPAddress)
+		assert.Equal(t, `curl`, got[1].UserAgent)
+		if assert.NotNil(t, got[1].UserID) {
+			assert.Equal(t, userID, *got[1].UserID)
+		}
+
+		got, err = repo.ListLoginEvents(ctx, userID, 1, 1)
+		if assert.NoError(t, err) && assert.Len(t, got, 1) {
+			assert.Equal(t, events[3].ID, got[0].ID)
+		}
+	})
+
+	t.Run(`CreateLoginEventWithLongPhoneNumber`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		// A failed login records the phone number as entered, whatever its
+		// length.
+		event := &entity.LoginEvent{
+			PhoneNumber:   `+62` + strings.Repeat(`1`, 60),
+			FailureReason: entity.LoginFailureUnknownPhoneNumber,
+			CreatedAt:     time.Now(),
+		}
+		if assert.NoError(t, repo.CreateLoginEvent(ctx, event)) {
+			assert.Greater(t, event.ID, 0)
+		}
+	})
+
+	t.Run(`PhoneNumberChanges`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		now := time.Now()
+		first := &entity.PhoneNumberChange{UserID: 1, PhoneNumber: `+62123456782`, CodeHash: `hash-1`, ExpiresAt: now.Add(time.Minute), CreatedAt: now.Add(-time.Hour)}
+		other := &entity.PhoneNumberChange{UserID: 2, PhoneNumber: `+62123456783`, CodeHash: `hash-2`, ExpiresAt: now.Add(time.Minute), CreatedAt: now}
+		for _, change := range []*entity.PhoneNumberChange{first, other} {
+			if !assert.NoError(t, repo.CreatePhoneNumberChange(ctx, change)) {
+				return
+			}
+			assert.Greater(t, change.ID, 0)
+		}
+
+		got, err := repo.GetPendingPhoneNumberChange(ctx, 1, now)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, first.ID, got.ID)
+		assert.Equal(t, `+62123456782`, got.PhoneNumber)
+		assert.Equal(t, `hash-1`, got.CodeHash)
+		assert.Nil(t, got.ConfirmedAt)
+
+		_, err = repo.GetPendingPhoneNumberChange(ctx, 1, now.Add(2*time.Minute))
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		got.Attempts = 2
+		assert.NoError(t, repo.UpdatePhoneNumberChange(ctx, got))
+		got, err = repo.GetPendingPhoneNumberChange(ctx, 1, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 2, got.Attempts)
+		}
+
+		// Attempts are only counted below the limit.
+		for _, want := range []bool{true, false} {
+			counted, err := repo.IncrementPhoneNumberChangeAttempts(ctx, got.ID, 3)
+			assert.NoError(t, err)
+			assert.Equal(t, want, counted)
+		}
+		got, err = repo.GetPendingPhoneNumberChange(ctx, 1, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, 3, got.Attempts)
+		}
+
+		// A newer request supersedes the pending one, even once confirmed.
+		second := &entity.PhoneNumberChange{UserID: 1, PhoneNumber: `+62123456784`, CodeHash: `hash-3`, ExpiresAt: now.Add(time.Minute), CreatedAt: now}
+		if !assert.NoError(t, repo.CreatePhoneNumberChange(ctx, second)) {
+			return
+		}
+		second.ConfirmedAt = &now
+		assert.NoError(t, repo.UpdatePhoneNumberChange(ctx, second))
+		_, err = repo.GetPendingPhoneNumberChange(ctx, 1, now)
+		assert.ErrorIs(t, err, ErrNotFound)
+
+		counts := []struct {
+			filter entity.PhoneNumberChangeFilter
+			want   int
+		}{
+			{entity.PhoneNumberChangeFilter{UserID: 1}, 2},
+			{entity.PhoneNumberChangeFilter{UserID: 1, CreatedFrom: now.Add(-time.Minute)}, 1},
+			{entity.PhoneNumberChangeFilter{UserID: 1, ConfirmedFrom: now.Add(-time.Minute)}, 1},
+			{entity.PhoneNumberChangeFilter{UserID: 1, ConfirmedFrom: now.Add(time.Minute)}, 0},
+			{entity.PhoneNumberChangeFilter{UserID: 2, ConfirmedFrom: now.Add(-time.Minute)}, 0},
+		}
+		for _, c := range counts {
+			count, err := repo.CountPhoneNumberChanges(ctx, c.filter)
+			assert.NoError(t, err)
+			assert.Equal(t, c.want, count, `%+v`, c.filter)
+		}
+	})
+
+	t.Run(`OutboxEvents`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		var events []*entity.OutboxEvent
+		for i := 1; i <= 3; i++ {
+			event := &entity.OutboxEvent{Type: entity.EventUserLoggedIn, UserID: i, Payload: `{}`, CreatedAt: time.Now()}
+			if !assert.NoError(t, repo.CreateOutboxEvent(ctx, event)) {
+				return
+			}
+			events = append(events, event)
+		}
+
+		assert.NoError(t, repo.MarkOutboxEventPublished(ctx, events[0].ID, time.Now()))
+
+		got, err := repo.ListPendingOutboxEvents(ctx, 10)
+		if !assert.NoError(t, err) || !assert.Len(t, got, 2) {
+			return
+		}
+		assert.Equal(t, events[1].ID, got[0].ID)
+		assert.Equal(t, events[2].ID, got[1].ID)
+		assert.Equal(t, entity.EventUserLoggedIn, got[0].Type)
+		assert.Equal(t, 2, got[0].UserID)
+		assert.Equal(t, `{}`, got[0].Payload)
+		assert.Nil(t, got[0].PublishedAt)
+
+		got, err = repo.ListPendingOutboxEvents(ctx, 1)
+		if assert.NoError(t, err) && assert.Len(t, got, 1) {
+			assert.Equal(t, events[1].ID, got[0].ID)
+		}
+	})
+
+	t.Run(`OutboxEventRollsBackWithTransaction`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			event := &entity.OutboxEvent{Type: entity.EventUserRegistered, UserID: 1, Payload: `{}`, CreatedAt: time.Now()}
+			if err := repo.CreateOutboxEvent(ctx, event); err != nil {
+				return err
+			}
+			return errors.New(`rollback`)
+		})
+		assert.Error(t, err)
+
+		got, err := repo.ListPendingOutboxEvents(ctx, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+	})
+
+	t.Run(`WebhookSubscriptions`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		first := &entity.WebhookSubscription{URL: `https://example.com/a`, EventTypes: `UserRegistered,ProfileUpdated`, Secret: `secret-a`, CreatedAt: time.Now()}
+		second := &entity.WebhookSubscription{URL: `https://example.com/b`, EventTypes: `UserLoggedIn`, Secret: `secret-b`, CreatedAt: time.Now()}
+		for _, subscription := range []*entity.WebhookSubscription{first, second} {
+			if !assert.NoError(t, repo.CreateWebhookSubscription(ctx, subscription)) {
+				return
+			}
+		}
+
+		got, err := repo.GetWebhookSubscription(ctx, first.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, `https://example.com/a`, got.URL)
+			assert.Equal(t, `secret-a`, got.Secret)
+			assert.True(t, got.Subscribes(entity.EventProfileUpdated))
+			assert.False(t, got.Subscribes(entity.EventUserLoggedIn))
+		}
+
+		assert.NoError(t, repo.DeleteWebhookSubscription(ctx, first.ID))
+
+		got, err = repo.GetWebhookSubscription(ctx, first.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		list, err := repo.ListWebhookSubscriptions(ctx)
+		if assert.NoError(t, err) && assert.Len(t, list, 1) {
+			assert.Equal(t, second.ID, list[0].ID)
+		}
+	})
+
+	t.Run(`WebhookDeliveries`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		now := time.Now()
+		deliveries := []*entity.WebhookDelivery{
+			{SubscriptionID: 1, EventID: 10, EventType: entity.EventUserRegistered, Payload: `{}`, Status: entity.WebhookDeliveryPending, NextAttemptAt: now.Add(-time.Minute), CreatedAt: now},
+			{SubscriptionID: 2, EventID: 10, EventType: entity.EventUserRegistered, Payload: `{}`, Status: entity.WebhookDeliveryPending, NextAttemptAt: now.Add(time.Minute), CreatedAt: now},
+			{SubscriptionID: 1, EventID: 11, EventType: entity.EventUserLoggedIn, Payload: `{}`, Status: entity.WebhookDeliverySucceeded, NextAttemptAt: now.Add(-time.Minute), CreatedAt: now},
+			{SubscriptionID: 1, EventID: 12, EventType: entity.EventUserLoggedIn, Payload: `{}`, Status: entity.WebhookDeliveryPending, NextAttemptAt: now, CreatedAt: now},
+		}
+		for _, delivery := range deliveries {
+			if !assert.NoError(t, repo.CreateWebhookDelivery(ctx, delivery)) {
+				return
+			}
+		}
+
+		due, err := repo.ListDueWebhookDeliveries(ctx, now, 10)
+		if assert.NoError(t, err) && assert.Len(t, due, 2) {
+			assert.Equal(t, deliveries[0].ID, due[0].ID)
+			assert.Equal(t, deliveries[3].ID, due[1].ID)
+		}
+
+		has, err := repo.HasWebhookDeliveries(ctx, 10)
+		assert.NoError(t, err)
+		assert.True(t, has)
+		has, err = repo.HasWebhookDeliveries(ctx, 13)
+		assert.NoError(t, err)
+		assert.False(t, has)
+
+		list, err := repo.ListWebhookDeliveries(ctx, 1, 10, 0)
+		if assert.NoError(t, err) && assert.Len(t, list, 3) {
+			assert.Equal(t, deliveries[3].ID, list[0].ID)
+			assert.Equal(t, deliveries[0].ID, list[2].ID)
+		}
+		list, err = repo.ListWebhookDeliveries(ctx, 1, 1, 1)
+		if assert.NoError(t, err) && assert.Len(t, list, 1) {
+			assert.Equal(t, deliveries[2].ID, list[0].ID)
+		}
+
+		delivered := deliveries[0]
+		delivered.Status = entity.WebhookDeliverySucceeded
+		delivered.Attempts = 2
+		delivered.LastStatusCode = 204
+		delivered.DeliveredAt = &now
+		assert.NoError(t, repo.UpdateWebhookDelivery(ctx, delivered))
+
+		got, err := repo.GetWebhookDelivery(ctx, delivered.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, entity.WebhookDeliverySucceeded, got.Status)
+			assert.Equal(t, 2, got.Attempts)
+			assert.Equal(t, 204, got.LastStatusCode)
+			assert.NotNil(t, got.DeliveredAt)
+		}
+
+		got, err = repo.GetWebhookDelivery(ctx, 100)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		for _, code := range []int{500, 204} {
+			attempt := &entity.WebhookAttempt{DeliveryID: delivered.ID, StatusCode: code, CreatedAt: now}
+			assert.NoError(t, repo.CreateWebhookAttempt(ctx, attempt))
+		}
+		attempts, err := repo.ListWebhookAttempts(ctx, delivered.ID)
+		if assert.NoError(t, err) && assert.Len(t, attempts, 2) {
+			assert.Equal(t, 500, attempts[0].StatusCode)
+			assert.Equal(t, 204, attempts[1].StatusCode)
+		}
+	})
+
+	t.Run(`Jobs`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		now := time.Now()
+		key := `prune@1`
+		jobs := []*entity.Job{
+			{Type: `a`, Payload: `{}`, Status: entity.JobPending, MaxAttempts: 3, RunAt: now.Add(-time.Minute), CreatedAt: now},
+			{Type: `b`, Payload: `{}`, Status: entity.JobPending, MaxAttempts: 3, RunAt: now.Add(-2 * time.Minute), CreatedAt: now},
+			{Type: `a`, Payload: `{}`, Status: entity.JobPending, MaxAttempts: 3, RunAt: now.Add(time.Minute), CreatedAt: now},
+			{Type: `a`, Payload: `{}`, Status: entity.JobPending, MaxAttempts: 3, RunAt: now.Add(-2 * time.Minute), UniqueKey: &key, CreatedAt: now},
+		}
+		for _, job := range jobs {
+			if !assert.NoError(t, repo.CreateJob(ctx, job)) {
+				return
+			}
+		}
+
+		duplicate := &entity.Job{Type: `a`, Payload: `{}`, Status: entity.JobPending, MaxAttempts: 3, RunAt: now, UniqueKey: &key, CreatedAt: now}
+		assert.NoError(t, repo.CreateJob(ctx, duplicate))
+		assert.Zero(t, duplicate.ID)
+
+		claim := entity.JobClaim{Types: []string{`a`}, Now: now, LockedBefore: now.Add(-time.Hour), Limit: 10}
+		claimed, err := repo.ClaimJobs(ctx, claim)
+		if assert.NoError(t, err) && assert.Len(t, claimed, 2) {
+			assert.Equal(t, jobs[3].ID, claimed[0].ID)
+			assert.Equal(t, jobs[0].ID, claimed[1].ID)
+			assert.Equal(t, entity.JobRunning, claimed[0].Status)
+			assert.Equal(t, 1, claimed[0].Attempts)
+			assert.NotNil(t, claimed[0].LockedAt)
+		}
+
+		// Running jobs are only taken again once their lock has expired.
+		claimed, err = repo.ClaimJobs(ctx, claim)
+		assert.NoError(t, err)
+		assert.Empty(t, claimed)
+
+		claim.LockedBefore = now.Add(time.Second)
+		claim.Limit = 1
+		claimed, err = repo.ClaimJobs(ctx, claim)
+		if assert.NoError(t, err) && assert.Len(t, claimed, 1) {
+			assert.Equal(t, jobs[3].ID, claimed[0].ID)
+			assert.Equal(t, 2, claimed[0].Attempts)
+		}
+
+		finished := claimed[0]
+		finished.Status = entity.JobSucceeded
+		finished.LockedAt = nil
+		finished.FinishedAt = &now
+		assert.NoError(t, repo.UpdateJob(ctx, &finished))
+
+		got, err := repo.GetJob(ctx, finished.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, entity.JobSucceeded, got.Status)
+			assert.Equal(t, 2, got.Attempts)
+			assert.Nil(t, got.LockedAt)
+			assert.NotNil(t, got.FinishedAt)
+		}
+
+		deleted, err := repo.DeleteSucceededJobs(ctx, now.Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.Zero(t, deleted)
+		deleted, err = repo.DeleteSucceededJobs(ctx, now.Add(time.Minute))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, deleted)
+
+		got, err = repo.GetJob(ctx, finished.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`Organizations`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		got, err := repo.GetOrganization(ctx, tenant.DefaultID)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, `Default`, got.Name)
+		}
+
+		organization := &entity.Organization{Name: `Acme`, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateOrganization(ctx, organization)) {
+			return
+		}
+		assert.Greater(t, organization.ID, tenant.DefaultID)
+
+		got, err = repo.GetOrganization(ctx, organization.ID+1)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		user := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+		assert.Equal(t, tenant.DefaultID, user.OrganizationID)
+
+		member := &entity.OrganizationMember{OrganizationID: organization.ID, UserID: user.ID, Role: entity.RoleAdmin, CreatedAt: time.Now()}
+		assert.NoError(t, repo.AddOrganizationMember(ctx, member))
+		err = repo.AddOrganizationMember(ctx, member)
+		assert.True(t, IsConflict(err), `expected conflict, got %v`, err)
+
+		members, err := repo.ListMemberships(ctx, user.ID)
+		if assert.NoError(t, err) && assert.Len(t, members, 2) {
+			assert.Equal(t, tenant.DefaultID, members[0].OrganizationID)
+			assert.Equal(t, entity.RoleUser, members[0].Role)
+			assert.Equal(t, organization.ID, members[1].OrganizationID)
+			assert.Equal(t, entity.RoleAdmin, members[1].Role)
+		}
+	})
+
+	t.Run(`TenantScopesUsers`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		organization := &entity.Organization{Name: `Acme`, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateOrganization(ctx, organization)) {
+			return
+		}
+		defaultCtx := tenant.WithID(ctx, tenant.DefaultID)
+		acmeCtx := tenant.WithID(ctx, organization.ID)
+
+		user := newUser(`+62123456781`)
+		other := newUser(`+62123456782`)
+		if !assert.NoError(t, repo.Create(defaultCtx, user)) || !assert.NoError(t, repo.Create(acmeCtx, other)) {
+			return
+		}
+		assert.Equal(t, organization.ID, other.OrganizationID)
+
+		got, err := repo.GetUserByID(acmeCtx, user.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+		got, err = repo.GetUserByPhoneNumber(defaultCtx, other.PhoneNumber)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		// Writes to users outside the tenant change nothing.
+		assert.NoError(t, repo.UpdatePassword(acmeCtx, &entity.User{ID: user.ID, Password: `changed`}))
+		got, _ = repo.GetUserByID(defaultCtx, user.ID)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, `hashed`, got.Password)
+		}
+
+		member := &entity.OrganizationMember{OrganizationID: organization.ID, UserID: user.ID, Role: entity.RoleAdmin, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.AddOrganizationMember(ctx, member)) {
+			return
+		}
+
+		got, err = repo.GetUserByID(acmeCtx, user.ID)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, entity.RoleAdmin, got.Role)
+		}
+		got, _ = repo.GetUserByID(defaultCtx, user.ID)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, entity.RoleUser, got.Role)
+		}
+		got, _ = repo.GetUserByID(ctx, user.ID)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, entity.RoleUser, got.Role)
+		}
+
+		users, err := repo.ListUsers(acmeCtx, entity.UserFilter{})
+		if assert.NoError(t, err) && assert.Len(t, users, 2) {
+			assert.Equal(t, entity.RoleAdmin, users[0].Role)
+		}
+		users, _ = repo.ListUsers(defaultCtx, entity.UserFilter{})
+		assert.Len(t, users, 1)
+
+		matches, err := repo.SearchUsers(defaultCtx, entity.UserSearch{Query: `user123`})
+		assert.NoError(t, err)
+		assert.Len(t, matches, 1)
+
+		taken, err := repo.ListTakenPhoneNumbers(defaultCtx, []string{user.PhoneNumber, other.PhoneNumber})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{user.PhoneNumber}, taken)
+	})
+
+	t.Run(`PhoneNumberScope`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		organization := &entity.Organization{Name: `Acme`, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateOrganization(ctx, organization)) {
+			return
+		}
+		acmeCtx := tenant.WithID(ctx, organization.ID)
+
+		global := newUser(`+62123456789`)
+		if !assert.NoError(t, repo.Create(ctx, global)) {
+			return
+		}
+
+		scoped := newUser(`+62123456789`)
+		scoped.PhoneNumberScope = organization.ID
+		if !assert.NoError(t, repo.Create(acmeCtx, scoped)) {
+			return
+		}
+
+		duplicate := newUser(`+62123456789`)
+		duplicate.PhoneNumberScope = organization.ID
+		err := repo.Create(acmeCtx, duplicate)
+		assert.True(t, IsConflict(err), `expected conflict, got %v`, err)
+
+		got, err := repo.GetUserByPhoneNumber(acmeCtx, `+62123456789`)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, scoped.ID, got.ID)
+		}
+		got, err = repo.GetUserByPhoneNumber(tenant.WithID(ctx, tenant.DefaultID), `+62123456789`)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, global.ID, got.ID)
+		}
+	})
+
+	t.Run(`TenantScopesRecords`, func(t *testing.T) {
+		repo := newRepo(t)
+
+		acmeCtx := tenant.WithID(ctx, 2)
+		defaultCtx := tenant.WithID(ctx, tenant.DefaultID)
+
+		entry := &entity.AuditLog{ActorID: 1, Action: entity.AuditActionRegistered, UserID: 1, Changes: `{}`, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateAuditLog(acmeCtx, entry)) {
+			return
+		}
+		assert.Equal(t, 2, entry.OrganizationID)
+
+		got, err := repo.ListAuditLogs(defaultCtx, entity.AuditLogFilter{})
+		assert.NoError(t, err)
+		assert.Empty(t, got)
+		got, _ = repo.ListAuditLogs(acmeCtx, entity.AuditLogFilter{})
+		assert.Len(t, got, 1)
+		got, _ = repo.ListAuditLogs(ctx, entity.AuditLogFilter{})
+		assert.Len(t, got, 1)
+
+		subscription := &entity.WebhookSubscription{URL: `https://example.com/hook`, EventTypes: `*`, Secret: `secret`, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateWebhookSubscription(acmeCtx, subscription)) {
+			return
+		}
+		found, err := repo.GetWebhookSubscription(defaultCtx, subscription.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, found)
+		assert.NoError(t, repo.DeleteWebhookSubscription(defaultCtx, subscription.ID))
+		subscriptions, _ := repo.ListWebhookSubscriptions(acmeCtx)
+		assert.Len(t, subscriptions, 1)
+	})
+}
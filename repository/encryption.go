@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/sawitpro/technical_test/entity"
+	"gorm.io/gorm"
+)
+
+// phoneNumberField names the phone number to the keyring, which derives the
+// keys of the field from it.
+const phoneNumberField = `user.phone_number`
+
+// The fields of the login history and audit log encrypted along with the
+// phone numbers of users.
+const (
+	loginEventPhoneNumberField = `login_event.phone_number`
+	auditLogChangesField       = `audit_log.changes`
+)
+
+var errNoKeyring = errors.New(`phone number is encrypted but no keyring is configured`)
+
+// sealUser sets the encrypted phone number and its blind index of a user
+// about to be written, or clears them when there is no keyring.
+func (r *repositoryCtx) sealUser(user *entity.User) error {
+	k := r.cfg.Keyring
+	if k == nil {
+		user.PhoneNumberCiphertext, user.PhoneNumberIndex = nil, nil
+		return nil
+	}
+
+	ciphertext, err := k.Encrypt(phoneNumberField, user.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	index := k.BlindIndex(phoneNumberField, user.PhoneNumber)
+
+	user.PhoneNumberCiphertext, user.PhoneNumberIndex = &ciphertext, &index
+	return nil
+}
+
+// sealUsers is sealUser for each of users.
+func (r *repositoryCtx) sealUsers(users []*entity.User) error {
+	for _, user := range users {
+		if err := r.sealUser(user); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// omitPhoneNumber leaves the plain text phone number NULL in an insert when
+// it is encrypted.
+func (r *repositoryCtx) omitPhoneNumber(db *gorm.DB) *gorm.DB {
+	if r.cfg.Keyring != nil {
+		return db.Omit(`phone_number`)
+	}
+
+	return db
+}
+
+// phoneNumberColumns returns the columns to update for a new phone number of
+// a sealed user.
+func phoneNumberColumns(user *entity.User) map[string]interface{} {
+	data := map[string]interface{}{
+		`phone_number`:            nil,
+		`phone_number_ciphertext`: user.PhoneNumberCiphertext,
+		`phone_number_index`:      user.PhoneNumberIndex,
+	}
+	if user.PhoneNumberCiphertext == nil {
+		data[`phone_number`] = user.PhoneNumber
+	}
+
+	return data
+}
+
+// openUser decrypts the phone number of a user read from the database.
+// Users written without a keyring keep it in plain text.
+func (r *repositoryCtx) openUser(user *entity.User) error {
+	if user.PhoneNumberCiphertext == nil {
+		return nil
+	}
+	if r.cfg.Keyring == nil {
+		return errNoKeyring
+	}
+
+	phoneNumber, err := r.cfg.Keyring.Decrypt(phoneNumberField, *user.PhoneNumberCiphertext)
+	if err != nil {
+		log.Printf(`Decrypt user %d error %s`, user.ID, err.Error())
+		return err
+	}
+
+	user.PhoneNumber = phoneNumber
+	return nil
+}
+
+// openUsers is openUser for each of users.
+func (r *repositoryCtx) openUsers(users []entity.User) error {
+	for i := range users {
+		if err := r.openUser(&users[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encryptColumn encrypts plaintext as field when a keyring is configured,
+// returning nil when there is none.
+func (r *repositoryCtx) encryptColumn(field string, plaintext string) (*string, error) {
+	if r.cfg.Keyring == nil {
+		return nil, nil
+	}
+
+	ciphertext, err := r.cfg.Keyring.Encrypt(field, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &ciphertext, nil
+}
+
+// decryptColumn decrypts the ciphertext of field into plaintext, which is
+// left as read when the value was written without a keyring.
+func (r *repositoryCtx) decryptColumn(field string, ciphertext *string, plaintext *string) error {
+	if ciphertext == nil {
+		return nil
+	}
+	if r.cfg.Keyring == nil {
+		return errNoKeyring
+	}
+
+	value, err := r.cfg.Keyring.Decrypt(field, *ciphertext)
+	if err != nil {
+		return err
+	}
+
+	*plaintext = value
+	return nil
+}
+
+// phoneNumberIn returns the condition matching users with any of
+// phoneNumbers: by blind index, and in plain text for the users written
+// without a keyring or not re-encrypted yet. It also returns the phone
+// number of each blind index.
+func (r *repositoryCtx) phoneNumberIn(phoneNumbers []string) (string, []interface{}, map[string]string) {
+	k := r.cfg.Keyring
+	if k == nil {
+		return `"user"."phone_number" IN ?`, []interface{}{phoneNumbers}, nil
+	}
+
+	indexes := make([]string, 0, len(phoneNumbers))
+	byIndex := make(map[string]string, len(phoneNumbers))
+	for _, phoneNumber := range phoneNumbers {
+		index := k.BlindIndex(phoneNumberField, phoneNumber)
+		indexes = append(indexes, index)
+		byIndex[index] = phoneNumber
+	}
+
+	return `("user"."phone_number_index" IN ? OR "user"."phone_number" IN ?)`, []interface{}{indexes, phoneNumbers}, byIndex
+}
+
+func (r *repositoryCtx) ReencryptUsers(ctx context.Context, afterID int, limit int, decrypt bool) (int, int, error) {
+	var (
+		users     []entity.User
+		rewritten int
+		err       error
+	)
+
+	if r.cfg.Keyring == nil {
+		return 0, 0, errors.New(`no keyring is configured`)
+	}
+
+	db := r.write(ctx)
+
+	err = db.Select(`id`, `phone_number`, `phone_number_ciphertext`).
+		Where(`id > ?`, afterID).Order(`id`).Limit(limit).Find(&users).Error
+	if err != nil {
+		log.Printf(`List users to reencrypt error %s`, err.Error())
+		return 0, 0, err
+	}
+	if len(users) == 0 {
+		return 0, 0, nil
+	}
+
+	for i := range users {
+		user := &users[i]
+		ciphertext, phoneNumber := user.PhoneNumberCiphertext, user.PhoneNumber
+
+		if decrypt && ciphertext == nil {
+			continue
+		}
+		if !decrypt && ciphertext != nil && r.cfg.Keyring.IsPrimary(*ciphertext) {
+			continue
+		}
+
+		if err = r.openUser(user); err != nil {
+			return 0, rewritten, err
+		}
+		if decrypt {
+			user.PhoneNumberCiphertext, user.PhoneNumberIndex = nil, nil
+		} else if err = r.sealUser(user); err != nil {
+			return 0, rewritten, err
+		}
+
+		// Matching the stored values makes the update a no-op when the
+		// phone number changed since it was read.
+		result := db.Model(&entity.User{}).
+			Where(`id = ? AND phone_number IS NOT DISTINCT FROM ? AND phone_number_ciphertext IS NOT DISTINCT FROM ?`,
+				user.ID, nullIfEmpty(phoneNumber), ciphertext).
+			Updates(phoneNumberColumns(user))
+		if err = result.Error; err != nil {
+			log.Printf(`Reencrypt user %d error %s`, user.ID, err.Error())
+			return 0, rewritten, translateError(err)
+		}
+		rewritten += int(result.RowsAffected)
+	}
+
+	return users[len(users)-1].ID, rewritten, nil
+}
+
+// nullIfEmpty returns nil for the empty string, which is how a NULL column
+// is read into a string.
+func nullIfEmpty(value string) interface{} {
+	if value == `` {
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,242 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/keyring"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// testKeyring returns a keyring with the keys k1 and k2, of which primary
+// encrypts new phone numbers.
+func testKeyring(t *testing.T, primary string) *keyring.Keyring {
+	key := func(b byte) string {
+		return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), keyring.KeySize)))
+	}
+
+	k, err := keyring.Parse([]byte(fmt.Sprintf(`{"primary":%q,"keys":{"k1":%q,"k2":%q},"index_key":%q}`,
+		primary, key('a'), key('b'), key('i'))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// storedPhoneNumber is a phone number as stored in the user table.
+type storedPhoneNumber struct {
+	PhoneNumber           *string
+	PhoneNumberCiphertext *string
+	PhoneNumberIndex      *string
+}
+
+func TestPhoneNumberEncryption(t *testing.T) {
+	ctx := context.Background()
+
+	newUser := func(phoneNumber string) *entity.User {
+		return &entity.User{
+			FullName:    `User123`,
+			PhoneNumber: phoneNumber,
+			Password:    `hashed`,
+			AccountSalt: `salt`,
+			CreatedAt:   time.Now(),
+		}
+	}
+	stored := func(t *testing.T, db *gorm.DB, userID int) storedPhoneNumber {
+		var s storedPhoneNumber
+		err := db.Table(`user`).Select(`phone_number`, `phone_number_ciphertext`, `phone_number_index`).
+			Where(`id = ?`, userID).Take(&s).Error
+		assert.NoError(t, err)
+		return s
+	}
+
+	t.Run(`StoresCiphertextAndIndex`, func(t *testing.T) {
+		db := newSQLiteDB(t)
+		repo := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+
+		user := newUser(`+62123456781`)
+		if !assert.NoError(t, repo.Create(ctx, user)) {
+			return
+		}
+
+		s := stored(t, db, user.ID)
+		assert.Nil(t, s.PhoneNumber)
+		if assert.NotNil(t, s.PhoneNumberCiphertext) && assert.NotNil(t, s.PhoneNumberIndex) {
+			assert.True(t, strings.HasPrefix(*s.PhoneNumberCiphertext, `k1:`))
+			assert.NotContains(t, *s.PhoneNumberCiphertext, `123456781`)
+			assert.Len(t, *s.PhoneNumberIndex, 64)
+		}
+
+		got, err := repo.GetUserByPhoneNumber(ctx, `+62123456781`)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, user.ID, got.ID)
+			assert.Equal(t, `+62123456781`, got.PhoneNumber)
+		}
+	})
+
+	t.Run(`EncryptsPhoneNumberChanges`, func(t *testing.T) {
+		db := newSQLiteDB(t)
+		repo := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+
+		now := time.Now()
+		change := &entity.PhoneNumberChange{UserID: 1, PhoneNumber: `+62123456782`, CodeHash: `hash`, ExpiresAt: now.Add(time.Minute), CreatedAt: now}
+		if !assert.NoError(t, repo.CreatePhoneNumberChange(ctx, change)) {
+			return
+		}
+
+		var s storedPhoneNumber
+		assert.NoError(t, db.Table(`phone_number_change`).Select(`phone_number`, `phone_number_ciphertext`).Where(`id = ?`, change.ID).Take(&s).Error)
+		assert.Nil(t, s.PhoneNumber)
+		if assert.NotNil(t, s.PhoneNumberCiphertext) {
+			assert.NotContains(t, *s.PhoneNumberCiphertext, `123456782`)
+		}
+
+		got, err := repo.GetPendingPhoneNumberChange(ctx, 1, now)
+		if assert.NoError(t, err) {
+			assert.Equal(t, `+62123456782`, got.PhoneNumber)
+		}
+	})
+
+	t.Run(`EncryptsLoginEventsAndAuditLogs`, func(t *testing.T) {
+		db := newSQLiteDB(t)
+		repo := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+
+		userID := 1
+		changes := `{"phone_number":{"old":"+62123456781","new":"+62123456782"}}`
+		event := &entity.LoginEvent{UserID: &userID, PhoneNumber: `+62123456781`, Success: true, CreatedAt: time.Now()}
+		auditLog := &entity.AuditLog{ActorID: 1, Action: entity.AuditActionProfileUpdated, UserID: 1, Changes: changes, CreatedAt: time.Now()}
+		if !assert.NoError(t, repo.CreateLoginEvent(ctx, event)) || !assert.NoError(t, repo.CreateAuditLog(ctx, auditLog)) {
+			return
+		}
+
+		var storedEvent struct {
+			PhoneNumber           string
+			PhoneNumberCiphertext *string
+		}
+		assert.NoError(t, db.Table(`login_event`).Select(`phone_number`, `phone_number_ciphertext`).Where(`id = ?`, event.ID).Take(&storedEvent).Error)
+		assert.Empty(t, storedEvent.PhoneNumber)
+		if assert.NotNil(t, storedEvent.PhoneNumberCiphertext) {
+			assert.NotContains(t, *storedEvent.PhoneNumberCiphertext, `123456781`)
+		}
+
+		var storedLog struct {
+			Changes           string
+			ChangesCiphertext *string
+		}
+		assert.NoError(t, db.Table(`audit_log`).Select(`changes`, `changes_ciphertext`).Where(`id = ?`, auditLog.ID).Take(&storedLog).Error)
+		assert.Equal(t, `{}`, storedLog.Changes)
+		if assert.NotNil(t, storedLog.ChangesCiphertext) {
+			assert.NotContains(t, *storedLog.ChangesCiphertext, `12345678`)
+		}
+
+		events, err := repo.ListLoginEvents(ctx, 1, 10, 0)
+		if assert.NoError(t, err) && assert.Len(t, events, 1) {
+			assert.Equal(t, `+62123456781`, events[0].PhoneNumber)
+		}
+		logs, err := repo.ListAuditLogs(ctx, entity.AuditLogFilter{})
+		if assert.NoError(t, err) && assert.Len(t, logs, 1) {
+			assert.Equal(t, changes, logs[0].Changes)
+		}
+
+		// Without the keyring they cannot be read.
+		plain := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite})
+		_, err = plain.ListLoginEvents(ctx, 1, 10, 0)
+		assert.ErrorIs(t, err, errNoKeyring)
+		_, err = plain.ListAuditLogs(ctx, entity.AuditLogFilter{})
+		assert.ErrorIs(t, err, errNoKeyring)
+	})
+
+	t.Run(`FindsPlainTextUsers`, func(t *testing.T) {
+		db := newSQLiteDB(t)
+		plain := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite})
+		encrypted := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+
+		legacy := newUser(`+62123456781`)
+		if !assert.NoError(t, plain.Create(ctx, legacy)) || !assert.NoError(t, encrypted.Create(ctx, newUser(`+62123456782`))) {
+			return
+		}
+
+		got, err := encrypted.GetUserByPhoneNumber(ctx, `+62123456781`)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, legacy.ID, got.ID)
+		}
+
+		taken, err := encrypted.ListTakenPhoneNumbers(ctx, []string{`+62123456781`, `+62123456782`, `+62123456783`})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{`+62123456781`, `+62123456782`}, taken)
+
+		// Without the keyring an encrypted user cannot be read.
+		users, err := plain.ListUsers(ctx, entity.UserFilter{Limit: 10})
+		assert.ErrorIs(t, err, errNoKeyring)
+		assert.Nil(t, users)
+	})
+
+	t.Run(`ReencryptUsers`, func(t *testing.T) {
+		db := newSQLiteDB(t)
+		plain := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite})
+		k1 := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+		k2 := NewRepository(&config.Config{DB: db, DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k2`)})
+
+		legacy, old := newUser(`+62123456781`), newUser(`+62123456782`)
+		if !assert.NoError(t, plain.Create(ctx, legacy)) || !assert.NoError(t, k1.Create(ctx, old)) {
+			return
+		}
+
+		_, _, err := plain.ReencryptUsers(ctx, 0, 10, false)
+		assert.Error(t, err)
+
+		// Rotating to k2 rewrites both users, one batch at a time.
+		lastID, rewritten, err := k2.ReencryptUsers(ctx, 0, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, legacy.ID, lastID)
+		assert.Equal(t, 1, rewritten)
+		lastID, rewritten, err = k2.ReencryptUsers(ctx, lastID, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, old.ID, lastID)
+		assert.Equal(t, 1, rewritten)
+		lastID, _, err = k2.ReencryptUsers(ctx, lastID, 1, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, lastID)
+
+		for _, user := range []*entity.User{legacy, old} {
+			s := stored(t, db, user.ID)
+			assert.Nil(t, s.PhoneNumber)
+			if assert.NotNil(t, s.PhoneNumberCiphertext) {
+				assert.True(t, strings.HasPrefix(*s.PhoneNumberCiphertext, `k2:`))
+			}
+
+			got, err := k2.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+			if assert.NoError(t, err) && assert.NotNil(t, got) {
+				assert.Equal(t, user.ID, got.ID)
+			}
+		}
+
+		// Running it again has nothing left to rewrite.
+		_, rewritten, err = k2.ReencryptUsers(ctx, 0, 10, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, rewritten)
+
+		_, rewritten, err = k2.ReencryptUsers(ctx, 0, 10, true)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, rewritten)
+
+		s := stored(t, db, old.ID)
+		assert.Nil(t, s.PhoneNumberCiphertext)
+		assert.Nil(t, s.PhoneNumberIndex)
+		if assert.NotNil(t, s.PhoneNumber) {
+			assert.Equal(t, old.PhoneNumber, *s.PhoneNumber)
+		}
+
+		got, err := plain.GetUserByPhoneNumber(ctx, old.PhoneNumber)
+		if assert.NoError(t, err) && assert.NotNil(t, got) {
+			assert.Equal(t, old.ID, got.ID)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/sawitpro/technical_test/shared"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by the lookups of a single record when none
+// matches, including when it belongs to another tenant.
+var ErrNotFound = fmt.Errorf(`record %w`, shared.ErrNotFound)
+
+// ErrVersionConflict is returned by UpdateProfile when the stored version no
+// longer matches the version of the user being saved.
+var ErrVersionConflict = fmt.Errorf(`user version %w`, shared.ErrConflict)
+
+var errMemoryUniqueViolation = errors.New(`duplicate key value violates unique constraint`)
+
+// uniqueViolation is the Postgres error code for a unique constraint failure.
+const uniqueViolation = `23505`
+
+// ConflictError is returned when a write violates a unique constraint, for
+// example when two requests register the same phone number concurrently.
+type ConflictError struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf(`unique constraint %s violated: %s`, e.Constraint, e.Err.Error())
+}
+
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == shared.ErrConflict
+}
+
+// IsConflict reports whether err is or wraps a ConflictError.
+func IsConflict(err error) bool {
+	var conflictErr *ConflictError
+	return errors.As(err, &conflictErr)
+}
+
+// translateError converts driver specific errors into repository errors.
+func translateError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return &ConflictError{Constraint: pqErr.Constraint, Err: err}
+	}
+
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return &ConflictError{Err: err}
+	}
+
+	return err
+}
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/tenant"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const charset = `abcdefghijklmnopqrstuvwxyz1234567890`
@@ -18,6 +20,10 @@ func (r *repositoryCtx) Now() time.Time {
 }
 
 func (r *repositoryCtx) RandomString(length int) string {
+	return randomString(length)
+}
+
+func randomString(length int) string {
 	value := make([]byte, length)
 	for i := range value {
 		value[i] = charset[rand.Intn(len(charset))]
@@ -25,18 +31,26 @@ func (r *repositoryCtx) RandomString(length int) string {
 	return string(value)
 }
 
+// copyTime keeps gorm from writing its own update time through the caller's
+// pointer.
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
 func (r *repositoryCtx) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
 	var (
 		user = &entity.User{}
 		err  error
 	)
 
-	db := r.cfg.DB.WithContext(ctx)
-
-	err = db.First(user, "id = ?", userID).Error
+	err = r.first(ctx, user, `"user"."id" = ?`, userID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+			return nil, ErrNotFound
 		}
 
 		return nil, err
@@ -51,12 +65,11 @@ func (r *repositoryCtx) GetUserByPhoneNumber(ctx context.Context, phoneNumber st
 		err  error
 	)
 
-	db := r.cfg.DB.WithContext(ctx)
-
-	err = db.First(user, "phone_number = ?", phoneNumber).Error
+	cond, args, _ := r.phoneNumberIn([]string{phoneNumber})
+	err = r.first(ctx, user, append([]interface{}{cond}, args...)...)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil
+			return nil, ErrNotFound
 		}
 
 		return nil, err
@@ -65,14 +78,59 @@ func (r *repositoryCtx) GetUserByPhoneNumber(ctx context.Context, phoneNumber st
 	return user, nil
 }
 
-func (r *repositoryCtx) IncrementSuccessfulLogin(ctx context.Context, userID int) error {
+func (r *repositoryCtx) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	var (
+		users []entity.User
+		err   error
+	)
+
+	db := r.db(ctx).Scopes(tenantMembership(ctx)).Select(userColumns)
+
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where(`"user"."created_at" >= ?`, filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		db = db.Where(`"user"."created_at" < ?`, filter.CreatedTo)
+	}
+	if !filter.UpdatedFrom.IsZero() {
+		db = db.Where(`"user"."updated_at" >= ?`, filter.UpdatedFrom)
+	}
+	if !filter.UpdatedTo.IsZero() {
+		db = db.Where(`"user"."updated_at" < ?`, filter.UpdatedTo)
+	}
+	if filter.AfterID > 0 {
+		db = db.Where(`"user"."id" > ?`, filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+
+	err = db.Order(`"user"."id"`).Find(&users).Error
+	if err != nil {
+		log.Printf(`List users error %s`, err.Error())
+		return nil, err
+	}
+
+	if err = r.openUsers(users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *repositoryCtx) IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error {
 	var (
 		err error
 	)
 
-	db := r.cfg.DB.WithContext(ctx)
+	db := r.write(ctx)
 
-	err = db.Model(&entity.User{}).Where(`id = ?`, userID).Update("successful_login", gorm.Expr("successful_login + ?", 1)).Error
+	column := clause.Column{Name: `successful_login`}
+	err = db.Model(&entity.User{}).Scopes(tenantMember(ctx)).Where(`id = ?`, userID).Updates(map[string]interface{}{
+		column.Name:          gorm.Expr(`? + ?`, column, 1),
+		`last_login_at`:      at,
+		`failed_login_count`: 0,
+	}).Error
 	if err != nil {
 		log.Printf(`Increment successful login error %s`, err.Error())
 		return err
@@ -81,40 +139,608 @@ func (r *repositoryCtx) IncrementSuccessfulLogin(ctx context.Context, userID int
 	return nil
 }
 
+func (r *repositoryCtx) IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	column := clause.Column{Name: `failed_login_count`}
+	err = db.Model(&entity.User{}).Scopes(tenantMember(ctx)).Where(`id = ?`, userID).Updates(map[string]interface{}{
+		column.Name:            gorm.Expr(`? + ?`, column, 1),
+		`last_failed_login_at`: at,
+	}).Error
+	if err != nil {
+		log.Printf(`Increment failed login error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
 func (r *repositoryCtx) UpdateProfile(ctx context.Context, user *entity.User) error {
 	var (
 		err error
 	)
 
-	db := r.cfg.DB.WithContext(ctx)
+	db := r.write(ctx)
 
-	data := &entity.User{
-		PhoneNumber: user.PhoneNumber,
-		FullName:    user.FullName,
-		UpdatedAt:   user.UpdatedAt,
+	version := user.Version + 1
+
+	// An empty phone number or full name leaves the column unchanged, while
+	// the optional fields are always written so that nil clears them.
+	data := map[string]interface{}{
+		`date_of_birth`:      user.DateOfBirth,
+		`gender`:             user.Gender,
+		`address`:            user.Address,
+		`preferred_language`: user.PreferredLanguage,
+		`avatar_key`:         user.AvatarKey,
+		`updated_at`:         copyTime(user.UpdatedAt),
+		`version`:            version,
+	}
+	if user.PhoneNumber != `` {
+		if err = r.sealUser(user); err != nil {
+			return err
+		}
+		for column, value := range phoneNumberColumns(user) {
+			data[column] = value
+		}
+	}
+	if user.FullName != `` {
+		data[`full_name`] = user.FullName
 	}
 
-	err = db.Model(user).Updates(data).Error
+	// The version check is part of the UPDATE so that concurrent writers
+	// cannot both succeed.
+	result := db.Model(&entity.User{}).Scopes(tenantMember(ctx)).Where(`id = ? AND version = ?`, user.ID, user.Version).Updates(data)
+	err = result.Error
 	if err != nil {
 		log.Printf(`Update profile error %s`, err.Error())
+		return translateError(err)
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	user.Version = version
+	return nil
+}
+
+func (r *repositoryCtx) UpdatePassword(ctx context.Context, user *entity.User) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	data := &entity.User{
+		Password:    user.Password,
+		AccountSalt: user.AccountSalt,
+		UpdatedAt:   copyTime(user.UpdatedAt),
+	}
+
+	err = db.Model(&entity.User{}).Scopes(tenantMember(ctx)).Where(`id = ?`, user.ID).Updates(data).Error
+	if err != nil {
+		log.Printf(`Update password error %s`, err.Error())
 		return err
 	}
 
 	return nil
 }
 
+// Create also makes the user a member of the organization creating them.
 func (r *repositoryCtx) Create(ctx context.Context, user *entity.User) error {
+	setTenant(ctx, &user.OrganizationID)
+	if err := r.sealUser(user); err != nil {
+		return err
+	}
+
+	return r.WithTransaction(ctx, func(ctx context.Context) error {
+		var (
+			err error
+		)
+
+		db := r.write(ctx)
+
+		err = db.Scopes(r.omitPhoneNumber).Create(user).Error
+		if err != nil {
+			log.Printf(`Create user Error %s`, err.Error())
+			return translateError(err)
+		}
+
+		members := ownerMemberships([]*entity.User{user})
+		err = db.Create(&members).Error
+		if err != nil {
+			log.Printf(`Create user membership error %s`, err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+// CreateUsers sends a single multi-row INSERT, so a taken phone number fails
+// the whole statement.
+func (r *repositoryCtx) CreateUsers(ctx context.Context, users []*entity.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	for _, user := range users {
+		setTenant(ctx, &user.OrganizationID)
+	}
+	if err := r.sealUsers(users); err != nil {
+		return err
+	}
+
+	return r.WithTransaction(ctx, func(ctx context.Context) error {
+		var (
+			err error
+		)
+
+		db := r.write(ctx)
+
+		err = db.Scopes(r.omitPhoneNumber).Create(&users).Error
+		if err != nil {
+			log.Printf(`Create users error %s`, err.Error())
+			return translateError(err)
+		}
+
+		members := ownerMemberships(users)
+		err = db.Create(&members).Error
+		if err != nil {
+			log.Printf(`Create user memberships error %s`, err.Error())
+			return err
+		}
+
+		return nil
+	})
+}
+
+func (r *repositoryCtx) ListTakenPhoneNumbers(ctx context.Context, phoneNumbers []string) ([]string, error) {
+	var (
+		users []entity.User
+		taken []string
+		err   error
+	)
+
+	if len(phoneNumbers) == 0 {
+		return nil, nil
+	}
+
+	db := r.db(ctx)
+
+	cond, args, byIndex := r.phoneNumberIn(phoneNumbers)
+	err = db.Scopes(tenantMembership(ctx)).Select(`"user"."phone_number", "user"."phone_number_index"`).
+		Where(cond, args...).Find(&users).Error
+	if err != nil {
+		log.Printf(`List taken phone numbers error %s`, err.Error())
+		return nil, err
+	}
+
+	// Encrypted numbers are recognized by their blind index.
+	for _, user := range users {
+		if user.PhoneNumberIndex != nil {
+			taken = append(taken, byIndex[*user.PhoneNumberIndex])
+		} else {
+			taken = append(taken, user.PhoneNumber)
+		}
+	}
+
+	return taken, nil
+}
+
+func (r *repositoryCtx) CreateImpersonationAudit(ctx context.Context, audit *entity.ImpersonationAudit) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &audit.OrganizationID)
+
+	db := r.write(ctx)
+
+	err = db.Create(audit).Error
+	if err != nil {
+		log.Printf(`Create impersonation audit error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) CreateLoginEvent(ctx context.Context, event *entity.LoginEvent) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &event.OrganizationID)
+
+	db := r.write(ctx)
+
+	event.PhoneNumberCiphertext, err = r.encryptColumn(loginEventPhoneNumberField, event.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	if event.PhoneNumberCiphertext != nil {
+		db = db.Omit(`phone_number`)
+	}
+
+	err = db.Create(event).Error
+	if err != nil {
+		log.Printf(`Create login event error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) ListLoginEvents(ctx context.Context, userID int, limit int, offset int) ([]entity.LoginEvent, error) {
+	var (
+		events []entity.LoginEvent
+		err    error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Where(`user_id = ?`, userID).Order(`id DESC`).Limit(limit).Offset(offset).Find(&events).Error
+	if err != nil {
+		log.Printf(`List login events error %s`, err.Error())
+		return nil, err
+	}
+
+	for i := range events {
+		event := &events[i]
+		if err = r.decryptColumn(loginEventPhoneNumberField, event.PhoneNumberCiphertext, &event.PhoneNumber); err != nil {
+			log.Printf(`Decrypt login event %d error %s`, event.ID, err.Error())
+			return nil, err
+		}
+	}
+
+	return events, nil
+}
+
+func (r *repositoryCtx) CreateAuditLog(ctx context.Context, auditLog *entity.AuditLog) error {
 	var (
 		err error
 	)
 
-	db := r.cfg.DB.WithContext(ctx)
+	setTenant(ctx, &auditLog.OrganizationID)
 
-	err = db.Create(user).Error
+	db := r.write(ctx)
+
+	auditLog.ChangesCiphertext, err = r.encryptColumn(auditLogChangesField, auditLog.Changes)
 	if err != nil {
-		log.Printf(`Create user Error %s`, err.Error())
+		return err
+	}
+	if auditLog.ChangesCiphertext != nil {
+		db = db.Omit(`changes`)
+	}
+
+	err = db.Create(auditLog).Error
+	if err != nil {
+		log.Printf(`Create audit log error %s`, err.Error())
 		return err
 	}
 
 	return nil
 }
+
+func (r *repositoryCtx) ListAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.AuditLog, error) {
+	var (
+		auditLogs []entity.AuditLog
+		err       error
+	)
+
+	db := r.db(ctx).Scopes(inTenant(ctx))
+
+	if filter.ActorID != 0 {
+		db = db.Where(`actor_id = ?`, filter.ActorID)
+	}
+	if filter.UserID != 0 {
+		db = db.Where(`user_id = ?`, filter.UserID)
+	}
+	if filter.Action != `` {
+		db = db.Where(`action = ?`, filter.Action)
+	}
+	if !filter.From.IsZero() {
+		db = db.Where(`created_at >= ?`, filter.From)
+	}
+	if !filter.To.IsZero() {
+		db = db.Where(`created_at < ?`, filter.To)
+	}
+	if filter.Limit > 0 {
+		db = db.Limit(filter.Limit)
+	}
+
+	err = db.Order(`id DESC`).Offset(filter.Offset).Find(&auditLogs).Error
+	if err != nil {
+		log.Printf(`List audit logs error %s`, err.Error())
+		return nil, err
+	}
+
+	for i := range auditLogs {
+		auditLog := &auditLogs[i]
+		if err = r.decryptColumn(auditLogChangesField, auditLog.ChangesCiphertext, &auditLog.Changes); err != nil {
+			log.Printf(`Decrypt audit log %d error %s`, auditLog.ID, err.Error())
+			return nil, err
+		}
+	}
+
+	return auditLogs, nil
+}
+
+func (r *repositoryCtx) CreateOutboxEvent(ctx context.Context, event *entity.OutboxEvent) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &event.OrganizationID)
+
+	db := r.write(ctx)
+
+	err = db.Create(event).Error
+	if err != nil {
+		log.Printf(`Create outbox event error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) ListPendingOutboxEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	var (
+		events []entity.OutboxEvent
+		err    error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Where(`published_at IS NULL`).Order(`id`).Limit(limit).Find(&events).Error
+	if err != nil {
+		log.Printf(`List pending outbox events error %s`, err.Error())
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (r *repositoryCtx) MarkOutboxEventPublished(ctx context.Context, eventID int, at time.Time) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Model(&entity.OutboxEvent{}).Scopes(inTenant(ctx)).Where(`id = ?`, eventID).Update(`published_at`, at).Error
+	if err != nil {
+		log.Printf(`Mark outbox event published error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) CreateWebhookSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &subscription.OrganizationID)
+
+	db := r.write(ctx)
+
+	err = db.Create(subscription).Error
+	if err != nil {
+		log.Printf(`Create webhook subscription error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) GetWebhookSubscription(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error) {
+	var (
+		subscription = &entity.WebhookSubscription{}
+		err          error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).First(subscription, `id = ?`, subscriptionID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+
+		log.Printf(`Get webhook subscription error %s`, err.Error())
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+func (r *repositoryCtx) ListWebhookSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	var (
+		subscriptions []entity.WebhookSubscription
+		err           error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Order(`id`).Find(&subscriptions).Error
+	if err != nil {
+		log.Printf(`List webhook subscriptions error %s`, err.Error())
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (r *repositoryCtx) DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Delete(&entity.WebhookSubscription{}, `id = ?`, subscriptionID).Error
+	if err != nil {
+		log.Printf(`Delete webhook subscription error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) CreateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &delivery.OrganizationID)
+
+	db := r.write(ctx)
+
+	err = db.Create(delivery).Error
+	if err != nil {
+		log.Printf(`Create webhook delivery error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) GetWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error) {
+	var (
+		delivery = &entity.WebhookDelivery{}
+		err      error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).First(delivery, `id = ?`, deliveryID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+
+		log.Printf(`Get webhook delivery error %s`, err.Error())
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+func (r *repositoryCtx) HasWebhookDeliveries(ctx context.Context, eventID int) (bool, error) {
+	var (
+		count int64
+		err   error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Model(&entity.WebhookDelivery{}).Scopes(inTenant(ctx)).Where(`event_id = ?`, eventID).Limit(1).Count(&count).Error
+	if err != nil {
+		log.Printf(`Count webhook deliveries error %s`, err.Error())
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (r *repositoryCtx) ListWebhookDeliveries(ctx context.Context, subscriptionID int, limit int, offset int) ([]entity.WebhookDelivery, error) {
+	var (
+		deliveries []entity.WebhookDelivery
+		err        error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Where(`subscription_id = ?`, subscriptionID).Order(`id DESC`).Limit(limit).Offset(offset).Find(&deliveries).Error
+	if err != nil {
+		log.Printf(`List webhook deliveries error %s`, err.Error())
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *repositoryCtx) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	var (
+		deliveries []entity.WebhookDelivery
+		err        error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Where(`status = ? AND next_attempt_at <= ?`, entity.WebhookDeliveryPending, now).Order(`id`).Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		log.Printf(`List due webhook deliveries error %s`, err.Error())
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *repositoryCtx) UpdateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	data := map[string]interface{}{
+		`status`:           delivery.Status,
+		`attempts`:         delivery.Attempts,
+		`next_attempt_at`:  delivery.NextAttemptAt,
+		`last_status_code`: delivery.LastStatusCode,
+		`last_error`:       delivery.LastError,
+		`delivered_at`:     copyTime(delivery.DeliveredAt),
+	}
+	err = db.Model(&entity.WebhookDelivery{}).Scopes(inTenant(ctx)).Where(`id = ?`, delivery.ID).Updates(data).Error
+	if err != nil {
+		log.Printf(`Update webhook delivery error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) CreateWebhookAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Create(attempt).Error
+	if err != nil {
+		log.Printf(`Create webhook attempt error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) ListWebhookAttempts(ctx context.Context, deliveryID int) ([]entity.WebhookAttempt, error) {
+	var (
+		attempts []entity.WebhookAttempt
+		err      error
+	)
+
+	db := r.db(ctx)
+
+	// Attempts belong to the tenant of their delivery.
+	if orgID, ok := tenant.ID(ctx); ok {
+		db = db.Where(`delivery_id IN (SELECT id FROM webhook_delivery WHERE organization_id = ?)`, orgID)
+	}
+
+	err = db.Where(`delivery_id = ?`, deliveryID).Order(`id`).Find(&attempts).Error
+	if err != nil {
+		log.Printf(`List webhook attempts error %s`, err.Error())
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"os"
+	"testing"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/migration"
+)
+
+// TestPostgresRepository runs against the database in TEST_POSTGRES_DSN,
+// for example "host=localhost user=postgres password=postgres
+// dbname=database_test sslmode=disable". Every table is emptied between
+// tests, so never point it at a database holding real data.
+func TestPostgresRepository(t *testing.T) {
+	dsn := os.Getenv(`TEST_POSTGRES_DSN`)
+	if dsn == `` || testing.Short() {
+		t.Skip(`TEST_POSTGRES_DSN is not set`)
+	}
+
+	db, err := config.OpenPostgres(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migration.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	runContractTests(t, func(t *testing.T) Repository {
+		err := db.Exec(`TRUNCATE "user", "organization_member", "impersonation_audit", "login_event", "audit_log", "outbox_event", "webhook_subscription", "webhook_delivery", "webhook_attempt", "phone_number_change", "job" RESTART IDENTITY`).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The default organization is kept, like after the migrations.
+		err = db.Exec(`DELETE FROM "organization" WHERE "id" <> 1`).Error
+		if err == nil {
+			err = db.Exec(`SELECT setval(pg_get_serial_sequence('organization', 'id'), 1)`).Error
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return NewRepository(&config.Config{DB: db, DBDriver: config.DriverPostgres})
+	})
+}
@@ -7,12 +7,116 @@ import (
 	"github.com/sawitpro/technical_test/entity"
 )
 
+// Repository queries are scoped to the organization carried by the context,
+// as set with tenant.WithID: users are only found, and updated, while they
+// are members of it, and other records only when they belong to it.
+// Records are created in it, or in the default organization when the
+// context has none. Without an organization, queries see every tenant.
+//
+// The Get methods return ErrNotFound when no record matches.
 type Repository interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	CreateOrganization(ctx context.Context, organization *entity.Organization) error
+	GetOrganization(ctx context.Context, organizationID int) (*entity.Organization, error)
+	// AddOrganizationMember returns a ConflictError when the user already
+	// is a member of the organization.
+	AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error
+	// ListMemberships returns the memberships of userID in every
+	// organization, ordered by organization.
+	ListMemberships(ctx context.Context, userID int) ([]entity.OrganizationMember, error)
+
 	GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error)
 	GetUserByID(ctx context.Context, userID int) (*entity.User, error)
+	// Create makes the user a member of their organization with their
+	// role, and returns a ConflictError when the phone number is taken in
+	// the user's PhoneNumberScope.
 	Create(ctx context.Context, user *entity.User) error
-	IncrementSuccessfulLogin(ctx context.Context, userID int) error
+	// CreateUsers inserts every user or none of them, returning a
+	// ConflictError when any of the phone numbers is taken.
+	CreateUsers(ctx context.Context, users []*entity.User) error
+	// ListTakenPhoneNumbers returns those of phoneNumbers that already
+	// belong to a user, in no particular order.
+	ListTakenPhoneNumbers(ctx context.Context, phoneNumbers []string) ([]string, error)
+	// ListUsers returns the users matching filter, ordered by id.
+	ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error)
+	// SearchUsers returns the users whose name is similar to the query or
+	// whose phone number contains its digits, best match first.
+	SearchUsers(ctx context.Context, filter entity.UserSearch) ([]entity.UserMatch, error)
+	// ReencryptUsers rewrites the phone numbers of up to limit users after
+	// the user afterID, in every organization: encrypted with the primary
+	// key of the keyring, or in plain text when decrypt is set. Users
+	// already stored that way are skipped. It returns the last user read,
+	// 0 once there are none left, and how many users were rewritten.
+	ReencryptUsers(ctx context.Context, afterID int, limit int, decrypt bool) (int, int, error)
+	// IncrementSuccessfulLogin also records at as the last login and
+	// resets the failed login count.
+	IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error
+	IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error
+	// UpdateProfile saves the profile only if the stored version still
+	// equals user.Version, returning ErrVersionConflict otherwise, and a
+	// ConflictError when the new phone number is taken.
 	UpdateProfile(ctx context.Context, user *entity.User) error
+	UpdatePassword(ctx context.Context, user *entity.User) error
+	CreatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error
+	// GetPendingPhoneNumberChange returns the latest phone number change of
+	// userID when it is neither confirmed nor expired at now.
+	GetPendingPhoneNumberChange(ctx context.Context, userID int, now time.Time) (*entity.PhoneNumberChange, error)
+	CountPhoneNumberChanges(ctx context.Context, filter entity.PhoneNumberChangeFilter) (int, error)
+	// IncrementPhoneNumberChangeAttempts counts an attempt at the code of the
+	// change id, returning false without counting it once maxAttempts were
+	// made.
+	IncrementPhoneNumberChangeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error)
+	// UpdatePhoneNumberChange saves the attempts and confirmation time of
+	// change.
+	UpdatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error
+	CreateImpersonationAudit(ctx context.Context, audit *entity.ImpersonationAudit) error
+	CreateLoginEvent(ctx context.Context, event *entity.LoginEvent) error
+	// ListLoginEvents returns the login attempts on userID, latest first.
+	ListLoginEvents(ctx context.Context, userID int, limit int, offset int) ([]entity.LoginEvent, error)
+	CreateAuditLog(ctx context.Context, log *entity.AuditLog) error
+	// ListAuditLogs returns the entries matching filter, latest written
+	// first.
+	ListAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.AuditLog, error)
+	CreateOutboxEvent(ctx context.Context, event *entity.OutboxEvent) error
+	// ListPendingOutboxEvents returns up to limit unpublished events,
+	// oldest first.
+	ListPendingOutboxEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error)
+	MarkOutboxEventPublished(ctx context.Context, eventID int, at time.Time) error
+	CreateWebhookSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error
+	GetWebhookSubscription(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error)
+	// ListWebhookSubscriptions returns every subscription, oldest first.
+	ListWebhookSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error
+	CreateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	GetWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error)
+	// HasWebhookDeliveries reports whether deliveries were already created
+	// for the outbox event.
+	HasWebhookDeliveries(ctx context.Context, eventID int) (bool, error)
+	// ListWebhookDeliveries returns the deliveries to subscriptionID,
+	// latest first.
+	ListWebhookDeliveries(ctx context.Context, subscriptionID int, limit int, offset int) ([]entity.WebhookDelivery, error)
+	// ListDueWebhookDeliveries returns up to limit pending deliveries whose
+	// next attempt is due at now, oldest first.
+	ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error)
+	// UpdateWebhookDelivery saves the status and attempt fields of delivery.
+	UpdateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error
+	CreateWebhookAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error
+	// ListWebhookAttempts returns the attempts of deliveryID, oldest first.
+	ListWebhookAttempts(ctx context.Context, deliveryID int) ([]entity.WebhookAttempt, error)
+	// CreateJob enqueues job, unless another job already has its
+	// UniqueKey, in which case nothing is written and job.ID is left 0.
+	CreateJob(ctx context.Context, job *entity.Job) error
+	GetJob(ctx context.Context, jobID int) (*entity.Job, error)
+	// ClaimJobs marks the jobs selected by claim as running, counts an
+	// attempt for each and returns them, earliest due first. Concurrent
+	// claims never return the same job.
+	ClaimJobs(ctx context.Context, claim entity.JobClaim) ([]entity.Job, error)
+	// UpdateJob saves the status, attempt and scheduling fields of job.
+	UpdateJob(ctx context.Context, job *entity.Job) error
+	// DeleteSucceededJobs deletes the jobs that succeeded before before and
+	// returns how many were deleted. Dead jobs are kept.
+	DeleteSucceededJobs(ctx context.Context, before time.Time) (int, error)
 
 	Now() time.Time
 	RandomString(length int) string
@@ -41,6 +41,50 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddOrganizationMember mocks base method.
+func (m *MockRepository) AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddOrganizationMember", ctx, member)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddOrganizationMember indicates an expected call of AddOrganizationMember.
+func (mr *MockRepositoryMockRecorder) AddOrganizationMember(ctx, member any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddOrganizationMember", reflect.TypeOf((*MockRepository)(nil).AddOrganizationMember), ctx, member)
+}
+
+// ClaimJobs mocks base method.
+func (m *MockRepository) ClaimJobs(ctx context.Context, claim entity.JobClaim) ([]entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimJobs", ctx, claim)
+	ret0, _ := ret[0].([]entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimJobs indicates an expected call of ClaimJobs.
+func (mr *MockRepositoryMockRecorder) ClaimJobs(ctx, claim any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimJobs", reflect.TypeOf((*MockRepository)(nil).ClaimJobs), ctx, claim)
+}
+
+// CountPhoneNumberChanges mocks base method.
+func (m *MockRepository) CountPhoneNumberChanges(ctx context.Context, filter entity.PhoneNumberChangeFilter) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountPhoneNumberChanges", ctx, filter)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountPhoneNumberChanges indicates an expected call of CountPhoneNumberChanges.
+func (mr *MockRepositoryMockRecorder) CountPhoneNumberChanges(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountPhoneNumberChanges", reflect.TypeOf((*MockRepository)(nil).CountPhoneNumberChanges), ctx, filter)
+}
+
 // Create mocks base method.
 func (m *MockRepository) Create(ctx context.Context, user *entity.User) error {
 	m.ctrl.T.Helper()
@@ -55,6 +99,234 @@ func (mr *MockRepositoryMockRecorder) Create(ctx, user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRepository)(nil).Create), ctx, user)
 }
 
+// CreateAuditLog mocks base method.
+func (m *MockRepository) CreateAuditLog(ctx context.Context, log *entity.AuditLog) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAuditLog", ctx, log)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAuditLog indicates an expected call of CreateAuditLog.
+func (mr *MockRepositoryMockRecorder) CreateAuditLog(ctx, log any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAuditLog", reflect.TypeOf((*MockRepository)(nil).CreateAuditLog), ctx, log)
+}
+
+// CreateImpersonationAudit mocks base method.
+func (m *MockRepository) CreateImpersonationAudit(ctx context.Context, audit *entity.ImpersonationAudit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateImpersonationAudit", ctx, audit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateImpersonationAudit indicates an expected call of CreateImpersonationAudit.
+func (mr *MockRepositoryMockRecorder) CreateImpersonationAudit(ctx, audit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateImpersonationAudit", reflect.TypeOf((*MockRepository)(nil).CreateImpersonationAudit), ctx, audit)
+}
+
+// CreateJob mocks base method.
+func (m *MockRepository) CreateJob(ctx context.Context, job *entity.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateJob indicates an expected call of CreateJob.
+func (mr *MockRepositoryMockRecorder) CreateJob(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateJob", reflect.TypeOf((*MockRepository)(nil).CreateJob), ctx, job)
+}
+
+// CreateLoginEvent mocks base method.
+func (m *MockRepository) CreateLoginEvent(ctx context.Context, event *entity.LoginEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoginEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateLoginEvent indicates an expected call of CreateLoginEvent.
+func (mr *MockRepositoryMockRecorder) CreateLoginEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoginEvent", reflect.TypeOf((*MockRepository)(nil).CreateLoginEvent), ctx, event)
+}
+
+// CreateOrganization mocks base method.
+func (m *MockRepository) CreateOrganization(ctx context.Context, organization *entity.Organization) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrganization", ctx, organization)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrganization indicates an expected call of CreateOrganization.
+func (mr *MockRepositoryMockRecorder) CreateOrganization(ctx, organization any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrganization", reflect.TypeOf((*MockRepository)(nil).CreateOrganization), ctx, organization)
+}
+
+// CreateOutboxEvent mocks base method.
+func (m *MockRepository) CreateOutboxEvent(ctx context.Context, event *entity.OutboxEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOutboxEvent", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOutboxEvent indicates an expected call of CreateOutboxEvent.
+func (mr *MockRepositoryMockRecorder) CreateOutboxEvent(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOutboxEvent", reflect.TypeOf((*MockRepository)(nil).CreateOutboxEvent), ctx, event)
+}
+
+// CreatePhoneNumberChange mocks base method.
+func (m *MockRepository) CreatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePhoneNumberChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePhoneNumberChange indicates an expected call of CreatePhoneNumberChange.
+func (mr *MockRepositoryMockRecorder) CreatePhoneNumberChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePhoneNumberChange", reflect.TypeOf((*MockRepository)(nil).CreatePhoneNumberChange), ctx, change)
+}
+
+// CreateUsers mocks base method.
+func (m *MockRepository) CreateUsers(ctx context.Context, users []*entity.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", ctx, users)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockRepositoryMockRecorder) CreateUsers(ctx, users any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockRepository)(nil).CreateUsers), ctx, users)
+}
+
+// CreateWebhookAttempt mocks base method.
+func (m *MockRepository) CreateWebhookAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookAttempt", ctx, attempt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookAttempt indicates an expected call of CreateWebhookAttempt.
+func (mr *MockRepositoryMockRecorder) CreateWebhookAttempt(ctx, attempt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookAttempt", reflect.TypeOf((*MockRepository)(nil).CreateWebhookAttempt), ctx, attempt)
+}
+
+// CreateWebhookDelivery mocks base method.
+func (m *MockRepository) CreateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookDelivery indicates an expected call of CreateWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) CreateWebhookDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).CreateWebhookDelivery), ctx, delivery)
+}
+
+// CreateWebhookSubscription mocks base method.
+func (m *MockRepository) CreateWebhookSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhookSubscription", ctx, subscription)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateWebhookSubscription indicates an expected call of CreateWebhookSubscription.
+func (mr *MockRepositoryMockRecorder) CreateWebhookSubscription(ctx, subscription any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhookSubscription", reflect.TypeOf((*MockRepository)(nil).CreateWebhookSubscription), ctx, subscription)
+}
+
+// DeleteSucceededJobs mocks base method.
+func (m *MockRepository) DeleteSucceededJobs(ctx context.Context, before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteSucceededJobs", ctx, before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteSucceededJobs indicates an expected call of DeleteSucceededJobs.
+func (mr *MockRepositoryMockRecorder) DeleteSucceededJobs(ctx, before any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteSucceededJobs", reflect.TypeOf((*MockRepository)(nil).DeleteSucceededJobs), ctx, before)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", ctx, subscriptionID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockRepositoryMockRecorder) DeleteWebhookSubscription(ctx, subscriptionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockRepository)(nil).DeleteWebhookSubscription), ctx, subscriptionID)
+}
+
+// GetJob mocks base method.
+func (m *MockRepository) GetJob(ctx context.Context, jobID int) (*entity.Job, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, jobID)
+	ret0, _ := ret[0].(*entity.Job)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockRepositoryMockRecorder) GetJob(ctx, jobID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockRepository)(nil).GetJob), ctx, jobID)
+}
+
+// GetOrganization mocks base method.
+func (m *MockRepository) GetOrganization(ctx context.Context, organizationID int) (*entity.Organization, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrganization", ctx, organizationID)
+	ret0, _ := ret[0].(*entity.Organization)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrganization indicates an expected call of GetOrganization.
+func (mr *MockRepositoryMockRecorder) GetOrganization(ctx, organizationID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrganization", reflect.TypeOf((*MockRepository)(nil).GetOrganization), ctx, organizationID)
+}
+
+// GetPendingPhoneNumberChange mocks base method.
+func (m *MockRepository) GetPendingPhoneNumberChange(ctx context.Context, userID int, now time.Time) (*entity.PhoneNumberChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingPhoneNumberChange", ctx, userID, now)
+	ret0, _ := ret[0].(*entity.PhoneNumberChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingPhoneNumberChange indicates an expected call of GetPendingPhoneNumberChange.
+func (mr *MockRepositoryMockRecorder) GetPendingPhoneNumberChange(ctx, userID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingPhoneNumberChange", reflect.TypeOf((*MockRepository)(nil).GetPendingPhoneNumberChange), ctx, userID, now)
+}
+
 // GetUserByID mocks base method.
 func (m *MockRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
 	m.ctrl.T.Helper()
@@ -85,18 +357,256 @@ func (mr *MockRepositoryMockRecorder) GetUserByPhoneNumber(ctx, phoneNumber any)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByPhoneNumber", reflect.TypeOf((*MockRepository)(nil).GetUserByPhoneNumber), ctx, phoneNumber)
 }
 
+// GetWebhookDelivery mocks base method.
+func (m *MockRepository) GetWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDelivery", ctx, deliveryID)
+	ret0, _ := ret[0].(*entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDelivery indicates an expected call of GetWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) GetWebhookDelivery(ctx, deliveryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).GetWebhookDelivery), ctx, deliveryID)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockRepository) GetWebhookSubscription(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", ctx, subscriptionID)
+	ret0, _ := ret[0].(*entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockRepositoryMockRecorder) GetWebhookSubscription(ctx, subscriptionID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockRepository)(nil).GetWebhookSubscription), ctx, subscriptionID)
+}
+
+// HasWebhookDeliveries mocks base method.
+func (m *MockRepository) HasWebhookDeliveries(ctx context.Context, eventID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasWebhookDeliveries", ctx, eventID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasWebhookDeliveries indicates an expected call of HasWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) HasWebhookDeliveries(ctx, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).HasWebhookDeliveries), ctx, eventID)
+}
+
+// IncrementFailedLogin mocks base method.
+func (m *MockRepository) IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementFailedLogin", ctx, userID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementFailedLogin indicates an expected call of IncrementFailedLogin.
+func (mr *MockRepositoryMockRecorder) IncrementFailedLogin(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementFailedLogin", reflect.TypeOf((*MockRepository)(nil).IncrementFailedLogin), ctx, userID, at)
+}
+
+// IncrementPhoneNumberChangeAttempts mocks base method.
+func (m *MockRepository) IncrementPhoneNumberChangeAttempts(ctx context.Context, id, maxAttempts int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementPhoneNumberChangeAttempts", ctx, id, maxAttempts)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementPhoneNumberChangeAttempts indicates an expected call of IncrementPhoneNumberChangeAttempts.
+func (mr *MockRepositoryMockRecorder) IncrementPhoneNumberChangeAttempts(ctx, id, maxAttempts any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementPhoneNumberChangeAttempts", reflect.TypeOf((*MockRepository)(nil).IncrementPhoneNumberChangeAttempts), ctx, id, maxAttempts)
+}
+
 // IncrementSuccessfulLogin mocks base method.
-func (m *MockRepository) IncrementSuccessfulLogin(ctx context.Context, userID int) error {
+func (m *MockRepository) IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IncrementSuccessfulLogin", ctx, userID)
+	ret := m.ctrl.Call(m, "IncrementSuccessfulLogin", ctx, userID, at)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // IncrementSuccessfulLogin indicates an expected call of IncrementSuccessfulLogin.
-func (mr *MockRepositoryMockRecorder) IncrementSuccessfulLogin(ctx, userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) IncrementSuccessfulLogin(ctx, userID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSuccessfulLogin", reflect.TypeOf((*MockRepository)(nil).IncrementSuccessfulLogin), ctx, userID, at)
+}
+
+// ListAuditLogs mocks base method.
+func (m *MockRepository) ListAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.AuditLog, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditLogs", ctx, filter)
+	ret0, _ := ret[0].([]entity.AuditLog)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditLogs indicates an expected call of ListAuditLogs.
+func (mr *MockRepositoryMockRecorder) ListAuditLogs(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditLogs", reflect.TypeOf((*MockRepository)(nil).ListAuditLogs), ctx, filter)
+}
+
+// ListDueWebhookDeliveries mocks base method.
+func (m *MockRepository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDueWebhookDeliveries", ctx, now, limit)
+	ret0, _ := ret[0].([]entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDueWebhookDeliveries indicates an expected call of ListDueWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) ListDueWebhookDeliveries(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDueWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).ListDueWebhookDeliveries), ctx, now, limit)
+}
+
+// ListLoginEvents mocks base method.
+func (m *MockRepository) ListLoginEvents(ctx context.Context, userID, limit, offset int) ([]entity.LoginEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLoginEvents", ctx, userID, limit, offset)
+	ret0, _ := ret[0].([]entity.LoginEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLoginEvents indicates an expected call of ListLoginEvents.
+func (mr *MockRepositoryMockRecorder) ListLoginEvents(ctx, userID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLoginEvents", reflect.TypeOf((*MockRepository)(nil).ListLoginEvents), ctx, userID, limit, offset)
+}
+
+// ListMemberships mocks base method.
+func (m *MockRepository) ListMemberships(ctx context.Context, userID int) ([]entity.OrganizationMember, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMemberships", ctx, userID)
+	ret0, _ := ret[0].([]entity.OrganizationMember)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMemberships indicates an expected call of ListMemberships.
+func (mr *MockRepositoryMockRecorder) ListMemberships(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMemberships", reflect.TypeOf((*MockRepository)(nil).ListMemberships), ctx, userID)
+}
+
+// ListPendingOutboxEvents mocks base method.
+func (m *MockRepository) ListPendingOutboxEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPendingOutboxEvents", ctx, limit)
+	ret0, _ := ret[0].([]entity.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPendingOutboxEvents indicates an expected call of ListPendingOutboxEvents.
+func (mr *MockRepositoryMockRecorder) ListPendingOutboxEvents(ctx, limit any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementSuccessfulLogin", reflect.TypeOf((*MockRepository)(nil).IncrementSuccessfulLogin), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPendingOutboxEvents", reflect.TypeOf((*MockRepository)(nil).ListPendingOutboxEvents), ctx, limit)
+}
+
+// ListTakenPhoneNumbers mocks base method.
+func (m *MockRepository) ListTakenPhoneNumbers(ctx context.Context, phoneNumbers []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTakenPhoneNumbers", ctx, phoneNumbers)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTakenPhoneNumbers indicates an expected call of ListTakenPhoneNumbers.
+func (mr *MockRepositoryMockRecorder) ListTakenPhoneNumbers(ctx, phoneNumbers any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTakenPhoneNumbers", reflect.TypeOf((*MockRepository)(nil).ListTakenPhoneNumbers), ctx, phoneNumbers)
+}
+
+// ListUsers mocks base method.
+func (m *MockRepository) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, filter)
+	ret0, _ := ret[0].([]entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRepositoryMockRecorder) ListUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRepository)(nil).ListUsers), ctx, filter)
+}
+
+// ListWebhookAttempts mocks base method.
+func (m *MockRepository) ListWebhookAttempts(ctx context.Context, deliveryID int) ([]entity.WebhookAttempt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookAttempts", ctx, deliveryID)
+	ret0, _ := ret[0].([]entity.WebhookAttempt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookAttempts indicates an expected call of ListWebhookAttempts.
+func (mr *MockRepositoryMockRecorder) ListWebhookAttempts(ctx, deliveryID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookAttempts", reflect.TypeOf((*MockRepository)(nil).ListWebhookAttempts), ctx, deliveryID)
+}
+
+// ListWebhookDeliveries mocks base method.
+func (m *MockRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID, limit, offset int) ([]entity.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookDeliveries", ctx, subscriptionID, limit, offset)
+	ret0, _ := ret[0].([]entity.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookDeliveries indicates an expected call of ListWebhookDeliveries.
+func (mr *MockRepositoryMockRecorder) ListWebhookDeliveries(ctx, subscriptionID, limit, offset any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookDeliveries", reflect.TypeOf((*MockRepository)(nil).ListWebhookDeliveries), ctx, subscriptionID, limit, offset)
+}
+
+// ListWebhookSubscriptions mocks base method.
+func (m *MockRepository) ListWebhookSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListWebhookSubscriptions", ctx)
+	ret0, _ := ret[0].([]entity.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListWebhookSubscriptions indicates an expected call of ListWebhookSubscriptions.
+func (mr *MockRepositoryMockRecorder) ListWebhookSubscriptions(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListWebhookSubscriptions", reflect.TypeOf((*MockRepository)(nil).ListWebhookSubscriptions), ctx)
+}
+
+// MarkOutboxEventPublished mocks base method.
+func (m *MockRepository) MarkOutboxEventPublished(ctx context.Context, eventID int, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventPublished", ctx, eventID, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventPublished indicates an expected call of MarkOutboxEventPublished.
+func (mr *MockRepositoryMockRecorder) MarkOutboxEventPublished(ctx, eventID, at any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventPublished", reflect.TypeOf((*MockRepository)(nil).MarkOutboxEventPublished), ctx, eventID, at)
 }
 
 // Now mocks base method.
@@ -127,6 +637,79 @@ func (mr *MockRepositoryMockRecorder) RandomString(length any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RandomString", reflect.TypeOf((*MockRepository)(nil).RandomString), length)
 }
 
+// ReencryptUsers mocks base method.
+func (m *MockRepository) ReencryptUsers(ctx context.Context, afterID, limit int, decrypt bool) (int, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReencryptUsers", ctx, afterID, limit, decrypt)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ReencryptUsers indicates an expected call of ReencryptUsers.
+func (mr *MockRepositoryMockRecorder) ReencryptUsers(ctx, afterID, limit, decrypt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReencryptUsers", reflect.TypeOf((*MockRepository)(nil).ReencryptUsers), ctx, afterID, limit, decrypt)
+}
+
+// SearchUsers mocks base method.
+func (m *MockRepository) SearchUsers(ctx context.Context, filter entity.UserSearch) ([]entity.UserMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", ctx, filter)
+	ret0, _ := ret[0].([]entity.UserMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockRepositoryMockRecorder) SearchUsers(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockRepository)(nil).SearchUsers), ctx, filter)
+}
+
+// UpdateJob mocks base method.
+func (m *MockRepository) UpdateJob(ctx context.Context, job *entity.Job) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateJob indicates an expected call of UpdateJob.
+func (mr *MockRepositoryMockRecorder) UpdateJob(ctx, job any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateJob", reflect.TypeOf((*MockRepository)(nil).UpdateJob), ctx, job)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockRepository) UpdatePassword(ctx context.Context, user *entity.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockRepositoryMockRecorder) UpdatePassword(ctx, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockRepository)(nil).UpdatePassword), ctx, user)
+}
+
+// UpdatePhoneNumberChange mocks base method.
+func (m *MockRepository) UpdatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePhoneNumberChange", ctx, change)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePhoneNumberChange indicates an expected call of UpdatePhoneNumberChange.
+func (mr *MockRepositoryMockRecorder) UpdatePhoneNumberChange(ctx, change any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePhoneNumberChange", reflect.TypeOf((*MockRepository)(nil).UpdatePhoneNumberChange), ctx, change)
+}
+
 // UpdateProfile mocks base method.
 func (m *MockRepository) UpdateProfile(ctx context.Context, user *entity.User) error {
 	m.ctrl.T.Helper()
@@ -140,3 +723,31 @@ func (mr *MockRepositoryMockRecorder) UpdateProfile(ctx, user any) *gomock.Call
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProfile", reflect.TypeOf((*MockRepository)(nil).UpdateProfile), ctx, user)
 }
+
+// UpdateWebhookDelivery mocks base method.
+func (m *MockRepository) UpdateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookDelivery", ctx, delivery)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWebhookDelivery indicates an expected call of UpdateWebhookDelivery.
+func (mr *MockRepositoryMockRecorder) UpdateWebhookDelivery(ctx, delivery any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookDelivery", reflect.TypeOf((*MockRepository)(nil).UpdateWebhookDelivery), ctx, delivery)
+}
+
+// WithTransaction mocks base method.
+func (m *MockRepository) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithTransaction", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithTransaction indicates an expected call of WithTransaction.
+func (mr *MockRepositoryMockRecorder) WithTransaction(ctx, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithTransaction", reflect.TypeOf((*MockRepository)(nil).WithTransaction), ctx, fn)
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// claimJobsQuery locks the claimed jobs with SKIP LOCKED, so that workers
+// polling at the same time each take different jobs instead of waiting for
+// one another. SQLite has no row locks, but serializes writes, so it runs
+// the query without the locking clause.
+const claimJobsQuery = `UPDATE "job" SET "status" = @running, "attempts" = "attempts" + 1, "locked_at" = @now
+WHERE "id" IN (
+	SELECT "id" FROM "job"
+	WHERE "type" IN @types
+		AND (("status" = @pending AND "run_at" <= @now) OR ("status" = @running AND "locked_at" <= @locked_before))
+	ORDER BY "run_at", "id"
+	LIMIT @limit
+	%s
+)
+RETURNING *`
+
+// CreateJob leaves job.ID at 0 when its unique key is taken, rather than
+// failing, so that it can be called inside a transaction on Postgres.
+func (r *repositoryCtx) CreateJob(ctx context.Context, job *entity.Job) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &job.OrganizationID)
+
+	db := r.write(ctx)
+
+	err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(job).Error
+	if err != nil {
+		log.Printf(`Create job error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) GetJob(ctx context.Context, jobID int) (*entity.Job, error) {
+	var (
+		job entity.Job
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Scopes(inTenant(ctx)).Where(`id = ?`, jobID).First(&job).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+
+		log.Printf(`Get job error %s`, err.Error())
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// ClaimJobs takes jobs of every tenant.
+func (r *repositoryCtx) ClaimJobs(ctx context.Context, claim entity.JobClaim) ([]entity.Job, error) {
+	var (
+		jobs []entity.Job
+		err  error
+	)
+
+	if len(claim.Types) == 0 || claim.Limit <= 0 {
+		return nil, nil
+	}
+
+	lock := ``
+	if r.cfg.DBDriver == config.DriverPostgres {
+		lock = `FOR UPDATE SKIP LOCKED`
+	}
+
+	db := r.write(ctx)
+
+	err = db.Raw(fmt.Sprintf(claimJobsQuery, lock), map[string]interface{}{
+		`running`:       entity.JobRunning,
+		`pending`:       entity.JobPending,
+		`types`:         claim.Types,
+		`now`:           claim.Now,
+		`locked_before`: claim.LockedBefore,
+		`limit`:         claim.Limit,
+	}).Scan(&jobs).Error
+	if err != nil {
+		log.Printf(`Claim jobs error %s`, err.Error())
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the subquery.
+	sort.Slice(jobs, func(i, j int) bool {
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	return jobs, nil
+}
+
+func (r *repositoryCtx) UpdateJob(ctx context.Context, job *entity.Job) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	data := map[string]interface{}{
+		`status`:      job.Status,
+		`attempts`:    job.Attempts,
+		`run_at`:      job.RunAt,
+		`locked_at`:   copyTime(job.LockedAt),
+		`last_error`:  job.LastError,
+		`finished_at`: copyTime(job.FinishedAt),
+	}
+	err = db.Model(&entity.Job{}).Scopes(inTenant(ctx)).Where(`id = ?`, job.ID).Updates(data).Error
+	if err != nil {
+		log.Printf(`Update job error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) DeleteSucceededJobs(ctx context.Context, before time.Time) (int, error) {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	res := db.Scopes(inTenant(ctx)).Where(`status = ? AND finished_at < ?`, entity.JobSucceeded, before).Delete(&entity.Job{})
+	if err = res.Error; err != nil {
+		log.Printf(`Delete succeeded jobs error %s`, err.Error())
+		return 0, err
+	}
+
+	return int(res.RowsAffected), nil
+}
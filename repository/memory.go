@@ -0,0 +1,1090 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/search"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+// memoryRepository keeps all data in process. Writes are serialized and a
+// failed transaction restores the state from before it started, but reads
+// are not isolated from a transaction that is still running.
+type memoryRepository struct {
+	// txMu serializes writers: a transaction holds it until it finishes
+	// and a standalone write holds it for the duration of the call.
+	txMu sync.Mutex
+	// mu guards the fields below.
+	mu   sync.RWMutex
+	data memoryData
+}
+
+type memoryData struct {
+	organizations             []entity.Organization
+	members                   map[memoryMemberKey]entity.OrganizationMember
+	users                     map[int]entity.User
+	userIDByPhoneNumber       map[memoryPhoneNumberKey]int
+	impersonationAudits       []entity.ImpersonationAudit
+	loginEvents               []entity.LoginEvent
+	auditLogs                 []entity.AuditLog
+	outboxEvents              []entity.OutboxEvent
+	webhookSubscriptions      []entity.WebhookSubscription
+	webhookDeliveries         []entity.WebhookDelivery
+	webhookAttempts           []entity.WebhookAttempt
+	phoneNumberChanges        []entity.PhoneNumberChange
+	jobs                      []entity.Job
+	lastOrganizationID        int
+	lastUserID                int
+	lastAuditID               int
+	lastLoginEventID          int
+	lastAuditLogID            int
+	lastOutboxEventID         int
+	lastWebhookSubscriptionID int
+	lastWebhookDeliveryID     int
+	lastWebhookAttemptID      int
+	lastPhoneNumberChangeID   int
+	lastJobID                 int
+}
+
+type memoryMemberKey struct {
+	organizationID int
+	userID         int
+}
+
+// memoryPhoneNumberKey is unique like the phone number constraint of the
+// user table.
+type memoryPhoneNumberKey struct {
+	scope       int
+	phoneNumber string
+}
+
+type memoryTxKey struct{}
+
+// NewMemoryRepository returns a Repository backed by process memory, for
+// local development and tests that should not need Postgres. Like the
+// migrations, it starts with the default organization.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		data: memoryData{
+			organizations: []entity.Organization{
+				{ID: tenant.DefaultID, Name: `Default`, CreatedAt: time.Now()},
+			},
+			members:             map[memoryMemberKey]entity.OrganizationMember{},
+			users:               map[int]entity.User{},
+			userIDByPhoneNumber: map[memoryPhoneNumberKey]int{},
+			lastOrganizationID:  tenant.DefaultID,
+		},
+	}
+}
+
+func (r *memoryRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.inTransaction(ctx) {
+		return fn(ctx)
+	}
+
+	r.txMu.Lock()
+	defer r.txMu.Unlock()
+
+	r.mu.RLock()
+	snapshot := r.data.clone()
+	r.mu.RUnlock()
+
+	err := fn(context.WithValue(ctx, memoryTxKey{}, r))
+	if err != nil {
+		r.mu.Lock()
+		r.data = snapshot
+		r.mu.Unlock()
+	}
+
+	return err
+}
+
+func (r *memoryRepository) Now() time.Time {
+	return time.Now()
+}
+
+func (r *memoryRepository) RandomString(length int) string {
+	return randomString(length)
+}
+
+func (r *memoryRepository) CreateOrganization(ctx context.Context, organization *entity.Organization) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if organization.CreatedAt.IsZero() {
+		organization.CreatedAt = time.Now()
+	}
+
+	r.data.lastOrganizationID++
+	organization.ID = r.data.lastOrganizationID
+	r.data.organizations = append(r.data.organizations, *organization)
+
+	return nil
+}
+
+func (r *memoryRepository) GetOrganization(ctx context.Context, organizationID int) (*entity.Organization, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, organization := range r.data.organizations {
+		if organization.ID == organizationID {
+			return &organization, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	key := memoryMemberKey{organizationID: member.OrganizationID, userID: member.UserID}
+	if _, ok := r.data.members[key]; ok {
+		return &ConflictError{Constraint: `organization_member_pkey`, Err: errMemoryUniqueViolation}
+	}
+
+	if member.CreatedAt.IsZero() {
+		member.CreatedAt = time.Now()
+	}
+	r.data.members[key] = *member
+
+	return nil
+}
+
+func (r *memoryRepository) ListMemberships(ctx context.Context, userID int) ([]entity.OrganizationMember, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var members []entity.OrganizationMember
+	for key, member := range r.data.members {
+		if key.userID == userID {
+			members = append(members, member)
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].OrganizationID < members[j].OrganizationID
+	})
+
+	return members, nil
+}
+
+func (r *memoryRepository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.data.tenantUser(ctx, userID)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &user, nil
+}
+
+// GetUserByPhoneNumber returns the member with the lowest ID, as the
+// phone number may belong to several users of a tenant whose members joined
+// from other organizations.
+func (r *memoryRepository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var found *entity.User
+	for userID, stored := range r.data.users {
+		if stored.PhoneNumber != phoneNumber || (found != nil && found.ID < userID) {
+			continue
+		}
+		if user, ok := r.data.tenantUser(ctx, userID); ok {
+			found = &user
+		}
+	}
+	if found == nil {
+		return nil, ErrNotFound
+	}
+
+	return found, nil
+}
+
+// Create mirrors the column defaults applied by the database.
+func (r *memoryRepository) Create(ctx context.Context, user *entity.User) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if _, ok := r.data.userIDByPhoneNumber[phoneNumberOf(user)]; ok {
+		return &ConflictError{Constraint: `user_phone_number_key`, Err: errMemoryUniqueViolation}
+	}
+
+	r.data.createUser(ctx, user)
+
+	return nil
+}
+
+func (r *memoryRepository) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []entity.User
+	for userID := range r.data.users {
+		user, ok := r.data.tenantUser(ctx, userID)
+		if !ok {
+			continue
+		}
+
+		updated := user.UpdatedAt != nil
+		switch {
+		case user.ID <= filter.AfterID,
+			!filter.CreatedFrom.IsZero() && user.CreatedAt.Before(filter.CreatedFrom),
+			!filter.CreatedTo.IsZero() && !user.CreatedAt.Before(filter.CreatedTo),
+			!filter.UpdatedFrom.IsZero() && (!updated || user.UpdatedAt.Before(filter.UpdatedFrom)),
+			!filter.UpdatedTo.IsZero() && (!updated || !user.UpdatedAt.Before(filter.UpdatedTo)):
+			continue
+		}
+		matched = append(matched, user)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID < matched[j].ID
+	})
+
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, nil
+}
+
+func (r *memoryRepository) SearchUsers(ctx context.Context, filter entity.UserSearch) ([]entity.UserMatch, error) {
+	query := search.NewQuery(filter.Query)
+	if query.Empty() {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []entity.UserMatch
+	for userID := range r.data.users {
+		user, ok := r.data.tenantUser(ctx, userID)
+		if !ok {
+			continue
+		}
+		if match, ok := matchUser(query, user); ok {
+			matches = append(matches, match)
+		}
+	}
+
+	return pageMatches(rankMatches(matches, 0), filter), nil
+}
+
+func (r *memoryRepository) CreateUsers(ctx context.Context, users []*entity.User) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	seen := make(map[memoryPhoneNumberKey]bool, len(users))
+	for _, user := range users {
+		key := phoneNumberOf(user)
+		if _, ok := r.data.userIDByPhoneNumber[key]; ok || seen[key] {
+			return &ConflictError{Constraint: `user_phone_number_key`, Err: errMemoryUniqueViolation}
+		}
+		seen[key] = true
+	}
+
+	for _, user := range users {
+		r.data.createUser(ctx, user)
+	}
+
+	return nil
+}
+
+// ReencryptUsers has nothing to do, since the memory repository never
+// encrypts.
+func (r *memoryRepository) ReencryptUsers(ctx context.Context, afterID int, limit int, decrypt bool) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (r *memoryRepository) ListTakenPhoneNumbers(ctx context.Context, phoneNumbers []string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	wanted := make(map[string]bool, len(phoneNumbers))
+	for _, phoneNumber := range phoneNumbers {
+		wanted[phoneNumber] = true
+	}
+
+	var taken []string
+	for userID, user := range r.data.users {
+		if !wanted[user.PhoneNumber] {
+			continue
+		}
+		if _, ok := r.data.tenantUser(ctx, userID); ok {
+			taken = append(taken, user.PhoneNumber)
+		}
+	}
+
+	return taken, nil
+}
+
+func (r *memoryRepository) IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if user, ok := r.data.tenantUser(ctx, userID); ok {
+		user.SuccessfulLogin++
+		user.LastLoginAt = &at
+		user.FailedLoginCount = 0
+		r.data.users[userID] = user
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if user, ok := r.data.tenantUser(ctx, userID); ok {
+		user.FailedLoginCount++
+		user.LastFailedLoginAt = &at
+		r.data.users[userID] = user
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) UpdateProfile(ctx context.Context, user *entity.User) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	stored, ok := r.data.tenantUser(ctx, user.ID)
+	if !ok || stored.Version != user.Version {
+		return ErrVersionConflict
+	}
+
+	// Like the SQL update, an empty phone number or full name leaves the
+	// field unchanged.
+	if user.PhoneNumber != `` && user.PhoneNumber != stored.PhoneNumber {
+		key := memoryPhoneNumberKey{scope: stored.PhoneNumberScope, phoneNumber: user.PhoneNumber}
+		if _, ok := r.data.userIDByPhoneNumber[key]; ok {
+			return &ConflictError{Constraint: `user_phone_number_key`, Err: errMemoryUniqueViolation}
+		}
+		delete(r.data.userIDByPhoneNumber, phoneNumberOf(&stored))
+		r.data.userIDByPhoneNumber[key] = stored.ID
+		stored.PhoneNumber = user.PhoneNumber
+	}
+	if user.FullName != `` {
+		stored.FullName = user.FullName
+	}
+	stored.DateOfBirth = copyString(user.DateOfBirth)
+	stored.Gender = copyString(user.Gender)
+	stored.Address = copyString(user.Address)
+	stored.PreferredLanguage = copyString(user.PreferredLanguage)
+	stored.AvatarKey = copyString(user.AvatarKey)
+	if user.UpdatedAt != nil {
+		stored.UpdatedAt = user.UpdatedAt
+	}
+	stored.Version++
+
+	r.data.users[stored.ID] = stored
+	user.Version = stored.Version
+
+	return nil
+}
+
+func (r *memoryRepository) UpdatePassword(ctx context.Context, user *entity.User) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	stored, ok := r.data.tenantUser(ctx, user.ID)
+	if !ok {
+		return nil
+	}
+
+	if user.Password != `` {
+		stored.Password = user.Password
+	}
+	if user.AccountSalt != `` {
+		stored.AccountSalt = user.AccountSalt
+	}
+	if user.UpdatedAt != nil {
+		stored.UpdatedAt = user.UpdatedAt
+	}
+
+	r.data.users[stored.ID] = stored
+
+	return nil
+}
+
+func (r *memoryRepository) CreatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &change.OrganizationID)
+	if change.CreatedAt.IsZero() {
+		change.CreatedAt = time.Now()
+	}
+
+	r.data.lastPhoneNumberChangeID++
+	change.ID = r.data.lastPhoneNumberChangeID
+	r.data.phoneNumberChanges = append(r.data.phoneNumberChanges, *change)
+
+	return nil
+}
+
+func (r *memoryRepository) GetPendingPhoneNumberChange(ctx context.Context, userID int, now time.Time) (*entity.PhoneNumberChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.data.phoneNumberChanges) - 1; i >= 0; i-- {
+		change := r.data.phoneNumberChanges[i]
+		if change.UserID != userID {
+			continue
+		}
+		if change.ConfirmedAt != nil || !change.ExpiresAt.After(now) || !inMemoryTenant(ctx, change.OrganizationID) {
+			break
+		}
+		change.ConfirmedAt = copyTime(change.ConfirmedAt)
+		return &change, nil
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) CountPhoneNumberChanges(ctx context.Context, filter entity.PhoneNumberChangeFilter) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for _, change := range r.data.phoneNumberChanges {
+		switch {
+		case change.UserID != filter.UserID || !inMemoryTenant(ctx, change.OrganizationID),
+			!filter.CreatedFrom.IsZero() && change.CreatedAt.Before(filter.CreatedFrom),
+			!filter.ConfirmedFrom.IsZero() && (change.ConfirmedAt == nil || change.ConfirmedAt.Before(filter.ConfirmedFrom)):
+			continue
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+func (r *memoryRepository) IncrementPhoneNumberChangeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	for i := range r.data.phoneNumberChanges {
+		stored := &r.data.phoneNumberChanges[i]
+		if stored.ID != id || !inMemoryTenant(ctx, stored.OrganizationID) {
+			continue
+		}
+		if stored.Attempts >= maxAttempts {
+			return false, nil
+		}
+		stored.Attempts++
+		return true, nil
+	}
+
+	return false, nil
+}
+
+func (r *memoryRepository) UpdatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	for i := range r.data.phoneNumberChanges {
+		stored := &r.data.phoneNumberChanges[i]
+		if stored.ID != change.ID || !inMemoryTenant(ctx, stored.OrganizationID) {
+			continue
+		}
+		stored.Attempts = change.Attempts
+		stored.ConfirmedAt = copyTime(change.ConfirmedAt)
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) CreateImpersonationAudit(ctx context.Context, audit *entity.ImpersonationAudit) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &audit.OrganizationID)
+	if audit.CreatedAt.IsZero() {
+		audit.CreatedAt = time.Now()
+	}
+
+	r.data.lastAuditID++
+	audit.ID = r.data.lastAuditID
+	r.data.impersonationAudits = append(r.data.impersonationAudits, *audit)
+
+	return nil
+}
+
+func (r *memoryRepository) CreateLoginEvent(ctx context.Context, event *entity.LoginEvent) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &event.OrganizationID)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	r.data.lastLoginEventID++
+	event.ID = r.data.lastLoginEventID
+	r.data.loginEvents = append(r.data.loginEvents, *event)
+
+	return nil
+}
+
+func (r *memoryRepository) ListLoginEvents(ctx context.Context, userID int, limit int, offset int) ([]entity.LoginEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []entity.LoginEvent
+	for i := len(r.data.loginEvents) - 1; i >= 0 && len(events) < limit; i-- {
+		event := r.data.loginEvents[i]
+		if event.UserID == nil || *event.UserID != userID || !inMemoryTenant(ctx, event.OrganizationID) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (r *memoryRepository) CreateAuditLog(ctx context.Context, auditLog *entity.AuditLog) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &auditLog.OrganizationID)
+	if auditLog.CreatedAt.IsZero() {
+		auditLog.CreatedAt = time.Now()
+	}
+
+	r.data.lastAuditLogID++
+	auditLog.ID = r.data.lastAuditLogID
+	r.data.auditLogs = append(r.data.auditLogs, *auditLog)
+
+	return nil
+}
+
+func (r *memoryRepository) ListAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.AuditLog, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matched []entity.AuditLog
+	for _, auditLog := range r.data.auditLogs {
+		switch {
+		case !inMemoryTenant(ctx, auditLog.OrganizationID),
+			filter.ActorID != 0 && auditLog.ActorID != filter.ActorID,
+			filter.UserID != 0 && auditLog.UserID != filter.UserID,
+			filter.Action != `` && auditLog.Action != filter.Action,
+			!filter.From.IsZero() && auditLog.CreatedAt.Before(filter.From),
+			!filter.To.IsZero() && !auditLog.CreatedAt.Before(filter.To):
+			continue
+		}
+		matched = append(matched, auditLog)
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID > matched[j].ID
+	})
+
+	if filter.Offset >= len(matched) {
+		return nil, nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched, nil
+}
+
+func (r *memoryRepository) CreateOutboxEvent(ctx context.Context, event *entity.OutboxEvent) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &event.OrganizationID)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	r.data.lastOutboxEventID++
+	event.ID = r.data.lastOutboxEventID
+	r.data.outboxEvents = append(r.data.outboxEvents, *event)
+
+	return nil
+}
+
+func (r *memoryRepository) ListPendingOutboxEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var events []entity.OutboxEvent
+	for _, event := range r.data.outboxEvents {
+		if len(events) == limit {
+			break
+		}
+		if event.PublishedAt == nil && inMemoryTenant(ctx, event.OrganizationID) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (r *memoryRepository) MarkOutboxEventPublished(ctx context.Context, eventID int, at time.Time) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	for i := range r.data.outboxEvents {
+		if r.data.outboxEvents[i].ID == eventID && inMemoryTenant(ctx, r.data.outboxEvents[i].OrganizationID) {
+			r.data.outboxEvents[i].PublishedAt = &at
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) CreateWebhookSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &subscription.OrganizationID)
+	if subscription.CreatedAt.IsZero() {
+		subscription.CreatedAt = time.Now()
+	}
+
+	r.data.lastWebhookSubscriptionID++
+	subscription.ID = r.data.lastWebhookSubscriptionID
+	r.data.webhookSubscriptions = append(r.data.webhookSubscriptions, *subscription)
+
+	return nil
+}
+
+func (r *memoryRepository) GetWebhookSubscription(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, subscription := range r.data.webhookSubscriptions {
+		if subscription.ID == subscriptionID && inMemoryTenant(ctx, subscription.OrganizationID) {
+			return &subscription, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) ListWebhookSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subscriptions []entity.WebhookSubscription
+	for _, subscription := range r.data.webhookSubscriptions {
+		if inMemoryTenant(ctx, subscription.OrganizationID) {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+
+	return subscriptions, nil
+}
+
+func (r *memoryRepository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	var kept []entity.WebhookSubscription
+	for _, subscription := range r.data.webhookSubscriptions {
+		if subscription.ID != subscriptionID || !inMemoryTenant(ctx, subscription.OrganizationID) {
+			kept = append(kept, subscription)
+		}
+	}
+	r.data.webhookSubscriptions = kept
+
+	return nil
+}
+
+func (r *memoryRepository) CreateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	setTenant(ctx, &delivery.OrganizationID)
+	if delivery.CreatedAt.IsZero() {
+		delivery.CreatedAt = time.Now()
+	}
+
+	r.data.lastWebhookDeliveryID++
+	delivery.ID = r.data.lastWebhookDeliveryID
+	r.data.webhookDeliveries = append(r.data.webhookDeliveries, *delivery)
+
+	return nil
+}
+
+func (r *memoryRepository) GetWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.data.webhookDeliveries {
+		if delivery.ID == deliveryID && inMemoryTenant(ctx, delivery.OrganizationID) {
+			return &delivery, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) HasWebhookDeliveries(ctx context.Context, eventID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, delivery := range r.data.webhookDeliveries {
+		if delivery.EventID == eventID && inMemoryTenant(ctx, delivery.OrganizationID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *memoryRepository) ListWebhookDeliveries(ctx context.Context, subscriptionID int, limit int, offset int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []entity.WebhookDelivery
+	for i := len(r.data.webhookDeliveries) - 1; i >= 0 && len(deliveries) < limit; i-- {
+		delivery := r.data.webhookDeliveries[i]
+		if delivery.SubscriptionID != subscriptionID || !inMemoryTenant(ctx, delivery.OrganizationID) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (r *memoryRepository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var deliveries []entity.WebhookDelivery
+	for _, delivery := range r.data.webhookDeliveries {
+		if len(deliveries) == limit {
+			break
+		}
+		if delivery.Status == entity.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) && inMemoryTenant(ctx, delivery.OrganizationID) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	return deliveries, nil
+}
+
+func (r *memoryRepository) UpdateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	for i := range r.data.webhookDeliveries {
+		stored := &r.data.webhookDeliveries[i]
+		if stored.ID != delivery.ID || !inMemoryTenant(ctx, stored.OrganizationID) {
+			continue
+		}
+		stored.Status = delivery.Status
+		stored.Attempts = delivery.Attempts
+		stored.NextAttemptAt = delivery.NextAttemptAt
+		stored.LastStatusCode = delivery.LastStatusCode
+		stored.LastError = delivery.LastError
+		stored.DeliveredAt = copyTime(delivery.DeliveredAt)
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) CreateWebhookAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if attempt.CreatedAt.IsZero() {
+		attempt.CreatedAt = time.Now()
+	}
+
+	r.data.lastWebhookAttemptID++
+	attempt.ID = r.data.lastWebhookAttemptID
+	r.data.webhookAttempts = append(r.data.webhookAttempts, *attempt)
+
+	return nil
+}
+
+func (r *memoryRepository) ListWebhookAttempts(ctx context.Context, deliveryID int) ([]entity.WebhookAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Attempts belong to the tenant of their delivery.
+	visible := false
+	for _, delivery := range r.data.webhookDeliveries {
+		if delivery.ID == deliveryID {
+			visible = inMemoryTenant(ctx, delivery.OrganizationID)
+		}
+	}
+	if !visible {
+		return nil, nil
+	}
+
+	var attempts []entity.WebhookAttempt
+	for _, attempt := range r.data.webhookAttempts {
+		if attempt.DeliveryID == deliveryID {
+			attempts = append(attempts, attempt)
+		}
+	}
+
+	return attempts, nil
+}
+
+func (r *memoryRepository) CreateJob(ctx context.Context, job *entity.Job) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	if job.UniqueKey != nil {
+		for _, stored := range r.data.jobs {
+			if stored.UniqueKey != nil && *stored.UniqueKey == *job.UniqueKey {
+				return nil
+			}
+		}
+	}
+
+	setTenant(ctx, &job.OrganizationID)
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	r.data.lastJobID++
+	job.ID = r.data.lastJobID
+	r.data.jobs = append(r.data.jobs, *job)
+
+	return nil
+}
+
+func (r *memoryRepository) GetJob(ctx context.Context, jobID int) (*entity.Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, job := range r.data.jobs {
+		if job.ID == jobID && inMemoryTenant(ctx, job.OrganizationID) {
+			job.LockedAt = copyTime(job.LockedAt)
+			job.FinishedAt = copyTime(job.FinishedAt)
+			return &job, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (r *memoryRepository) ClaimJobs(ctx context.Context, claim entity.JobClaim) ([]entity.Job, error) {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	types := make(map[string]bool, len(claim.Types))
+	for _, t := range claim.Types {
+		types[t] = true
+	}
+
+	var claimable []*entity.Job
+	for i := range r.data.jobs {
+		job := &r.data.jobs[i]
+		switch {
+		case !types[job.Type],
+			job.Status == entity.JobPending && job.RunAt.After(claim.Now),
+			job.Status == entity.JobRunning && (job.LockedAt == nil || job.LockedAt.After(claim.LockedBefore)),
+			job.Status != entity.JobPending && job.Status != entity.JobRunning:
+			continue
+		}
+		claimable = append(claimable, job)
+	}
+	sort.SliceStable(claimable, func(i, j int) bool {
+		return claimable[i].RunAt.Before(claimable[j].RunAt)
+	})
+
+	var jobs []entity.Job
+	for _, job := range claimable {
+		if len(jobs) == claim.Limit {
+			break
+		}
+		now := claim.Now
+		job.Status = entity.JobRunning
+		job.Attempts++
+		job.LockedAt = &now
+		jobs = append(jobs, *job)
+	}
+
+	return jobs, nil
+}
+
+func (r *memoryRepository) UpdateJob(ctx context.Context, job *entity.Job) error {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	for i := range r.data.jobs {
+		stored := &r.data.jobs[i]
+		if stored.ID != job.ID || !inMemoryTenant(ctx, stored.OrganizationID) {
+			continue
+		}
+		stored.Status = job.Status
+		stored.Attempts = job.Attempts
+		stored.RunAt = job.RunAt
+		stored.LockedAt = copyTime(job.LockedAt)
+		stored.LastError = job.LastError
+		stored.FinishedAt = copyTime(job.FinishedAt)
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) DeleteSucceededJobs(ctx context.Context, before time.Time) (int, error) {
+	unlock := r.lockWrite(ctx)
+	defer unlock()
+
+	jobs := r.data.jobs[:0]
+	for _, job := range r.data.jobs {
+		if job.Status == entity.JobSucceeded && job.FinishedAt != nil && job.FinishedAt.Before(before) && inMemoryTenant(ctx, job.OrganizationID) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	deleted := len(r.data.jobs) - len(jobs)
+	r.data.jobs = jobs
+
+	return deleted, nil
+}
+
+// lockWrite takes the locks needed to modify the data. Inside a transaction
+// the writer lock is already held by WithTransaction.
+func (r *memoryRepository) lockWrite(ctx context.Context) func() {
+	inTx := r.inTransaction(ctx)
+	if !inTx {
+		r.txMu.Lock()
+	}
+	r.mu.Lock()
+
+	return func() {
+		r.mu.Unlock()
+		if !inTx {
+			r.txMu.Unlock()
+		}
+	}
+}
+
+func (r *memoryRepository) inTransaction(ctx context.Context) bool {
+	tx, ok := ctx.Value(memoryTxKey{}).(*memoryRepository)
+	return ok && tx == r
+}
+
+// tenantUser returns the user with their role in the tenant carried by
+// ctx, like tenantMembership, and false when they are not a member of it.
+func (d memoryData) tenantUser(ctx context.Context, userID int) (entity.User, bool) {
+	user, ok := d.users[userID]
+	if !ok {
+		return user, false
+	}
+
+	orgID, ok := tenant.ID(ctx)
+	if !ok {
+		orgID = user.OrganizationID
+	}
+	member, ok := d.members[memoryMemberKey{organizationID: orgID, userID: userID}]
+	if !ok {
+		return user, false
+	}
+
+	user.Role = member.Role
+	return user, true
+}
+
+// createUser stores a user whose phone number is known to be free, along
+// with their membership in the organization creating them.
+func (d *memoryData) createUser(ctx context.Context, user *entity.User) {
+	setTenant(ctx, &user.OrganizationID)
+	if user.Version == 0 {
+		user.Version = 1
+	}
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+
+	d.lastUserID++
+	user.ID = d.lastUserID
+	for _, member := range ownerMemberships([]*entity.User{user}) {
+		d.members[memoryMemberKey{organizationID: member.OrganizationID, userID: member.UserID}] = member
+	}
+	d.users[user.ID] = *user
+	d.userIDByPhoneNumber[phoneNumberOf(user)] = user.ID
+}
+
+func phoneNumberOf(user *entity.User) memoryPhoneNumberKey {
+	return memoryPhoneNumberKey{scope: user.PhoneNumberScope, phoneNumber: user.PhoneNumber}
+}
+
+// inMemoryTenant reports whether a record of the organization orgID is
+// visible to ctx, like inTenant.
+func inMemoryTenant(ctx context.Context, orgID int) bool {
+	tenantID, ok := tenant.ID(ctx)
+	return !ok || tenantID == orgID
+}
+
+func (d memoryData) clone() memoryData {
+	c := memoryData{
+		organizations:             append([]entity.Organization(nil), d.organizations...),
+		members:                   make(map[memoryMemberKey]entity.OrganizationMember, len(d.members)),
+		users:                     make(map[int]entity.User, len(d.users)),
+		userIDByPhoneNumber:       make(map[memoryPhoneNumberKey]int, len(d.userIDByPhoneNumber)),
+		impersonationAudits:       append([]entity.ImpersonationAudit(nil), d.impersonationAudits...),
+		loginEvents:               append([]entity.LoginEvent(nil), d.loginEvents...),
+		auditLogs:                 append([]entity.AuditLog(nil), d.auditLogs...),
+		outboxEvents:              append([]entity.OutboxEvent(nil), d.outboxEvents...),
+		webhookSubscriptions:      append([]entity.WebhookSubscription(nil), d.webhookSubscriptions...),
+		webhookDeliveries:         append([]entity.WebhookDelivery(nil), d.webhookDeliveries...),
+		webhookAttempts:           append([]entity.WebhookAttempt(nil), d.webhookAttempts...),
+		phoneNumberChanges:        append([]entity.PhoneNumberChange(nil), d.phoneNumberChanges...),
+		jobs:                      append([]entity.Job(nil), d.jobs...),
+		lastOrganizationID:        d.lastOrganizationID,
+		lastUserID:                d.lastUserID,
+		lastAuditID:               d.lastAuditID,
+		lastLoginEventID:          d.lastLoginEventID,
+		lastAuditLogID:            d.lastAuditLogID,
+		lastOutboxEventID:         d.lastOutboxEventID,
+		lastWebhookSubscriptionID: d.lastWebhookSubscriptionID,
+		lastWebhookDeliveryID:     d.lastWebhookDeliveryID,
+		lastWebhookAttemptID:      d.lastWebhookAttemptID,
+		lastPhoneNumberChangeID:   d.lastPhoneNumberChangeID,
+	}
+	for key, member := range d.members {
+		c.members[key] = member
+	}
+	for id, user := range d.users {
+		c.users[id] = user
+	}
+	for phoneNumber, id := range d.userIDByPhoneNumber {
+		c.userIDByPhoneNumber[phoneNumber] = id
+	}
+
+	return c
+}
+
+// copyString keeps the stored user from sharing optional fields with the
+// caller's copy.
+func copyString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
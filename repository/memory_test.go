@@ -0,0 +1,9 @@
+package repository
+
+import "testing"
+
+func TestMemoryRepository(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Repository {
+		return NewMemoryRepository()
+	})
+}
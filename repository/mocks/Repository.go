@@ -16,42 +16,754 @@ type Repository struct {
 	mock.Mock
 }
 
+// AddOrganizationMember provides a mock function with given fields: ctx, member
+func (_m *Repository) AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error {
+	ret := _m.Called(ctx, member)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddOrganizationMember")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.OrganizationMember) error); ok {
+		r0 = rf(ctx, member)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ClaimJobs provides a mock function with given fields: ctx, claim
+func (_m *Repository) ClaimJobs(ctx context.Context, claim entity.JobClaim) ([]entity.Job, error) {
+	ret := _m.Called(ctx, claim)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClaimJobs")
+	}
+
+	var r0 []entity.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.JobClaim) ([]entity.Job, error)); ok {
+		return rf(ctx, claim)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.JobClaim) []entity.Job); ok {
+		r0 = rf(ctx, claim)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.JobClaim) error); ok {
+		r1 = rf(ctx, claim)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountPhoneNumberChanges provides a mock function with given fields: ctx, filter
+func (_m *Repository) CountPhoneNumberChanges(ctx context.Context, filter entity.PhoneNumberChangeFilter) (int, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountPhoneNumberChanges")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.PhoneNumberChangeFilter) (int, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.PhoneNumberChangeFilter) int); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.PhoneNumberChangeFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, user
 func (_m *Repository) Create(ctx context.Context, user *entity.User) error {
 	ret := _m.Called(ctx, user)
 
 	if len(ret) == 0 {
-		panic("no return value specified for Create")
+		panic("no return value specified for Create")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateAuditLog provides a mock function with given fields: ctx, log
+func (_m *Repository) CreateAuditLog(ctx context.Context, log *entity.AuditLog) error {
+	ret := _m.Called(ctx, log)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateAuditLog")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.AuditLog) error); ok {
+		r0 = rf(ctx, log)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateImpersonationAudit provides a mock function with given fields: ctx, audit
+func (_m *Repository) CreateImpersonationAudit(ctx context.Context, audit *entity.ImpersonationAudit) error {
+	ret := _m.Called(ctx, audit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateImpersonationAudit")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.ImpersonationAudit) error); ok {
+		r0 = rf(ctx, audit)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateJob provides a mock function with given fields: ctx, job
+func (_m *Repository) CreateJob(ctx context.Context, job *entity.Job) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateLoginEvent provides a mock function with given fields: ctx, event
+func (_m *Repository) CreateLoginEvent(ctx context.Context, event *entity.LoginEvent) error {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateLoginEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.LoginEvent) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOrganization provides a mock function with given fields: ctx, organization
+func (_m *Repository) CreateOrganization(ctx context.Context, organization *entity.Organization) error {
+	ret := _m.Called(ctx, organization)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrganization")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Organization) error); ok {
+		r0 = rf(ctx, organization)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateOutboxEvent provides a mock function with given fields: ctx, event
+func (_m *Repository) CreateOutboxEvent(ctx context.Context, event *entity.OutboxEvent) error {
+	ret := _m.Called(ctx, event)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOutboxEvent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.OutboxEvent) error); ok {
+		r0 = rf(ctx, event)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreatePhoneNumberChange provides a mock function with given fields: ctx, change
+func (_m *Repository) CreatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	ret := _m.Called(ctx, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreatePhoneNumberChange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.PhoneNumberChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateUsers provides a mock function with given fields: ctx, users
+func (_m *Repository) CreateUsers(ctx context.Context, users []*entity.User) error {
+	ret := _m.Called(ctx, users)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateUsers")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []*entity.User) error); ok {
+		r0 = rf(ctx, users)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookAttempt provides a mock function with given fields: ctx, attempt
+func (_m *Repository) CreateWebhookAttempt(ctx context.Context, attempt *entity.WebhookAttempt) error {
+	ret := _m.Called(ctx, attempt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookAttempt")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookAttempt) error); ok {
+		r0 = rf(ctx, attempt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookDelivery provides a mock function with given fields: ctx, delivery
+func (_m *Repository) CreateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	ret := _m.Called(ctx, delivery)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookDelivery) error); ok {
+		r0 = rf(ctx, delivery)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateWebhookSubscription provides a mock function with given fields: ctx, subscription
+func (_m *Repository) CreateWebhookSubscription(ctx context.Context, subscription *entity.WebhookSubscription) error {
+	ret := _m.Called(ctx, subscription)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookSubscription) error); ok {
+		r0 = rf(ctx, subscription)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSucceededJobs provides a mock function with given fields: ctx, before
+func (_m *Repository) DeleteSucceededJobs(ctx context.Context, before time.Time) (int, error) {
+	ret := _m.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteSucceededJobs")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int, error)); ok {
+		return rf(ctx, before)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, before)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteWebhookSubscription provides a mock function with given fields: ctx, subscriptionID
+func (_m *Repository) DeleteWebhookSubscription(ctx context.Context, subscriptionID int) error {
+	ret := _m.Called(ctx, subscriptionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteWebhookSubscription")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, subscriptionID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetJob provides a mock function with given fields: ctx, jobID
+func (_m *Repository) GetJob(ctx context.Context, jobID int) (*entity.Job, error) {
+	ret := _m.Called(ctx, jobID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetJob")
+	}
+
+	var r0 *entity.Job
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Job, error)); ok {
+		return rf(ctx, jobID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Job); ok {
+		r0 = rf(ctx, jobID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Job)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, jobID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrganization provides a mock function with given fields: ctx, organizationID
+func (_m *Repository) GetOrganization(ctx context.Context, organizationID int) (*entity.Organization, error) {
+	ret := _m.Called(ctx, organizationID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrganization")
+	}
+
+	var r0 *entity.Organization
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.Organization, error)); ok {
+		return rf(ctx, organizationID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.Organization); ok {
+		r0 = rf(ctx, organizationID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.Organization)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, organizationID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPendingPhoneNumberChange provides a mock function with given fields: ctx, userID, now
+func (_m *Repository) GetPendingPhoneNumberChange(ctx context.Context, userID int, now time.Time) (*entity.PhoneNumberChange, error) {
+	ret := _m.Called(ctx, userID, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetPendingPhoneNumberChange")
+	}
+
+	var r0 *entity.PhoneNumberChange
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) (*entity.PhoneNumberChange, error)); ok {
+		return rf(ctx, userID, now)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) *entity.PhoneNumberChange); ok {
+		r0 = rf(ctx, userID, now)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.PhoneNumberChange)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, time.Time) error); ok {
+		r1 = rf(ctx, userID, now)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *Repository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.User, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.User); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByPhoneNumber provides a mock function with given fields: ctx, phoneNumber
+func (_m *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
+	ret := _m.Called(ctx, phoneNumber)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByPhoneNumber")
+	}
+
+	var r0 *entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.User, error)); ok {
+		return rf(ctx, phoneNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.User); ok {
+		r0 = rf(ctx, phoneNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, phoneNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookDelivery provides a mock function with given fields: ctx, deliveryID
+func (_m *Repository) GetWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, deliveryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookDelivery")
+	}
+
+	var r0 *entity.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.WebhookDelivery, error)); ok {
+		return rf(ctx, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.WebhookDelivery); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetWebhookSubscription provides a mock function with given fields: ctx, subscriptionID
+func (_m *Repository) GetWebhookSubscription(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error) {
+	ret := _m.Called(ctx, subscriptionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetWebhookSubscription")
+	}
+
+	var r0 *entity.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.WebhookSubscription, error)); ok {
+		return rf(ctx, subscriptionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.WebhookSubscription); ok {
+		r0 = rf(ctx, subscriptionID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*entity.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, subscriptionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasWebhookDeliveries provides a mock function with given fields: ctx, eventID
+func (_m *Repository) HasWebhookDeliveries(ctx context.Context, eventID int) (bool, error) {
+	ret := _m.Called(ctx, eventID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HasWebhookDeliveries")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) (bool, error)); ok {
+		return rf(ctx, eventID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) bool); ok {
+		r0 = rf(ctx, eventID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, eventID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementFailedLogin provides a mock function with given fields: ctx, userID, at
+func (_m *Repository) IncrementFailedLogin(ctx context.Context, userID int, at time.Time) error {
+	ret := _m.Called(ctx, userID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementFailedLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// IncrementPhoneNumberChangeAttempts provides a mock function with given fields: ctx, id, maxAttempts
+func (_m *Repository) IncrementPhoneNumberChangeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	ret := _m.Called(ctx, id, maxAttempts)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementPhoneNumberChangeAttempts")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) (bool, error)); ok {
+		return rf(ctx, id, maxAttempts)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int) bool); ok {
+		r0 = rf(ctx, id, maxAttempts)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int) error); ok {
+		r1 = rf(ctx, id, maxAttempts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IncrementSuccessfulLogin provides a mock function with given fields: ctx, userID, at
+func (_m *Repository) IncrementSuccessfulLogin(ctx context.Context, userID int, at time.Time) error {
+	ret := _m.Called(ctx, userID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IncrementSuccessfulLogin")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, userID, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListAuditLogs provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListAuditLogs(ctx context.Context, filter entity.AuditLogFilter) ([]entity.AuditLog, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListAuditLogs")
+	}
+
+	var r0 []entity.AuditLog
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.AuditLogFilter) ([]entity.AuditLog, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.AuditLogFilter) []entity.AuditLog); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.AuditLog)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.AuditLogFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListDueWebhookDeliveries provides a mock function with given fields: ctx, now, limit
+func (_m *Repository) ListDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, now, limit)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDueWebhookDeliveries")
 	}
 
-	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, *entity.User) error); ok {
-		r0 = rf(ctx, user)
+	var r0 []entity.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) ([]entity.WebhookDelivery, error)); ok {
+		return rf(ctx, now, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) []entity.WebhookDelivery); ok {
+		r0 = rf(ctx, now, limit)
 	} else {
-		r0 = ret.Error(0)
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookDelivery)
+		}
 	}
 
-	return r0
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, now, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
-// GetUserByID provides a mock function with given fields: ctx, userID
-func (_m *Repository) GetUserByID(ctx context.Context, userID int) (*entity.User, error) {
+// ListLoginEvents provides a mock function with given fields: ctx, userID, limit, offset
+func (_m *Repository) ListLoginEvents(ctx context.Context, userID int, limit int, offset int) ([]entity.LoginEvent, error) {
+	ret := _m.Called(ctx, userID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListLoginEvents")
+	}
+
+	var r0 []entity.LoginEvent
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.LoginEvent, error)); ok {
+		return rf(ctx, userID, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.LoginEvent); ok {
+		r0 = rf(ctx, userID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.LoginEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, userID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListMemberships provides a mock function with given fields: ctx, userID
+func (_m *Repository) ListMemberships(ctx context.Context, userID int) ([]entity.OrganizationMember, error) {
 	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByID")
+		panic("no return value specified for ListMemberships")
 	}
 
-	var r0 *entity.User
+	var r0 []entity.OrganizationMember
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) (*entity.User, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.OrganizationMember, error)); ok {
 		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int) *entity.User); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.OrganizationMember); ok {
 		r0 = rf(ctx, userID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*entity.User)
+			r0 = ret.Get(0).([]entity.OrganizationMember)
 		}
 	}
 
@@ -64,29 +776,29 @@ func (_m *Repository) GetUserByID(ctx context.Context, userID int) (*entity.User
 	return r0, r1
 }
 
-// GetUserByPhoneNumber provides a mock function with given fields: ctx, phoneNumber
-func (_m *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber string) (*entity.User, error) {
-	ret := _m.Called(ctx, phoneNumber)
+// ListPendingOutboxEvents provides a mock function with given fields: ctx, limit
+func (_m *Repository) ListPendingOutboxEvents(ctx context.Context, limit int) ([]entity.OutboxEvent, error) {
+	ret := _m.Called(ctx, limit)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetUserByPhoneNumber")
+		panic("no return value specified for ListPendingOutboxEvents")
 	}
 
-	var r0 *entity.User
+	var r0 []entity.OutboxEvent
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) (*entity.User, error)); ok {
-		return rf(ctx, phoneNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.OutboxEvent, error)); ok {
+		return rf(ctx, limit)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, string) *entity.User); ok {
-		r0 = rf(ctx, phoneNumber)
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.OutboxEvent); ok {
+		r0 = rf(ctx, limit)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*entity.User)
+			r0 = ret.Get(0).([]entity.OutboxEvent)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, phoneNumber)
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -94,17 +806,167 @@ func (_m *Repository) GetUserByPhoneNumber(ctx context.Context, phoneNumber stri
 	return r0, r1
 }
 
-// IncrementSuccessfulLogin provides a mock function with given fields: ctx, userID
-func (_m *Repository) IncrementSuccessfulLogin(ctx context.Context, userID int) error {
-	ret := _m.Called(ctx, userID)
+// ListTakenPhoneNumbers provides a mock function with given fields: ctx, phoneNumbers
+func (_m *Repository) ListTakenPhoneNumbers(ctx context.Context, phoneNumbers []string) ([]string, error) {
+	ret := _m.Called(ctx, phoneNumbers)
 
 	if len(ret) == 0 {
-		panic("no return value specified for IncrementSuccessfulLogin")
+		panic("no return value specified for ListTakenPhoneNumbers")
+	}
+
+	var r0 []string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []string) ([]string, error)); ok {
+		return rf(ctx, phoneNumbers)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []string); ok {
+		r0 = rf(ctx, phoneNumbers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, phoneNumbers)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx, filter
+func (_m *Repository) ListUsers(ctx context.Context, filter entity.UserFilter) ([]entity.User, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []entity.User
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.UserFilter) ([]entity.User, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.UserFilter) []entity.User); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.User)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.UserFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookAttempts provides a mock function with given fields: ctx, deliveryID
+func (_m *Repository) ListWebhookAttempts(ctx context.Context, deliveryID int) ([]entity.WebhookAttempt, error) {
+	ret := _m.Called(ctx, deliveryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookAttempts")
+	}
+
+	var r0 []entity.WebhookAttempt
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]entity.WebhookAttempt, error)); ok {
+		return rf(ctx, deliveryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []entity.WebhookAttempt); ok {
+		r0 = rf(ctx, deliveryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookAttempt)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, deliveryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookDeliveries provides a mock function with given fields: ctx, subscriptionID, limit, offset
+func (_m *Repository) ListWebhookDeliveries(ctx context.Context, subscriptionID int, limit int, offset int) ([]entity.WebhookDelivery, error) {
+	ret := _m.Called(ctx, subscriptionID, limit, offset)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookDeliveries")
+	}
+
+	var r0 []entity.WebhookDelivery
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) ([]entity.WebhookDelivery, error)); ok {
+		return rf(ctx, subscriptionID, limit, offset)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, int) []entity.WebhookDelivery); ok {
+		r0 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookDelivery)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, int) error); ok {
+		r1 = rf(ctx, subscriptionID, limit, offset)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListWebhookSubscriptions provides a mock function with given fields: ctx
+func (_m *Repository) ListWebhookSubscriptions(ctx context.Context) ([]entity.WebhookSubscription, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListWebhookSubscriptions")
+	}
+
+	var r0 []entity.WebhookSubscription
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]entity.WebhookSubscription, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []entity.WebhookSubscription); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.WebhookSubscription)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkOutboxEventPublished provides a mock function with given fields: ctx, eventID, at
+func (_m *Repository) MarkOutboxEventPublished(ctx context.Context, eventID int, at time.Time) error {
+	ret := _m.Called(ctx, eventID, at)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkOutboxEventPublished")
 	}
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
-		r0 = rf(ctx, userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int, time.Time) error); ok {
+		r0 = rf(ctx, eventID, at)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -148,6 +1010,125 @@ func (_m *Repository) RandomString(length int) string {
 	return r0
 }
 
+// ReencryptUsers provides a mock function with given fields: ctx, afterID, limit, decrypt
+func (_m *Repository) ReencryptUsers(ctx context.Context, afterID int, limit int, decrypt bool) (int, int, error) {
+	ret := _m.Called(ctx, afterID, limit, decrypt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReencryptUsers")
+	}
+
+	var r0 int
+	var r1 int
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, bool) (int, int, error)); ok {
+		return rf(ctx, afterID, limit, decrypt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int, int, bool) int); ok {
+		r0 = rf(ctx, afterID, limit, decrypt)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int, int, bool) int); ok {
+		r1 = rf(ctx, afterID, limit, decrypt)
+	} else {
+		r1 = ret.Get(1).(int)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int, int, bool) error); ok {
+		r2 = rf(ctx, afterID, limit, decrypt)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SearchUsers provides a mock function with given fields: ctx, filter
+func (_m *Repository) SearchUsers(ctx context.Context, filter entity.UserSearch) ([]entity.UserMatch, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SearchUsers")
+	}
+
+	var r0 []entity.UserMatch
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, entity.UserSearch) ([]entity.UserMatch, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, entity.UserSearch) []entity.UserMatch); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]entity.UserMatch)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, entity.UserSearch) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateJob provides a mock function with given fields: ctx, job
+func (_m *Repository) UpdateJob(ctx context.Context, job *entity.Job) error {
+	ret := _m.Called(ctx, job)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateJob")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.Job) error); ok {
+		r0 = rf(ctx, job)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePassword provides a mock function with given fields: ctx, user
+func (_m *Repository) UpdatePassword(ctx context.Context, user *entity.User) error {
+	ret := _m.Called(ctx, user)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.User) error); ok {
+		r0 = rf(ctx, user)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdatePhoneNumberChange provides a mock function with given fields: ctx, change
+func (_m *Repository) UpdatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	ret := _m.Called(ctx, change)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePhoneNumberChange")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.PhoneNumberChange) error); ok {
+		r0 = rf(ctx, change)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateProfile provides a mock function with given fields: ctx, user
 func (_m *Repository) UpdateProfile(ctx context.Context, user *entity.User) error {
 	ret := _m.Called(ctx, user)
@@ -166,6 +1147,42 @@ func (_m *Repository) UpdateProfile(ctx context.Context, user *entity.User) erro
 	return r0
 }
 
+// UpdateWebhookDelivery provides a mock function with given fields: ctx, delivery
+func (_m *Repository) UpdateWebhookDelivery(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	ret := _m.Called(ctx, delivery)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateWebhookDelivery")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *entity.WebhookDelivery) error); ok {
+		r0 = rf(ctx, delivery)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// WithTransaction provides a mock function with given fields: ctx, fn
+func (_m *Repository) WithTransaction(ctx context.Context, fn func(context.Context) error) error {
+	ret := _m.Called(ctx, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for WithTransaction")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(context.Context) error) error); ok {
+		r0 = rf(ctx, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // NewRepository creates a new instance of Repository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewRepository(t interface {
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/tenant"
+	"gorm.io/gorm"
+)
+
+// userColumns selects a user along with their role in the membership joined
+// by tenantMembership.
+const userColumns = `"user".*, "organization_member"."role"`
+
+// tenantMembership joins users to their membership in the tenant carried by
+// ctx, so that only its members are found. Without a tenant every user is
+// found through their membership in the organization that created them.
+func tenantMembership(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID, ok := tenant.ID(ctx); ok {
+			return db.Joins(`JOIN "organization_member" ON "organization_member"."user_id" = "user"."id" AND "organization_member"."organization_id" = ?`, orgID)
+		}
+
+		return db.Joins(`JOIN "organization_member" ON "organization_member"."user_id" = "user"."id" AND "organization_member"."organization_id" = "user"."organization_id"`)
+	}
+}
+
+// tenantMember limits a write on users to the members of the tenant carried
+// by ctx.
+func tenantMember(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID, ok := tenant.ID(ctx); ok {
+			return db.Where(`"id" IN (SELECT "user_id" FROM "organization_member" WHERE "organization_id" = ?)`, orgID)
+		}
+
+		return db
+	}
+}
+
+// inTenant limits a query to the records of the tenant carried by ctx.
+func inTenant(ctx context.Context) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if orgID, ok := tenant.ID(ctx); ok {
+			return db.Where(`"organization_id" = ?`, orgID)
+		}
+
+		return db
+	}
+}
+
+// setTenant assigns a record about to be created to the tenant carried by
+// ctx, or to the default organization, unless it already has one.
+func setTenant(ctx context.Context, orgID *int) {
+	if *orgID == 0 {
+		*orgID = tenant.IDOrDefault(ctx)
+	}
+}
+
+// ownerMemberships returns the membership of each user in the organization
+// that created them, with the role they were created with.
+func ownerMemberships(users []*entity.User) []entity.OrganizationMember {
+	members := make([]entity.OrganizationMember, 0, len(users))
+	for _, user := range users {
+		if user.Role == `` {
+			user.Role = entity.RoleUser
+		}
+		members = append(members, entity.OrganizationMember{
+			OrganizationID: user.OrganizationID,
+			UserID:         user.ID,
+			Role:           user.Role,
+			CreatedAt:      user.CreatedAt,
+		})
+	}
+
+	return members
+}
+
+func (r *repositoryCtx) CreateOrganization(ctx context.Context, organization *entity.Organization) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Create(organization).Error
+	if err != nil {
+		log.Printf(`Create organization error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) GetOrganization(ctx context.Context, organizationID int) (*entity.Organization, error) {
+	var (
+		organization = &entity.Organization{}
+		err          error
+	)
+
+	db := r.db(ctx)
+
+	err = db.First(organization, `id = ?`, organizationID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+
+		log.Printf(`Get organization error %s`, err.Error())
+		return nil, err
+	}
+
+	return organization, nil
+}
+
+func (r *repositoryCtx) AddOrganizationMember(ctx context.Context, member *entity.OrganizationMember) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Create(member).Error
+	if err != nil {
+		log.Printf(`Add organization member error %s`, err.Error())
+		return translateError(err)
+	}
+
+	return nil
+}
+
+func (r *repositoryCtx) ListMemberships(ctx context.Context, userID int) ([]entity.OrganizationMember, error) {
+	var (
+		members []entity.OrganizationMember
+		err     error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Where(`user_id = ?`, userID).Order(`organization_id`).Find(&members).Error
+	if err != nil {
+		log.Printf(`List memberships error %s`, err.Error())
+		return nil, err
+	}
+
+	return members, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// phoneNumberChangeField names the new phone number of a change to the
+// keyring, so that its ciphertext cannot be swapped with that of a user.
+const phoneNumberChangeField = `phone_number_change.phone_number`
+
+func (r *repositoryCtx) CreatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	var (
+		err error
+	)
+
+	setTenant(ctx, &change.OrganizationID)
+
+	db := r.write(ctx)
+
+	change.PhoneNumberCiphertext, err = r.encryptColumn(phoneNumberChangeField, change.PhoneNumber)
+	if err != nil {
+		return err
+	}
+	if change.PhoneNumberCiphertext != nil {
+		db = db.Omit(`phone_number`)
+	}
+
+	err = db.Create(change).Error
+	if err != nil {
+		log.Printf(`Create phone number change error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// GetPendingPhoneNumberChange reads from the primary, since a change is
+// usually confirmed moments after it was requested.
+func (r *repositoryCtx) GetPendingPhoneNumberChange(ctx context.Context, userID int, now time.Time) (*entity.PhoneNumberChange, error) {
+	var (
+		change entity.PhoneNumberChange
+		err    error
+	)
+
+	db := r.write(ctx)
+
+	// Only the latest request can be confirmed, so that a code sent before
+	// it cannot change the phone number once more.
+	err = db.Scopes(inTenant(ctx)).
+		Where(`id = (SELECT MAX(id) FROM phone_number_change WHERE user_id = ?)`, userID).
+		Where(`confirmed_at IS NULL AND expires_at > ?`, now).
+		First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+
+		log.Printf(`Get phone number change error %s`, err.Error())
+		return nil, err
+	}
+
+	if err = r.decryptColumn(phoneNumberChangeField, change.PhoneNumberCiphertext, &change.PhoneNumber); err != nil {
+		log.Printf(`Decrypt phone number change %d error %s`, change.ID, err.Error())
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+func (r *repositoryCtx) CountPhoneNumberChanges(ctx context.Context, filter entity.PhoneNumberChangeFilter) (int, error) {
+	var (
+		count int64
+		err   error
+	)
+
+	db := r.write(ctx).Model(&entity.PhoneNumberChange{}).Scopes(inTenant(ctx)).Where(`user_id = ?`, filter.UserID)
+
+	if !filter.CreatedFrom.IsZero() {
+		db = db.Where(`created_at >= ?`, filter.CreatedFrom)
+	}
+	if !filter.ConfirmedFrom.IsZero() {
+		db = db.Where(`confirmed_at >= ?`, filter.ConfirmedFrom)
+	}
+
+	err = db.Count(&count).Error
+	if err != nil {
+		log.Printf(`Count phone number changes error %s`, err.Error())
+		return 0, err
+	}
+
+	return int(count), nil
+}
+
+// IncrementPhoneNumberChangeAttempts checks and counts the attempt in a
+// single statement, so that concurrent attempts cannot exceed maxAttempts.
+func (r *repositoryCtx) IncrementPhoneNumberChangeAttempts(ctx context.Context, id int, maxAttempts int) (bool, error) {
+	db := r.write(ctx)
+
+	column := clause.Column{Name: `attempts`}
+	result := db.Model(&entity.PhoneNumberChange{}).Scopes(inTenant(ctx)).
+		Where(`id = ? AND attempts < ?`, id, maxAttempts).
+		Update(column.Name, gorm.Expr(`? + ?`, column, 1))
+	if err := result.Error; err != nil {
+		log.Printf(`Increment phone number change attempts error %s`, err.Error())
+		return false, err
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repositoryCtx) UpdatePhoneNumberChange(ctx context.Context, change *entity.PhoneNumberChange) error {
+	var (
+		err error
+	)
+
+	db := r.write(ctx)
+
+	err = db.Model(&entity.PhoneNumberChange{}).Scopes(inTenant(ctx)).Where(`id = ?`, change.ID).
+		Updates(map[string]interface{}{
+			`attempts`:     change.Attempts,
+			`confirmed_at`: copyTime(change.ConfirmedAt),
+		}).Error
+	if err != nil {
+		log.Printf(`Update phone number change error %s`, err.Error())
+		return err
+	}
+
+	return nil
+}
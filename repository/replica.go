@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const replicaPingTimeout = 2 * time.Second
+
+// replicaSet spreads reads over the healthy replicas in turn.
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+type replica struct {
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+type sessionKey struct{}
+
+// session records whether a request has written to the primary.
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession returns a context that remembers writes made with it. Reads
+// made with the same context after a write are served by the primary, so a
+// request always sees its own changes even while replicas lag behind.
+func WithSession(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+func markWritten(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+func hasWritten(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
+
+// newReplicaSet returns nil when there are no replicas. Replicas start out
+// healthy, so that reads use them before the first check has run.
+func newReplicaSet(dbs []*gorm.DB) *replicaSet {
+	if len(dbs) == 0 {
+		return nil
+	}
+
+	set := &replicaSet{}
+	for _, db := range dbs {
+		rep := &replica{db: db}
+		rep.healthy.Store(true)
+		set.replicas = append(set.replicas, rep)
+	}
+
+	return set
+}
+
+// pick returns the next healthy replica, or nil when none is healthy.
+func (s *replicaSet) pick() *replica {
+	if s == nil {
+		return nil
+	}
+
+	n := uint64(len(s.replicas))
+	start := s.next.Add(1)
+	for i := uint64(0); i < n; i++ {
+		rep := s.replicas[(start+i)%n]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+
+	return nil
+}
+
+// monitor pings every replica at the given interval until ctx is done,
+// taking failing replicas out of rotation and returning recovered ones.
+func (s *replicaSet) monitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.check(ctx)
+		}
+	}
+}
+
+func (s *replicaSet) check(ctx context.Context) {
+	for i, rep := range s.replicas {
+		err := rep.ping(ctx)
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf(`Replica %d is healthy again`, i)
+			} else {
+				log.Printf(`Replica %d is unhealthy %s`, i, err.Error())
+			}
+		}
+	}
+}
+
+func (r *replica) ping(ctx context.Context) error {
+	dbc, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	defer cancel()
+
+	return dbc.PingContext(ctx)
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestReplicaRouting(t *testing.T) {
+	ctx := context.Background()
+
+	// The primary and replica are separate databases that are never
+	// synchronized, so the database answering a read can be told apart by
+	// what it returns.
+	setup := func(t *testing.T) (*repositoryCtx, *entity.User) {
+		primary := newSQLiteDB(t)
+		replica := newSQLiteDB(t)
+
+		repo := NewRepository(&config.Config{
+			DB:       primary,
+			DBDriver: config.DriverSQLite,
+			Replicas: []*gorm.DB{replica},
+		}).(*repositoryCtx)
+
+		now := time.Now()
+		user := &entity.User{
+			FullName:    `User123`,
+			PhoneNumber: `+62123456789`,
+			Password:    `hashed`,
+			AccountSalt: `salt`,
+			CreatedAt:   now,
+			UpdatedAt:   &now,
+		}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+
+		return repo, user
+	}
+
+	t.Run(`ReadsGoToReplica`, func(t *testing.T) {
+		repo, user := setup(t)
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+
+		got, err = repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`ReadsAfterWriteInSessionGoToPrimary`, func(t *testing.T) {
+		repo, user := setup(t)
+
+		reqCtx := WithSession(ctx)
+		got, _ := repo.GetUserByID(reqCtx, user.ID)
+		assert.Nil(t, got)
+
+		assert.NoError(t, repo.IncrementSuccessfulLogin(reqCtx, user.ID, time.Now()))
+
+		got, err := repo.GetUserByID(reqCtx, user.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, got) {
+			assert.Equal(t, 1, got.SuccessfulLogin)
+		}
+
+		got, _ = repo.GetUserByID(WithSession(ctx), user.ID)
+		assert.Nil(t, got)
+	})
+
+	t.Run(`ReadsInTransactionGoToPrimary`, func(t *testing.T) {
+		repo, user := setup(t)
+
+		err := repo.WithTransaction(ctx, func(ctx context.Context) error {
+			got, err := repo.GetUserByPhoneNumber(ctx, user.PhoneNumber)
+			assert.NotNil(t, got)
+			return err
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run(`UnhealthyReplicaFallsBackToPrimary`, func(t *testing.T) {
+		repo, user := setup(t)
+
+		repo.replicas.replicas[0].healthy.Store(false)
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+	})
+
+	t.Run(`FailingReplicaIsTakenOutOfRotation`, func(t *testing.T) {
+		repo, user := setup(t)
+
+		dbc, _ := repo.replicas.replicas[0].db.DB()
+		dbc.Close()
+
+		got, err := repo.GetUserByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.NotNil(t, got)
+		assert.False(t, repo.replicas.replicas[0].healthy.Load())
+	})
+
+	t.Run(`CheckRestoresRecoveredReplica`, func(t *testing.T) {
+		repo, _ := setup(t)
+
+		rep := repo.replicas.replicas[0]
+		rep.healthy.Store(false)
+		repo.replicas.check(ctx)
+		assert.True(t, rep.healthy.Load())
+
+		dbc, _ := rep.db.DB()
+		dbc.Close()
+		repo.replicas.check(ctx)
+		assert.False(t, rep.healthy.Load())
+	})
+}
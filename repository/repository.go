@@ -1,19 +1,111 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"log"
 	"math/rand"
 	"time"
 
 	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"gorm.io/gorm"
 )
 
 type repositoryCtx struct {
-	cfg *config.Config
+	cfg      *config.Config
+	replicas *replicaSet
 }
 
+type txKey struct{}
+
 func NewRepository(cfg *config.Config) Repository {
 	rand.Seed(time.Now().UnixNano())
-	return &repositoryCtx{
-		cfg: cfg,
+
+	repo := newRepository(cfg)
+	switch cfg.CacheBackend {
+	case config.CacheMemory:
+		return NewCachedRepository(repo, NewLRUCache(cfg.CacheSize), cfg.CacheTTL, cfg.Keyring)
+	case config.CacheRedis:
+		return NewCachedRepository(repo, NewRedisCache(cfg.CacheRedisAddr), cfg.CacheTTL, cfg.Keyring)
+	}
+
+	return repo
+}
+
+func newRepository(cfg *config.Config) Repository {
+	if cfg.DBDriver == config.DriverMemory {
+		return NewMemoryRepository()
+	}
+
+	r := &repositoryCtx{
+		cfg:      cfg,
+		replicas: newReplicaSet(cfg.Replicas),
 	}
+	if r.replicas != nil && cfg.ReplicaHealthInterval > 0 {
+		go r.replicas.monitor(context.Background(), cfg.ReplicaHealthInterval)
+	}
+
+	return r
+}
+
+// WithTransaction runs fn in a database transaction. Repository calls made
+// with the context passed to fn join the transaction, which is committed
+// when fn returns nil and rolled back otherwise. Nested calls reuse the
+// outer transaction.
+func (r *repositoryCtx) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	markWritten(ctx)
+	return r.cfg.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// db returns the transaction carried by ctx, or the shared connection pool.
+func (r *repositoryCtx) db(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+
+	return r.cfg.DB.WithContext(ctx)
+}
+
+// write returns the connection for a write and marks the request session as
+// having written, so its later reads go to the primary.
+func (r *repositoryCtx) write(ctx context.Context) *gorm.DB {
+	markWritten(ctx)
+	return r.db(ctx)
+}
+
+// first loads the first member of the tenant matching the query and
+// decrypts it. Outside a transaction, and unless the request has already
+// written, it is served by a replica. A replica that fails is taken out of
+// rotation and the primary answers.
+func (r *repositoryCtx) first(ctx context.Context, user *entity.User, conds ...interface{}) error {
+	err := r.firstRow(ctx, user, conds...)
+	if err != nil {
+		return err
+	}
+
+	return r.openUser(user)
+}
+
+func (r *repositoryCtx) firstRow(ctx context.Context, user *entity.User, conds ...interface{}) error {
+	_, inTx := ctx.Value(txKey{}).(*gorm.DB)
+	if !inTx && !hasWritten(ctx) {
+		if rep := r.replicas.pick(); rep != nil {
+			err := rep.db.WithContext(ctx).Scopes(tenantMembership(ctx)).Select(userColumns).First(user, conds...).Error
+			if err == nil || errors.Is(err, gorm.ErrRecordNotFound) || ctx.Err() != nil {
+				return err
+			}
+
+			log.Printf(`Replica read error %s, falling back to primary`, err.Error())
+			rep.healthy.Store(false)
+		}
+	}
+
+	return r.db(ctx).Scopes(tenantMembership(ctx)).Select(userColumns).First(user, conds...).Error
 }
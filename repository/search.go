@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/search"
+	"github.com/sawitpro/technical_test/tenant"
+	"gorm.io/gorm"
+)
+
+// searchScanPageSize is the number of users scored at a time when searching
+// without trigram indexes.
+const searchScanPageSize = 1000
+
+// searchUsersQuery ranks users the way search.Query.Score does, using the
+// trigram indexes on the unaccented, lowercased full name and on the phone
+// number. The <% operator compares against
+// pg_trgm.word_similarity_threshold, which must be set to search.Threshold.
+// Like tenantMembership, it only finds the members of the organization @org,
+// or every user when it is 0.
+const searchUsersQuery = `SELECT id, full_name, phone_number, score FROM (
+	SELECT "user".id, full_name, phone_number, GREATEST(
+		CASE WHEN @name <> '' THEN word_similarity(@name, lower(immutable_unaccent(full_name))) ELSE 0 END,
+		CASE WHEN @digits <> '' AND phone_number LIKE @phone THEN word_similarity(@digits, phone_number) ELSE 0 END
+	) AS score
+	FROM "user"
+	JOIN "organization_member" ON "organization_member"."user_id" = "user"."id"
+		AND "organization_member"."organization_id" = COALESCE(NULLIF(@org, 0), "user"."organization_id")
+	WHERE (@name <> '' AND @name <% lower(immutable_unaccent(full_name)))
+		OR (@digits <> '' AND phone_number LIKE @phone)
+) matches
+ORDER BY score DESC, id
+LIMIT NULLIF(@limit, 0) OFFSET @offset`
+
+// SearchUsers uses the pg_trgm indexes on Postgres. SQLite has none, and
+// encrypted phone numbers cannot be indexed, so then the users are read a
+// page at a time and scored in the application instead.
+func (r *repositoryCtx) SearchUsers(ctx context.Context, filter entity.UserSearch) ([]entity.UserMatch, error) {
+	query := search.NewQuery(filter.Query)
+	if query.Empty() {
+		return nil, nil
+	}
+
+	if r.cfg.DBDriver != config.DriverPostgres || r.cfg.Keyring != nil {
+		return r.scanUsers(ctx, query, filter)
+	}
+
+	var (
+		matches []entity.UserMatch
+		err     error
+	)
+
+	db := r.db(ctx)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// Like SET LOCAL, the setting lasts until the end of the transaction.
+		threshold := strconv.FormatFloat(search.Threshold, 'f', -1, 64)
+		err := tx.Exec(`SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)`, threshold).Error
+		if err != nil {
+			return err
+		}
+
+		orgID, _ := tenant.ID(ctx)
+		return tx.Raw(searchUsersQuery, map[string]interface{}{
+			`org`:    orgID,
+			`name`:   query.Name,
+			`digits`: query.Digits,
+			`phone`:  `%` + query.Digits + `%`,
+			`limit`:  filter.Limit,
+			`offset`: filter.Offset,
+		}).Scan(&matches).Error
+	})
+	if err != nil {
+		log.Printf(`Search users error %s`, err.Error())
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+func (r *repositoryCtx) scanUsers(ctx context.Context, query search.Query, filter entity.UserSearch) ([]entity.UserMatch, error) {
+	var (
+		matches []entity.UserMatch
+		afterID int
+	)
+
+	keep := 0
+	if filter.Limit > 0 {
+		keep = filter.Offset + filter.Limit
+	}
+
+	db := r.db(ctx)
+
+	for {
+		var users []entity.User
+		err := db.Scopes(tenantMembership(ctx)).Select(`"user"."id", "user"."full_name", "user"."phone_number", "user"."phone_number_ciphertext"`).
+			Where(`"user"."id" > ?`, afterID).Order(`"user"."id"`).Limit(searchScanPageSize).Find(&users).Error
+		if err != nil {
+			log.Printf(`Search users error %s`, err.Error())
+			return nil, err
+		}
+		if err = r.openUsers(users); err != nil {
+			return nil, err
+		}
+
+		for _, user := range users {
+			if match, ok := matchUser(query, user); ok {
+				matches = append(matches, match)
+			}
+		}
+		// Only the matches up to the requested page are kept between pages.
+		matches = rankMatches(matches, keep)
+
+		if len(users) < searchScanPageSize {
+			break
+		}
+		afterID = users[len(users)-1].ID
+	}
+
+	return pageMatches(matches, filter), nil
+}
+
+func matchUser(query search.Query, user entity.User) (entity.UserMatch, bool) {
+	score, ok := query.Score(user.FullName, user.PhoneNumber)
+
+	return entity.UserMatch{
+		ID:          user.ID,
+		FullName:    user.FullName,
+		PhoneNumber: user.PhoneNumber,
+		Score:       score,
+	}, ok
+}
+
+// rankMatches sorts matches best first, ties by id, and keeps the first n
+// of them, or all of them when n is not positive.
+func rankMatches(matches []entity.UserMatch, n int) []entity.UserMatch {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	if n > 0 && n < len(matches) {
+		matches = matches[:n]
+	}
+
+	return matches
+}
+
+// pageMatches returns the page of ranked matches selected by filter.
+func pageMatches(matches []entity.UserMatch, filter entity.UserSearch) []entity.UserMatch {
+	if filter.Offset >= len(matches) {
+		return nil
+	}
+	matches = matches[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matches) {
+		matches = matches[:filter.Limit]
+	}
+
+	return matches
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/migration"
+	"gorm.io/gorm"
+)
+
+func TestSQLiteRepository(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Repository {
+		return NewRepository(&config.Config{DB: newSQLiteDB(t), DBDriver: config.DriverSQLite})
+	})
+}
+
+// newSQLiteDB returns a migrated private in-memory database that is closed
+// when the test ends.
+func newSQLiteDB(t *testing.T) *gorm.DB {
+	db, err := config.OpenSQLite(`:memory:`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = migration.Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if dbc, err := db.DB(); err == nil {
+			dbc.Close()
+		}
+	})
+
+	return db
+}
+
+func TestEncryptedSQLiteRepository(t *testing.T) {
+	runContractTests(t, func(t *testing.T) Repository {
+		return NewRepository(&config.Config{DB: newSQLiteDB(t), DBDriver: config.DriverSQLite, Keyring: testKeyring(t, `k1`)})
+	})
+}
@@ -0,0 +1,182 @@
+// Package search scores users against a fuzzy query the way the Postgres
+// search does with pg_trgm, for the storage backends without trigram
+// indexes.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Threshold is the lowest name score that counts as a match. It is lower
+// than the pg_trgm default of 0.6 so that names with a typo or two are still
+// found.
+const Threshold = 0.3
+
+// minPhoneDigits is the shortest digit sequence searched in phone numbers,
+// since shorter ones match almost every user.
+const minPhoneDigits = 3
+
+// Query is a normalized search query.
+type Query struct {
+	// Name is the query lowercased and without accents.
+	Name string
+	// Digits are the digits of the query when it looks like a phone number,
+	// with a leading 0 replaced by the 62 country code.
+	Digits string
+}
+
+// NewQuery normalizes q. Both fields are empty when nothing can match.
+func NewQuery(q string) Query {
+	query := Query{Name: Normalize(q)}
+
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, q)
+	if strings.HasPrefix(digits, `0`) {
+		digits = `62` + digits[1:]
+	}
+	if len(digits) >= minPhoneDigits && isPhoneLike(q) {
+		query.Digits = digits
+	}
+
+	return query
+}
+
+// Empty reports whether the query cannot match any user.
+func (q Query) Empty() bool {
+	return len(trigrams(q.Name)) == 0 && q.Digits == ``
+}
+
+// Score returns how well a user matches the query, between 0 and 1, and
+// whether it matches at all. A user matches when the name is similar enough
+// to the query or when the phone number contains its digits.
+func (q Query) Score(fullName string, phoneNumber string) (float64, bool) {
+	var (
+		score   float64
+		matched bool
+	)
+
+	if q.Name != `` {
+		score = WordSimilarity(q.Name, Normalize(fullName))
+		matched = score >= Threshold
+	}
+
+	if q.Digits != `` && strings.Contains(phoneNumber, q.Digits) {
+		matched = true
+		if phoneScore := WordSimilarity(q.Digits, phoneNumber); phoneScore > score {
+			score = phoneScore
+		}
+	}
+
+	return score, matched
+}
+
+// Normalize lowercases s and strips its accents, like lower(unaccent(s)) in
+// Postgres.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	return b.String()
+}
+
+// Similarity returns the number of trigrams a and b share divided by the
+// number of distinct trigrams in both, as pg_trgm's similarity does.
+func Similarity(a string, b string) float64 {
+	return jaccard(trigrams(a), trigrams(b))
+}
+
+// WordSimilarity returns the greatest similarity between query and a run of
+// consecutive words of text, so that "budi" matches "Budi Santoso" as well
+// as "Budi" does. It approximates pg_trgm's word_similarity, which looks at
+// runs of trigrams rather than of words.
+func WordSimilarity(query string, text string) float64 {
+	queryTrigrams := trigrams(query)
+	if len(queryTrigrams) == 0 {
+		return 0
+	}
+
+	textWords := words(text)
+	span := len(words(query))
+	if span < 1 {
+		span = 1
+	}
+
+	var best float64
+	for start := range textWords {
+		for end := start + 1; end <= len(textWords) && end-start <= span; end++ {
+			run := make(map[string]bool)
+			for _, word := range textWords[start:end] {
+				addTrigrams(run, word)
+			}
+			if score := jaccard(queryTrigrams, run); score > best {
+				best = score
+			}
+		}
+	}
+
+	return best
+}
+
+// trigrams returns the trigrams of the words of s, each word padded with two
+// spaces before and one after, like pg_trgm.
+func trigrams(s string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range words(s) {
+		addTrigrams(set, word)
+	}
+
+	return set
+}
+
+func addTrigrams(set map[string]bool, word string) {
+	padded := []rune(`  ` + strings.ToLower(word) + ` `)
+	for i := 0; i+3 <= len(padded); i++ {
+		set[string(padded[i:i+3])] = true
+	}
+}
+
+// words splits s on every character that is not a letter or a digit.
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func jaccard(a map[string]bool, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	common := 0
+	for trigram := range a {
+		if b[trigram] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// isPhoneLike reports whether q holds nothing but digits and the characters
+// used to write phone numbers.
+func isPhoneLike(q string) bool {
+	for _, r := range q {
+		if !unicode.IsDigit(r) && !strings.ContainsRune(`+-() `, r) {
+			return false
+		}
+	}
+
+	return true
+}
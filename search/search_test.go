@@ -0,0 +1,76 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, `jose muller`, Normalize(`José MÜLLER`))
+	assert.Equal(t, `siti aisyah`, Normalize(`Sití Äisyah`))
+}
+
+func TestSimilarity(t *testing.T) {
+	// "word" and "words" share 4 of their 7 distinct trigrams.
+	assert.Equal(t, 1.0, Similarity(`budi`, `Budi`))
+	assert.InDelta(t, 4.0/7, Similarity(`word`, `words`), 1e-9)
+	assert.Equal(t, 0.0, Similarity(`budi`, `siti`))
+	assert.Equal(t, 0.0, Similarity(``, `siti`))
+}
+
+func TestWordSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, WordSimilarity(`santoso`, `budi santoso`))
+	assert.Equal(t, 1.0, WordSimilarity(`budi santoso`, `pak budi santoso`))
+	assert.Greater(t, WordSimilarity(`santosa`, `budi santoso`), Threshold)
+	assert.Less(t, WordSimilarity(`agus`, `budi santoso`), Threshold)
+}
+
+func TestNewQuery(t *testing.T) {
+	tests := []struct {
+		q    string
+		want Query
+	}{
+		{q: `Budi`, want: Query{Name: `budi`}},
+		{q: `0812-345`, want: Query{Name: `0812-345`, Digits: `62812345`}},
+		{q: `+62 812`, want: Query{Name: `+62 812`, Digits: `62812`}},
+		{q: `12`, want: Query{Name: `12`}},
+		{q: `budi 812`, want: Query{Name: `budi 812`}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NewQuery(tt.q), tt.q)
+	}
+
+	assert.True(t, NewQuery(` - `).Empty())
+	assert.False(t, NewQuery(`bu`).Empty())
+}
+
+func TestQueryScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		q           string
+		fullName    string
+		phoneNumber string
+		matched     bool
+	}{
+		{name: `AccentAndCase`, q: `jose`, fullName: `José Ramos`, phoneNumber: `+628123456781`, matched: true},
+		{name: `Typo`, q: `santosa`, fullName: `Budi Santoso`, phoneNumber: `+628123456781`, matched: true},
+		{name: `OtherName`, q: `agus`, fullName: `Budi Santoso`, phoneNumber: `+628123456781`, matched: false},
+		{name: `PhoneDigits`, q: `34567`, fullName: `Budi Santoso`, phoneNumber: `+628123456781`, matched: true},
+		{name: `LocalPhone`, q: `08123`, fullName: `Budi Santoso`, phoneNumber: `+628123456781`, matched: true},
+		{name: `OtherPhone`, q: `0899`, fullName: `Budi Santoso`, phoneNumber: `+628123456781`, matched: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, matched := NewQuery(tt.q).Score(tt.fullName, tt.phoneNumber)
+			assert.Equal(t, tt.matched, matched)
+			if matched {
+				assert.Greater(t, score, 0.0)
+			}
+		})
+	}
+
+	exact, _ := NewQuery(`budi santoso`).Score(`Budi Santoso`, `+628123456781`)
+	partial, _ := NewQuery(`budi santoso`).Score(`Budi Santosa`, `+628123456782`)
+	assert.Greater(t, exact, partial)
+}
@@ -2,7 +2,10 @@ package shared
 
 import (
 	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
+	"math/big"
 )
 
 func MD5(text string) string {
@@ -10,3 +13,23 @@ func MD5(text string) string {
 	hasher.Write([]byte(text))
 	return hex.EncodeToString(hasher.Sum(nil))
 }
+
+func SHA256(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// RandomDigits returns a string of length decimal digits read from a
+// cryptographically secure source, for codes that must not be guessable.
+func RandomDigits(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return ``, err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+
+	return string(digits), nil
+}
@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"errors"
+	"net/http"
+)
+
+// The kinds of domain errors. Errors of any layer wrap one of them, directly
+// or through an ErrorMessage, and HttpError reports them with the matching
+// status.
+var (
+	ErrValidation   = errors.New(`validation failed`)
+	ErrUnauthorized = errors.New(`unauthorized`)
+	ErrForbidden    = errors.New(`forbidden`)
+	ErrNotFound     = errors.New(`not found`)
+	ErrConflict     = errors.New(`conflict`)
+	ErrInternal     = errors.New(`internal error`)
+)
+
+// kinds are the statuses of the kinds, with the message reported for an
+// error that carries no message of its own.
+var kinds = []struct {
+	kind    error
+	status  int
+	message string
+}{
+	{ErrValidation, http.StatusBadRequest, ERR_BAD_REQUEST.ErrorMessage},
+	{ErrUnauthorized, http.StatusUnauthorized, `Unauthorized`},
+	{ErrForbidden, http.StatusForbidden, `Forbidden`},
+	{ErrNotFound, http.StatusNotFound, `Not found`},
+	{ErrConflict, http.StatusConflict, `Conflict`},
+}
+
+func Validation(message string) *ErrorMessage {
+	return &ErrorMessage{ErrorCode: http.StatusBadRequest, ErrorMessage: message}
+}
+
+func Unauthorized(message string) *ErrorMessage {
+	return &ErrorMessage{ErrorCode: http.StatusUnauthorized, ErrorMessage: message}
+}
+
+func Forbidden(message string) *ErrorMessage {
+	return &ErrorMessage{ErrorCode: http.StatusForbidden, ErrorMessage: message}
+}
+
+func NotFound(message string) *ErrorMessage {
+	return &ErrorMessage{ErrorCode: http.StatusNotFound, ErrorMessage: message}
+}
+
+func Conflict(message string) *ErrorMessage {
+	return &ErrorMessage{ErrorCode: http.StatusConflict, ErrorMessage: message}
+}
+
+// Internal reports a failure the client cannot do anything about, such as a
+// database error. The client only sees "Internal server error"; err is
+// logged by HttpError.
+func Internal(err error) *ErrorMessage {
+	return &ErrorMessage{
+		ErrorCode:    http.StatusInternalServerError,
+		ErrorMessage: "Internal server error",
+		Err:          err,
+	}
+}
+
+// Malformed reports a request that could not be parsed, such as invalid
+// JSON or a non-numeric id.
+func Malformed(err error) *ErrorMessage {
+	return &ErrorMessage{
+		ErrorCode:    ERR_BAD_REQUEST.ErrorCode,
+		ErrorMessage: ERR_BAD_REQUEST.ErrorMessage,
+		Err:          err,
+	}
+}
+
+// kindOf returns the kind of errors reported with status.
+func kindOf(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case status == http.StatusForbidden:
+		return ErrForbidden
+	case status == http.StatusNotFound:
+		return ErrNotFound
+	case status == http.StatusConflict, status == http.StatusPreconditionFailed:
+		return ErrConflict
+	case status >= http.StatusInternalServerError:
+		return ErrInternal
+	default:
+		return ErrValidation
+	}
+}
+
+// ErrorMessageOf returns the ErrorMessage err is or wraps. Any other error
+// is reported by its kind, and as Internal when it has none.
+func ErrorMessageOf(err error) *ErrorMessage {
+	var msg *ErrorMessage
+	if errors.As(err, &msg) {
+		return msg
+	}
+
+	for _, k := range kinds {
+		if errors.Is(err, k.kind) {
+			return &ErrorMessage{ErrorCode: k.status, ErrorMessage: k.message, Err: err}
+		}
+	}
+
+	return Internal(err)
+}
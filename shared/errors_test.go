@@ -0,0 +1,49 @@
+package shared
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorMessageOf(t *testing.T) {
+	cause := errors.New(`connection refused`)
+
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+		kind    error
+	}{
+		{`Validation`, Validation(`Full name is required`), http.StatusBadRequest, `Full name is required`, ErrValidation},
+		{`NotFound`, NotFound(`This user does not exists`), http.StatusNotFound, `This user does not exists`, ErrNotFound},
+		{`WrappedMessage`, fmt.Errorf(`commit: %w`, Conflict(`Phone number already exists`)), http.StatusConflict, `Phone number already exists`, ErrConflict},
+		{`WrappedKind`, fmt.Errorf(`record %w`, ErrNotFound), http.StatusNotFound, `Not found`, ErrNotFound},
+		{`Internal`, Internal(cause), http.StatusInternalServerError, `Internal server error`, ErrInternal},
+		{`Untyped`, cause, http.StatusInternalServerError, `Internal server error`, ErrInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := ErrorMessageOf(tt.err)
+			assert.Equal(t, tt.status, msg.ErrorCode)
+			assert.Equal(t, tt.message, msg.ErrorMessage)
+			assert.ErrorIs(t, msg, tt.kind)
+		})
+	}
+}
+
+func TestHttpError(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, `/profile/1`, nil), rec)
+
+	assert.NoError(t, HttpError(c, Internal(errors.New(`password authentication failed for user "postgres"`))))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"code":500,"message":"Internal server error"}`, rec.Body.String())
+}
@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ETag formats a resource version as a strong entity tag.
+func ETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// AnyVersion is the version of the If-Match value "*", which matches any
+// current version of the resource (RFC 7232 section 3.1).
+const AnyVersion = -1
+
+// ParseETag returns the version held by an entity tag produced by ETag, or
+// AnyVersion for "*". Weak tags are accepted since the version is the only
+// validator.
+func ParseETag(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == `*` {
+		return AnyVersion, true
+	}
+
+	tag = strings.TrimPrefix(tag, `W/`)
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,28 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		tag     string
+		version int
+		ok      bool
+	}{
+		{tag: ETag(3), version: 3, ok: true},
+		{tag: ` W/"3" `, version: 3, ok: true},
+		{tag: `*`, version: AnyVersion, ok: true},
+		{tag: `3`, ok: false},
+		{tag: `"0"`, ok: false},
+		{tag: `"abc"`, ok: false},
+		{tag: `"`, ok: false},
+	}
+	for _, tt := range tests {
+		version, ok := ParseETag(tt.tag)
+		assert.Equal(t, tt.ok, ok, tt.tag)
+		assert.Equal(t, tt.version, version, tt.tag)
+	}
+}
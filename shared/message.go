@@ -12,13 +12,43 @@ var (
 	}
 )
 
+// ErrorMessage is an error reported to the client with the HTTP status
+// ErrorCode. Err is the cause, which is logged for internal errors but never
+// sent to the client.
 type ErrorMessage struct {
-	ErrorCode    int    `json:"code"`
-	ErrorMessage string `json:"message"`
+	ErrorCode    int          `json:"code"`
+	ErrorMessage string       `json:"message"`
+	Errors       []FieldError `json:"errors,omitempty"`
+	Err          error        `json:"-"`
 }
 
+// FieldError is one reason a request was rejected, for requests that do not
+// match the API specification.
+type FieldError struct {
+	// In is where the field is: body, query, header or path.
+	In string `json:"in"`
+	// Field is the parameter name, or the path of a body property with its
+	// parts joined by dots, empty for the body as a whole.
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error includes the cause, which the JSON encoding leaves out.
 func (c *ErrorMessage) Error() string {
 	b, _ := json.Marshal(c)
+	if c.Err != nil {
+		return string(b) + `: ` + c.Err.Error()
+	}
 
 	return string(b)
 }
+
+func (c *ErrorMessage) Unwrap() error {
+	return c.Err
+}
+
+// Is matches the kind of the error, given by its status, so that
+// errors.Is(err, ErrNotFound) holds for a 404.
+func (c *ErrorMessage) Is(target error) bool {
+	return target == kindOf(c.ErrorCode)
+}
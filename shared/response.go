@@ -1,19 +1,22 @@
 package shared
 
 import (
+	"log"
 	"net/http"
 
 	"github.com/labstack/echo/v4"
 )
 
+// HttpError writes err as the response with the status of its kind. The
+// cause of an internal error is logged rather than sent to the client.
 func HttpError(c echo.Context, err error) error {
-	switch err.(type) {
-	case *ErrorMessage:
-		msg := err.(*ErrorMessage)
-		return c.JSON(msg.ErrorCode, msg)
+	msg := ErrorMessageOf(err)
+	if msg.ErrorCode >= http.StatusInternalServerError {
+		req := c.Request()
+		log.Printf(`%s %s error %s`, req.Method, req.URL.Path, msg.Error())
 	}
 
-	return c.JSON(http.StatusBadRequest, ERR_BAD_REQUEST)
+	return c.JSON(msg.ErrorCode, msg)
 }
 
 type Response struct {
@@ -0,0 +1,91 @@
+// Package sms sends text messages such as one-time codes to phone numbers.
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+)
+
+// Sender delivers a text message to a phone number. Send returns nil once
+// the message has been accepted for delivery. Implementations must be safe
+// for concurrent use.
+type Sender interface {
+	Send(ctx context.Context, phoneNumber string, message string) error
+}
+
+// logSender writes messages to the log instead of sending them.
+type logSender struct {
+	logger *log.Logger
+}
+
+// NewLogSender returns a Sender that only logs each message, one-time codes
+// included. It is meant for development, where no SMS gateway is available.
+func NewLogSender(logger *log.Logger) Sender {
+	return &logSender{logger: logger}
+}
+
+func (s *logSender) Send(ctx context.Context, phoneNumber string, message string) error {
+	s.logger.Printf(`SMS to %s: %s`, phoneNumber, message)
+	return nil
+}
+
+// httpSender posts each message to an SMS gateway.
+type httpSender struct {
+	url    string
+	client *http.Client
+}
+
+// httpMessage is the request body posted by the HTTP sender.
+type httpMessage struct {
+	To      string `json:"to"`
+	Message string `json:"message"`
+}
+
+// NewHTTPSender returns a Sender posting {"to": ..., "message": ...} as JSON
+// to url. Any 2xx response accepts the message.
+func NewHTTPSender(url string, client *http.Client) Sender {
+	return &httpSender{url: url, client: client}
+}
+
+func (s *httpSender) Send(ctx context.Context, phoneNumber string, message string) error {
+	body, err := json.Marshal(httpMessage{To: phoneNumber, Message: message})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(`Content-Type`, `application/json`)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf(`send sms: %s responded %s`, s.url, res.Status)
+	}
+
+	return nil
+}
+
+// NewSender returns the Sender selected by cfg.SMSSender.
+func NewSender(cfg *config.Config) Sender {
+	if cfg.SMSSender == config.SMSSenderHTTP {
+		return NewHTTPSender(cfg.SMSURL, &http.Client{Timeout: 10 * time.Second})
+	}
+
+	return NewLogSender(log.Default())
+}
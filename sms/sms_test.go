@@ -0,0 +1,44 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogSender(t *testing.T) {
+	buf := &bytes.Buffer{}
+	sender := NewLogSender(log.New(buf, ``, 0))
+
+	assert.NoError(t, sender.Send(context.Background(), `+628123456781`, `Your verification code is 123456.`))
+	assert.Equal(t, "SMS to +628123456781: Your verification code is 123456.\n", buf.String())
+}
+
+func TestHTTPSender(t *testing.T) {
+	var (
+		body   []byte
+		header http.Header
+		status = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(server.URL, server.Client())
+
+	assert.NoError(t, sender.Send(context.Background(), `+628123456781`, `hello`))
+	assert.Equal(t, `application/json`, header.Get(`Content-Type`))
+	assert.JSONEq(t, `{"to":"+628123456781","message":"hello"}`, string(body))
+
+	status = http.StatusBadGateway
+	assert.Error(t, sender.Send(context.Background(), `+628123456781`, `hello`))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	_ "embed"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// apiSpec is api.yaml, built into the binary so that the contract enforced
+// at runtime is the one the server was built with.
+//
+//go:embed api.yaml
+var apiSpec []byte
+
+// LoadSpec parses and checks the embedded API specification.
+func LoadSpec() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(apiSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = spec.Validate(loader.Context); err != nil {
+		return nil, err
+	}
+
+	return spec, nil
+}
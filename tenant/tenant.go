@@ -0,0 +1,33 @@
+// Package tenant carries the organization a request acts in through its
+// context, so that the repository can scope every query to it.
+package tenant
+
+import "context"
+
+// DefaultID is the organization that every user belonged to before
+// organizations were introduced. Tokens without an organization act in it.
+const DefaultID = 1
+
+type key struct{}
+
+// WithID returns a context acting in the organization orgID. An orgID of 0
+// removes the organization, so that queries made with the context see every
+// tenant; only background jobs and the cache should need that.
+func WithID(ctx context.Context, orgID int) context.Context {
+	return context.WithValue(ctx, key{}, orgID)
+}
+
+// ID returns the organization ctx acts in, and false when it has none.
+func ID(ctx context.Context) (int, bool) {
+	orgID, _ := ctx.Value(key{}).(int)
+	return orgID, orgID != 0
+}
+
+// IDOrDefault returns the organization ctx acts in, or DefaultID.
+func IDOrDefault(ctx context.Context) int {
+	if orgID, ok := ID(ctx); ok {
+		return orgID
+	}
+
+	return DefaultID
+}
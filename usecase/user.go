@@ -2,30 +2,77 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"io"
 
+	"github.com/sawitpro/technical_test/blob"
 	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
 	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
 )
 
 type UserUsecase interface {
 	UserRegistration(ctx context.Context, form *user.UserRegistrationRequest) (*user.UserRegistrationResponse, error)
+	ImportUsers(ctx context.Context, form *user.ImportUsersRequest) (*user.ImportUsersResponse, error)
+	ExportUsers(ctx context.Context, form *user.ExportUsersRequest, w io.Writer) error
+	SearchUsers(ctx context.Context, form *user.SearchUsersRequest) (*user.SearchUsersResponse, error)
 	UserLogin(ctx context.Context, form *user.UserLoginRequest) (*user.UserLoginResponse, error)
 	GetUserProfile(ctx context.Context, userID int) (*user.GetUserProfileResponse, error)
 	UpdateProfile(ctx context.Context, form *user.UpdateProfileRequest, userID int) error
+	PatchProfile(ctx context.Context, form *user.PatchProfileRequest, userID int) error
+	UploadAvatar(ctx context.Context, form *user.UploadAvatarRequest, userID int) (*user.AvatarResponse, error)
+	Reauthenticate(ctx context.Context, form *user.ReauthenticateRequest, userID int) (*user.UserLoginResponse, error)
+	ChangePassword(ctx context.Context, form *user.ChangePasswordRequest, userID int) error
+	RequestPhoneNumberChange(ctx context.Context, form *user.RequestPhoneNumberChangeRequest, userID int) (*user.PhoneNumberChangeResponse, error)
+	ConfirmPhoneNumberChange(ctx context.Context, form *user.ConfirmPhoneNumberChangeRequest, userID int) error
+	Impersonate(ctx context.Context, form *user.ImpersonateRequest, adminID int) (*user.UserLoginResponse, error)
+	RecordImpersonation(ctx context.Context, audit *entity.ImpersonationAudit) error
+	ListAuditLogs(ctx context.Context, form *user.ListAuditLogsRequest) (*user.ListAuditLogsResponse, error)
+	ListLogins(ctx context.Context, form *user.ListLoginsRequest, userID int) (*user.ListLoginsResponse, error)
+	CreateWebhook(ctx context.Context, form *user.CreateWebhookRequest) (*user.CreateWebhookResponse, error)
+	ListWebhooks(ctx context.Context) (*user.ListWebhooksResponse, error)
+	DeleteWebhook(ctx context.Context, subscriptionID int) error
+	ListWebhookDeliveries(ctx context.Context, form *user.ListWebhookDeliveriesRequest, subscriptionID int) (*user.ListWebhookDeliveriesResponse, error)
+	GetWebhookDelivery(ctx context.Context, deliveryID int) (*user.WebhookDeliveryDetailResponse, error)
+	ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*user.WebhookDeliveryResponse, error)
+	CreateOrganization(ctx context.Context, form *user.CreateOrganizationRequest) (*user.OrganizationResponse, error)
+	AddOrganizationMember(ctx context.Context, form *user.AddOrganizationMemberRequest, organizationID int) (*user.OrganizationMemberResponse, error)
+	ListOrganizations(ctx context.Context, userID int) (*user.ListOrganizationsResponse, error)
 }
 
 type userUsecaseCtx struct {
-	cfg  *config.Config
-	repo repository.Repository
+	cfg     *config.Config
+	repo    repository.Repository
+	storage blob.Storage
 }
 
 func NewUserUsecase(
 	cfg *config.Config,
 	repo repository.Repository,
+	storage blob.Storage,
 ) UserUsecase {
 	return &userUsecaseCtx{
-		cfg:  cfg,
-		repo: repo,
+		cfg:     cfg,
+		repo:    repo,
+		storage: storage,
 	}
 }
+
+var errUserNotFound = shared.NotFound("This user does not exists")
+
+var errPhoneNumberExists = shared.Conflict("Phone number already exists")
+
+var errOrganizationNotFound = shared.NotFound("This organization does not exist")
+
+// transactionError passes usecase errors returned from a transaction through
+// and reports anything else, such as a failed commit, as an internal error.
+func transactionError(err error) error {
+	var msg *shared.ErrorMessage
+	if errors.As(err, &msg) {
+		return msg
+	}
+
+	return shared.Internal(err)
+}
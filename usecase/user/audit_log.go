@@ -0,0 +1,91 @@
+package user
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	defaultAuditLogLimit = 50
+	maxAuditLogLimit     = 200
+)
+
+type ListAuditLogsRequest struct {
+	ActorID int    `query:"actor_id"`
+	UserID  int    `query:"user_id"`
+	Action  string `query:"action"`
+	// From and To bound the creation time as RFC 3339 timestamps, From
+	// inclusive and To exclusive.
+	From   string `query:"from"`
+	To     string `query:"to"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+type ListAuditLogsResponse struct {
+	AuditLogs []AuditLogResponse `json:"audit_logs"`
+}
+
+type AuditLogResponse struct {
+	ID        int             `json:"id"`
+	ActorID   int             `json:"actor_id"`
+	ActorRole string          `json:"actor_role"`
+	Action    string          `json:"action"`
+	UserID    int             `json:"user_id"`
+	Changes   json.RawMessage `json:"changes"`
+	IPAddress string          `json:"ip_address"`
+	RequestID string          `json:"request_id"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+func (c *ListAuditLogsRequest) Validation() error {
+
+	if c.ActorID < 0 || c.UserID < 0 {
+		return shared.Validation("Actor id and user id must be positive")
+	}
+
+	if c.Limit < 0 || c.Limit > maxAuditLogLimit {
+		return shared.Validation("Limit must be between 1 to 200")
+	}
+
+	if c.Offset < 0 {
+		return shared.Validation("Offset must not be negative")
+	}
+
+	for _, value := range []string{c.From, c.To} {
+		if value == `` {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return shared.Validation("From and to must be RFC 3339 timestamps")
+		}
+	}
+
+	return nil
+}
+
+// Filter converts a validated request to a repository filter.
+func (c *ListAuditLogsRequest) Filter() entity.AuditLogFilter {
+	filter := entity.AuditLogFilter{
+		ActorID: c.ActorID,
+		UserID:  c.UserID,
+		Action:  c.Action,
+		Limit:   c.Limit,
+		Offset:  c.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLogLimit
+	}
+	// Entries are stored in UTC+7, like every other timestamp.
+	if from, err := time.Parse(time.RFC3339, c.From); err == nil {
+		filter.From = shared.UTC7(from)
+	}
+	if to, err := time.Parse(time.RFC3339, c.To); err == nil {
+		filter.To = shared.UTC7(to)
+	}
+
+	return filter
+}
@@ -0,0 +1,20 @@
+package user
+
+import "io"
+
+type UploadAvatarRequest struct {
+	// Body is the image, read up to the configured size limit.
+	Body io.Reader `json:"-"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	RequestMeta
+}
+
+// AvatarResponse has the URL of each avatar variant.
+type AvatarResponse struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
+}
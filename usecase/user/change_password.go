@@ -0,0 +1,31 @@
+package user
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sawitpro/technical_test/shared"
+)
+
+type ChangePasswordRequest struct {
+	Password string `json:"password"`
+
+	// AuthTime is taken from the access token, not from the request body.
+	AuthTime time.Time `json:"-"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	RequestMeta
+}
+
+func (c *ChangePasswordRequest) Validation() error {
+
+	c.Password = strings.TrimSpace(c.Password)
+	err := shared.CheckPasswordComplexity(c.Password)
+	if err != nil {
+		return shared.Validation(err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	ExportFormatCSV   = `csv`
+	ExportFormatJSONL = `jsonl`
+)
+
+// ExportFields are the user fields that can be exported, in their default
+// order. The password hash and salt are never exported.
+var ExportFields = []string{
+	`id`,
+	`full_name`,
+	`phone_number`,
+	`role`,
+	`date_of_birth`,
+	`gender`,
+	`address`,
+	`preferred_language`,
+	`successful_login`,
+	`last_login_at`,
+	`failed_login_count`,
+	`last_failed_login_at`,
+	`version`,
+	`created_at`,
+	`updated_at`,
+}
+
+// ExportUsersRequest exports the users matching the filters, ordered by id.
+type ExportUsersRequest struct {
+	Format string `query:"format"`
+	// Fields is a comma separated list of ExportFields, all of them when
+	// empty.
+	Fields string `query:"fields"`
+	// The times are RFC 3339 timestamps, From inclusive and To exclusive.
+	CreatedFrom string `query:"created_from"`
+	CreatedTo   string `query:"created_to"`
+	UpdatedFrom string `query:"updated_from"`
+	UpdatedTo   string `query:"updated_to"`
+}
+
+func (c *ExportUsersRequest) Validation() error {
+
+	if c.Format == `` {
+		c.Format = ExportFormatCSV
+	}
+	if c.Format != ExportFormatCSV && c.Format != ExportFormatJSONL {
+		return shared.Validation("Format must be one of csv or jsonl")
+	}
+
+	if _, err := c.FieldList(); err != nil {
+		return err
+	}
+
+	for _, value := range []string{c.CreatedFrom, c.CreatedTo, c.UpdatedFrom, c.UpdatedTo} {
+		if value == `` {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return shared.Validation("Created and updated times must be RFC 3339 timestamps")
+		}
+	}
+
+	return nil
+}
+
+// FieldList returns the requested fields, in the requested order.
+func (c *ExportUsersRequest) FieldList() ([]string, error) {
+	if strings.TrimSpace(c.Fields) == `` {
+		return ExportFields, nil
+	}
+
+	exportable := make(map[string]bool, len(ExportFields))
+	for _, field := range ExportFields {
+		exportable[field] = true
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	for _, field := range strings.Split(c.Fields, `,`) {
+		field = strings.TrimSpace(field)
+		if !exportable[field] {
+			return nil, shared.Validation(fmt.Sprintf("Field %q cannot be exported", field))
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// Filter converts a validated request to a repository filter.
+func (c *ExportUsersRequest) Filter() entity.UserFilter {
+	var filter entity.UserFilter
+	// Users are stored in UTC+7, like every other timestamp.
+	if from, err := time.Parse(time.RFC3339, c.CreatedFrom); err == nil {
+		filter.CreatedFrom = shared.UTC7(from)
+	}
+	if to, err := time.Parse(time.RFC3339, c.CreatedTo); err == nil {
+		filter.CreatedTo = shared.UTC7(to)
+	}
+	if from, err := time.Parse(time.RFC3339, c.UpdatedFrom); err == nil {
+		filter.UpdatedFrom = shared.UTC7(from)
+	}
+	if to, err := time.Parse(time.RFC3339, c.UpdatedTo); err == nil {
+		filter.UpdatedTo = shared.UTC7(to)
+	}
+
+	return filter
+}
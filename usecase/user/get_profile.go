@@ -1,6 +1,17 @@
 package user
 
+// GetUserProfileResponse has the optional profile fields as null when they
+// are not set.
 type GetUserProfileResponse struct {
-	FullName    string `json:"full_name"`
-	PhoneNumber string `json:"phone_number"`
+	FullName          string  `json:"full_name"`
+	PhoneNumber       string  `json:"phone_number"`
+	DateOfBirth       *string `json:"date_of_birth"`
+	Gender            *string `json:"gender"`
+	Address           *string `json:"address"`
+	PreferredLanguage *string `json:"preferred_language"`
+	// Avatar is nil when the user has not uploaded one.
+	Avatar *AvatarResponse `json:"avatar"`
+
+	// Version is sent as the ETag header rather than in the body.
+	Version int `json:"-"`
 }
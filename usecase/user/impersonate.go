@@ -0,0 +1,16 @@
+package user
+
+import "github.com/sawitpro/technical_test/shared"
+
+type ImpersonateRequest struct {
+	UserID int `json:"user_id"`
+}
+
+func (c *ImpersonateRequest) Validation() error {
+
+	if c.UserID <= 0 {
+		return shared.Validation("User id is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"io"
+
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	ImportFormatCSV   = `csv`
+	ImportFormatJSONL = `jsonl`
+)
+
+const (
+	DefaultImportBatchSize = 100
+	maxImportBatchSize     = 1000
+)
+
+// ImportUsersRequest imports the users read from Body, one registration per
+// CSV record or JSONL line. CSV files start with a header naming the
+// phone_number, full_name and password columns, in any order; JSONL lines
+// are objects with the fields of UserRegistrationRequest.
+type ImportUsersRequest struct {
+	Body   io.Reader `query:"-"`
+	Format string    `query:"format"`
+	// DryRun validates every row, against the database too, without
+	// creating any user.
+	DryRun    bool `query:"dry_run"`
+	BatchSize int  `query:"batch_size"`
+	// ActorID is the admin running the import, 0 for the command line.
+	ActorID int `query:"-"`
+
+	RequestMeta
+}
+
+// ImportUsersResponse reports every row that was not imported. In a dry run,
+// Imported counts the rows that would have been.
+type ImportUsersResponse struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// ImportRowError explains why a row was not imported. Row is the line the
+// row starts on, counting the CSV header.
+type ImportRowError struct {
+	Row         int    `json:"row"`
+	PhoneNumber string `json:"phone_number,omitempty"`
+	Message     string `json:"message"`
+}
+
+func (c *ImportUsersRequest) Validation() error {
+
+	if c.Format != ImportFormatCSV && c.Format != ImportFormatJSONL {
+		return shared.Validation("Format must be one of csv or jsonl")
+	}
+
+	if c.BatchSize < 0 || c.BatchSize > maxImportBatchSize {
+		return shared.Validation("Batch size must be between 1 to 1000")
+	}
+	if c.BatchSize == 0 {
+		c.BatchSize = DefaultImportBatchSize
+	}
+
+	if c.Body == nil {
+		return shared.Validation("Import file is required")
+	}
+
+	return nil
+}
@@ -1,36 +1,35 @@
 package user
 
-import (
-	"net/http"
-
-	"github.com/sawitpro/technical_test/shared"
-)
+import "github.com/sawitpro/technical_test/shared"
 
+// UserLoginRequest logs in to OrganizationID, the default organization when
+// it is not given.
 type UserLoginRequest struct {
-	PhoneNumber string `json:"phone_number"`
-	Password    string `json:"password"`
+	PhoneNumber    string `json:"phone_number"`
+	Password       string `json:"password"`
+	OrganizationID int    `json:"organization_id"`
+
+	RequestMeta
 }
 
 type UserLoginResponse struct {
-	UserID    int    `json:"user_id"`
-	Token     string `json:"token"`
-	ExpiredAt string `json:"expired_at"`
+	UserID         int    `json:"user_id"`
+	OrganizationID int    `json:"organization_id"`
+	Token          string `json:"token"`
+	ExpiredAt      string `json:"expired_at"`
 }
 
 func (c *UserLoginRequest) Validation() error {
+	if err := validateOrganizationID(&c.OrganizationID); err != nil {
+		return err
+	}
 
 	if c.PhoneNumber == `` {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Phone number is required",
-		}
+		return shared.Validation("Phone number is required")
 	}
 
 	if c.Password == `` {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Password is required",
-		}
+		return shared.Validation("Password is required")
 	}
 
 	return nil
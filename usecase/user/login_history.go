@@ -0,0 +1,51 @@
+package user
+
+import (
+	"time"
+
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	defaultLoginLimit = 50
+	maxLoginLimit     = 200
+)
+
+type ListLoginsRequest struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+type ListLoginsResponse struct {
+	Logins []LoginResponse `json:"logins"`
+}
+
+type LoginResponse struct {
+	ID            int       `json:"id"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	IPAddress     string    `json:"ip_address"`
+	UserAgent     string    `json:"user_agent"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func (c *ListLoginsRequest) Validation() error {
+
+	if c.Limit < 0 || c.Limit > maxLoginLimit {
+		return shared.Validation("Limit must be between 1 to 200")
+	}
+
+	if c.Offset < 0 {
+		return shared.Validation("Offset must not be negative")
+	}
+
+	return nil
+}
+
+// PageSize returns the requested limit, or the default when none was given.
+func (c *ListLoginsRequest) PageSize() int {
+	if c.Limit == 0 {
+		return defaultLoginLimit
+	}
+	return c.Limit
+}
@@ -0,0 +1,87 @@
+package user
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+type OrganizationResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddOrganizationMemberRequest adds an existing user to an organization, as
+// a user unless Role says otherwise.
+type AddOrganizationMemberRequest struct {
+	UserID int    `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+type OrganizationMemberResponse struct {
+	OrganizationID int       `json:"organization_id"`
+	UserID         int       `json:"user_id"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ListOrganizationsResponse struct {
+	Organizations []MembershipResponse `json:"organizations"`
+}
+
+// MembershipResponse is an organization the user is a member of, with their
+// role in it.
+type MembershipResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+func (c *CreateOrganizationRequest) Validation() error {
+
+	c.Name = strings.TrimSpace(c.Name)
+	if utf8.RuneCountInString(c.Name) < 2 || utf8.RuneCountInString(c.Name) > 100 {
+		return shared.Validation("Organization name length must be between 2 to 100 characters")
+	}
+
+	return nil
+}
+
+func (c *AddOrganizationMemberRequest) Validation() error {
+
+	if c.UserID <= 0 {
+		return shared.Validation("User ID is required")
+	}
+
+	if c.Role == `` {
+		c.Role = entity.RoleUser
+	}
+	if c.Role != entity.RoleUser && c.Role != entity.RoleAdmin {
+		return shared.Validation("Role must be one of user or admin")
+	}
+
+	return nil
+}
+
+// validateOrganizationID defaults an organization ID that was not given to
+// the default organization.
+func validateOrganizationID(organizationID *int) error {
+	if *organizationID < 0 {
+		return shared.Validation("Organization ID must be positive")
+	}
+
+	if *organizationID == 0 {
+		*organizationID = tenant.DefaultID
+	}
+
+	return nil
+}
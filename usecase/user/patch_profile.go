@@ -0,0 +1,114 @@
+package user
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+// languageTagFormat matches BCP 47 language tags such as "en" or "id-ID".
+const languageTagFormat = `^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`
+
+// PatchString is a JSON merge patch member. Set is false when the member was
+// not in the patch, and Value is nil when it was null.
+type PatchString struct {
+	Set   bool
+	Value *string
+}
+
+func (p *PatchString) UnmarshalJSON(data []byte) error {
+	p.Set = true
+	if string(data) == `null` {
+		p.Value = nil
+		return nil
+	}
+
+	return json.Unmarshal(data, &p.Value)
+}
+
+// Removed reports whether the patch sets the member to null.
+func (p PatchString) Removed() bool {
+	return p.Set && p.Value == nil
+}
+
+// PatchProfileRequest is a JSON merge patch (RFC 7396) of the profile.
+// Members left out of the patch are unchanged and null members are removed.
+type PatchProfileRequest struct {
+	PhoneNumber       PatchString `json:"phone_number"`
+	FullName          PatchString `json:"full_name"`
+	DateOfBirth       PatchString `json:"date_of_birth"`
+	Gender            PatchString `json:"gender"`
+	Address           PatchString `json:"address"`
+	PreferredLanguage PatchString `json:"preferred_language"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	// Version is the profile version from the If-Match header, 0 when the
+	// header was not sent and shared.AnyVersion when it was "*".
+	Version int `json:"-"`
+
+	RequestMeta
+}
+
+func (c *PatchProfileRequest) Validation() error {
+
+	if c.PhoneNumber.Removed() {
+		return shared.Validation("Phone number cannot be removed")
+	}
+	if c.PhoneNumber.Set {
+		phoneNumber := *c.PhoneNumber.Value
+		if len(phoneNumber) < 10 || len(phoneNumber) > 13 {
+			return shared.Validation("Phone number length must be between 10 to 13 characters")
+		}
+
+		match, err := regexp.MatchString(shared.PhoneNumberFormat, phoneNumber)
+		if err != nil || !match {
+			return shared.Validation("Invalid phone number format")
+		}
+	}
+
+	if c.FullName.Removed() {
+		return shared.Validation("Full name cannot be removed")
+	}
+	if c.FullName.Set {
+		if len(*c.FullName.Value) < 3 || len(*c.FullName.Value) > 60 {
+			return shared.Validation("Full name length must be between 3 to 60 characters")
+		}
+	}
+
+	if c.DateOfBirth.Value != nil {
+		dateOfBirth, err := time.Parse(entity.DateOfBirthFormat, *c.DateOfBirth.Value)
+		if err != nil {
+			return shared.Validation("Date of birth must be a date in YYYY-MM-DD format")
+		}
+		if dateOfBirth.Year() < 1900 || dateOfBirth.After(time.Now()) {
+			return shared.Validation("Date of birth must be between 1900-01-01 and today")
+		}
+	}
+
+	if c.Gender.Value != nil {
+		switch *c.Gender.Value {
+		case entity.GenderMale, entity.GenderFemale, entity.GenderOther:
+		default:
+			return shared.Validation("Gender must be one of male, female or other")
+		}
+	}
+
+	if c.Address.Value != nil {
+		if len(*c.Address.Value) < 1 || len(*c.Address.Value) > 255 {
+			return shared.Validation("Address length must be between 1 to 255 characters")
+		}
+	}
+
+	if c.PreferredLanguage.Value != nil {
+		match, err := regexp.MatchString(languageTagFormat, *c.PreferredLanguage.Value)
+		if err != nil || !match || len(*c.PreferredLanguage.Value) > 35 {
+			return shared.Validation("Preferred language must be a BCP 47 language tag, such as en or id-ID")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/sawitpro/technical_test/shared"
+)
+
+// PhoneNumberChangeCodeLength is the number of digits of the code sent to
+// confirm a new phone number.
+const PhoneNumberChangeCodeLength = 6
+
+type RequestPhoneNumberChangeRequest struct {
+	PhoneNumber string `json:"phone_number"`
+
+	// AuthTime is taken from the access token, not from the request body.
+	AuthTime time.Time `json:"-"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	RequestMeta
+}
+
+func (c *RequestPhoneNumberChangeRequest) Validation() error {
+
+	if len(c.PhoneNumber) < 10 || len(c.PhoneNumber) > 13 {
+		return shared.Validation("Phone number length must be between 10 to 13 characters")
+	}
+
+	match, err := regexp.MatchString(shared.PhoneNumberFormat, c.PhoneNumber)
+	if err != nil || !match {
+		return shared.Validation("Invalid phone number format")
+	}
+	return nil
+}
+
+type PhoneNumberChangeResponse struct {
+	PhoneNumber string    `json:"phone_number"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type ConfirmPhoneNumberChangeRequest struct {
+	Code string `json:"code"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	RequestMeta
+}
+
+var codeFormat = regexp.MustCompile(`^[0-9]+$`)
+
+func (c *ConfirmPhoneNumberChangeRequest) Validation() error {
+
+	if len(c.Code) != PhoneNumberChangeCodeLength || !codeFormat.MatchString(c.Code) {
+		return shared.Validation("Code must be 6 digits")
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package user
+
+import "github.com/sawitpro/technical_test/shared"
+
+type ReauthenticateRequest struct {
+	Password string `json:"password"`
+}
+
+func (c *ReauthenticateRequest) Validation() error {
+
+	if c.Password == `` {
+		return shared.Validation("Password is required")
+	}
+
+	return nil
+}
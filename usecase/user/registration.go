@@ -1,17 +1,21 @@
 package user
 
 import (
-	"net/http"
 	"regexp"
 	"strings"
 
 	"github.com/sawitpro/technical_test/shared"
 )
 
+// UserRegistrationRequest registers a member of OrganizationID, the default
+// organization when it is not given.
 type UserRegistrationRequest struct {
-	PhoneNumber string `json:"phone_number"`
-	FullName    string `json:"full_name"`
-	Password    string `json:"password"`
+	PhoneNumber    string `json:"phone_number"`
+	FullName       string `json:"full_name"`
+	Password       string `json:"password"`
+	OrganizationID int    `json:"organization_id"`
+
+	RequestMeta
 }
 
 type UserRegistrationResponse struct {
@@ -19,36 +23,27 @@ type UserRegistrationResponse struct {
 }
 
 func (c *UserRegistrationRequest) Validation() error {
+	if err := validateOrganizationID(&c.OrganizationID); err != nil {
+		return err
+	}
 
 	if len(c.PhoneNumber) < 10 || len(c.PhoneNumber) > 13 {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Phone number length must be between 10 to 13 characters",
-		}
+		return shared.Validation("Phone number length must be between 10 to 13 characters")
 	}
 
 	match, err := regexp.MatchString(shared.PhoneNumberFormat, c.PhoneNumber)
 	if err != nil || !match {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Invalid phone number format",
-		}
+		return shared.Validation("Invalid phone number format")
 	}
 
 	if len(c.FullName) < 3 || len(c.FullName) > 60 {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Full name length must be between 3 to 60 characters",
-		}
+		return shared.Validation("Full name length must be between 3 to 60 characters")
 	}
 
 	c.Password = strings.TrimSpace(c.Password)
 	err = shared.CheckPasswordComplexity(c.Password)
 	if err != nil {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: err.Error(),
-		}
+		return shared.Validation(err.Error())
 	}
 
 	return nil
@@ -0,0 +1,12 @@
+package user
+
+// RequestMeta describes the request making a change or login attempt, for the
+// audit log and login history. The handler fills it from the access token and
+// the connection, never from the request body.
+type RequestMeta struct {
+	// Role is the role in the access token, empty for anonymous requests.
+	Role      string `json:"-"`
+	IPAddress string `json:"-"`
+	RequestID string `json:"-"`
+	UserAgent string `json:"-"`
+}
@@ -0,0 +1,69 @@
+package user
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/search"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchUsersRequest struct {
+	// Q is part of a name, possibly misspelled, or digits of a phone number.
+	Q      string `query:"q"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+type SearchUsersResponse struct {
+	Users []UserSearchResult `json:"users"`
+}
+
+type UserSearchResult struct {
+	ID          int     `json:"id"`
+	FullName    string  `json:"full_name"`
+	PhoneNumber string  `json:"phone_number"`
+	Score       float64 `json:"score"`
+}
+
+func (c *SearchUsersRequest) Validation() error {
+
+	c.Q = strings.TrimSpace(c.Q)
+	if utf8.RuneCountInString(c.Q) < 2 || utf8.RuneCountInString(c.Q) > 100 {
+		return shared.Validation("Search query length must be between 2 to 100 characters")
+	}
+
+	if search.NewQuery(c.Q).Empty() {
+		return shared.Validation("Search query must contain letters or digits")
+	}
+
+	if c.Limit < 0 || c.Limit > maxSearchLimit {
+		return shared.Validation("Limit must be between 1 to 100")
+	}
+
+	if c.Offset < 0 {
+		return shared.Validation("Offset must not be negative")
+	}
+
+	return nil
+}
+
+// Filter converts a validated request to a repository search.
+func (c *SearchUsersRequest) Filter() entity.UserSearch {
+	filter := entity.UserSearch{
+		Query:  c.Q,
+		Limit:  c.Limit,
+		Offset: c.Offset,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultSearchLimit
+	}
+
+	return filter
+}
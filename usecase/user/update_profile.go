@@ -1,7 +1,6 @@
 package user
 
 import (
-	"net/http"
 	"regexp"
 
 	"github.com/sawitpro/technical_test/shared"
@@ -10,33 +9,33 @@ import (
 type UpdateProfileRequest struct {
 	PhoneNumber string `json:"phone_number"`
 	FullName    string `json:"full_name"`
+
+	// ActorID is set when an admin is impersonating the user.
+	ActorID int `json:"-"`
+
+	// Version is the profile version from the If-Match header, 0 when the
+	// header was not sent and shared.AnyVersion when it was "*".
+	Version int `json:"-"`
+
+	RequestMeta
 }
 
 func (c *UpdateProfileRequest) Validation() error {
 
 	if c.PhoneNumber != `` {
 		if len(c.PhoneNumber) < 10 || len(c.PhoneNumber) > 13 {
-			return &shared.ErrorMessage{
-				ErrorCode:    http.StatusBadRequest,
-				ErrorMessage: "Phone number length must be between 10 to 13 characters",
-			}
+			return shared.Validation("Phone number length must be between 10 to 13 characters")
 		}
 
 		match, err := regexp.MatchString(shared.PhoneNumberFormat, c.PhoneNumber)
 		if err != nil || !match {
-			return &shared.ErrorMessage{
-				ErrorCode:    http.StatusBadRequest,
-				ErrorMessage: "Invalid phone number format",
-			}
+			return shared.Validation("Invalid phone number format")
 		}
 	}
 
 	if c.FullName != `` {
 		if len(c.FullName) < 3 || len(c.FullName) > 60 {
-			return &shared.ErrorMessage{
-				ErrorCode:    http.StatusBadRequest,
-				ErrorMessage: "Full name length must be between 3 to 60 characters",
-			}
+			return shared.Validation("Full name length must be between 3 to 60 characters")
 		}
 	}
 	return nil
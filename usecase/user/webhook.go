@@ -0,0 +1,128 @@
+package user
+
+import (
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+const (
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 200
+)
+
+// webhookEventTypes are the domain events a webhook can subscribe to.
+var webhookEventTypes = map[string]bool{
+	entity.EventUserRegistered:  true,
+	entity.EventProfileUpdated:  true,
+	entity.EventUserLoggedIn:    true,
+	entity.EventPasswordChanged: true,
+}
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+	// Secret signs the requests. A random one is generated when empty.
+	Secret string `json:"secret"`
+}
+
+type WebhookResponse struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CreateWebhookResponse is the only response that includes the secret.
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type ListWebhooksResponse struct {
+	Webhooks []WebhookResponse `json:"webhooks"`
+}
+
+type ListWebhookDeliveriesRequest struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+type ListWebhookDeliveriesResponse struct {
+	Deliveries []WebhookDeliveryResponse `json:"deliveries"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int    `json:"id"`
+	SubscriptionID int    `json:"subscription_id"`
+	EventID        int    `json:"event_id"`
+	EventType      string `json:"event_type"`
+	Status         string `json:"status"`
+	AttemptCount   int    `json:"attempt_count"`
+	// NextAttemptAt is only set while the delivery is pending.
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+type WebhookDeliveryDetailResponse struct {
+	WebhookDeliveryResponse
+	Payload  json.RawMessage          `json:"payload"`
+	Attempts []WebhookAttemptResponse `json:"attempts"`
+}
+
+type WebhookAttemptResponse struct {
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error"`
+	DurationMS int       `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (c *CreateWebhookRequest) Validation() error {
+
+	target, err := url.Parse(c.URL)
+	if err != nil || (target.Scheme != `http` && target.Scheme != `https`) || target.Host == `` || len(c.URL) > 2048 {
+		return shared.Validation("Url must be an absolute http or https URL")
+	}
+
+	if len(c.EventTypes) == 0 {
+		return shared.Validation("Event types is required")
+	}
+	for _, eventType := range c.EventTypes {
+		if !webhookEventTypes[eventType] {
+			return shared.Validation("Event types must be UserRegistered, ProfileUpdated, UserLoggedIn or PasswordChanged")
+		}
+	}
+
+	if c.Secret != `` && (len(c.Secret) < 16 || len(c.Secret) > 255) {
+		return shared.Validation("Secret must be between 16 to 255 characters")
+	}
+
+	return nil
+}
+
+func (c *ListWebhookDeliveriesRequest) Validation() error {
+
+	if c.Limit < 0 || c.Limit > maxDeliveryLimit {
+		return shared.Validation("Limit must be between 1 to 200")
+	}
+
+	if c.Offset < 0 {
+		return shared.Validation("Offset must not be negative")
+	}
+
+	return nil
+}
+
+// PageSize returns the requested limit, or the default when none was given.
+func (c *ListWebhookDeliveriesRequest) PageSize() int {
+	if c.Limit == 0 {
+		return defaultDeliveryLimit
+	}
+	return c.Limit
+}
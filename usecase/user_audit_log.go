@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) ListAuditLogs(ctx context.Context, form *user.ListAuditLogsRequest) (*user.ListAuditLogsResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	auditLogs, err := u.repo.ListAuditLogs(ctx, form.Filter())
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.ListAuditLogsResponse{
+		AuditLogs: make([]user.AuditLogResponse, 0, len(auditLogs)),
+	}
+	for _, auditLog := range auditLogs {
+		res.AuditLogs = append(res.AuditLogs, user.AuditLogResponse{
+			ID:        auditLog.ID,
+			ActorID:   auditLog.ActorID,
+			ActorRole: auditLog.ActorRole,
+			Action:    auditLog.Action,
+			UserID:    auditLog.UserID,
+			Changes:   json.RawMessage(auditLog.Changes),
+			IPAddress: auditLog.IPAddress,
+			RequestID: auditLog.RequestID,
+			CreatedAt: auditLog.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// recordChange writes the audit log entry for a change to userID, in the
+// transaction carried by ctx. actorID is the admin impersonating the user,
+// 0 when the user made the change themselves.
+func (u *userUsecaseCtx) recordChange(ctx context.Context, meta user.RequestMeta, actorID int, userID int, action string, changes map[string]entity.AuditChange, at time.Time) error {
+	err := u.repo.CreateAuditLog(ctx, newAuditLog(meta, actorID, userID, action, changes, at))
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	return nil
+}
+
+func newAuditLog(meta user.RequestMeta, actorID int, userID int, action string, changes map[string]entity.AuditChange, at time.Time) *entity.AuditLog {
+	auditLog := &entity.AuditLog{
+		ActorID:   userID,
+		ActorRole: meta.Role,
+		Action:    action,
+		UserID:    userID,
+		IPAddress: meta.IPAddress,
+		RequestID: meta.RequestID,
+		CreatedAt: at,
+	}
+	if actorID != 0 {
+		auditLog.ActorID = actorID
+		auditLog.ActorRole = entity.RoleAdmin
+	}
+	if auditLog.ActorRole == `` {
+		auditLog.ActorRole = entity.RoleUser
+	}
+
+	if changes == nil {
+		changes = map[string]entity.AuditChange{}
+	}
+	// A map of strings always marshals.
+	value, _ := json.Marshal(changes)
+	auditLog.Changes = string(value)
+
+	return auditLog
+}
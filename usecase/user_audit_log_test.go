@@ -0,0 +1,233 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUsecaseCtx_ListAuditLogs(t *testing.T) {
+	type args struct {
+		form *user.ListAuditLogsRequest
+	}
+
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.ListAuditLogsResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestListAuditLogs-LimitInvalid`,
+			args: args{
+				form: &user.ListAuditLogsRequest{
+					Limit: 201,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Limit must be between 1 to 200",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestListAuditLogs-FromInvalid`,
+			args: args{
+				form: &user.ListAuditLogsRequest{
+					From: `2024-01-01`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "From and to must be RFC 3339 timestamps",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestListAuditLogs-ListError`,
+			args: args{
+				form: &user.ListAuditLogsRequest{},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`ListAuditLogs`, context.Background(), entity.AuditLogFilter{Limit: 50}).Return(nil, errors.New(`error`)).Once()
+
+				return uc
+			},
+		},
+		{
+			name: `TestListAuditLogs-Success`,
+			args: args{
+				form: &user.ListAuditLogsRequest{
+					UserID: 1,
+					Action: entity.AuditActionProfileUpdated,
+					From:   `2024-01-01T00:00:00Z`,
+					To:     `2024-01-02T00:00:00Z`,
+					Limit:  10,
+					Offset: 20,
+				},
+			},
+			want: &user.ListAuditLogsResponse{
+				AuditLogs: []user.AuditLogResponse{
+					{
+						ID:        3,
+						ActorID:   2,
+						ActorRole: entity.RoleAdmin,
+						Action:    entity.AuditActionProfileUpdated,
+						UserID:    1,
+						Changes:   json.RawMessage(`{"full_name":{"old":"a","new":"b"}}`),
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+						CreatedAt: createdAt,
+					},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				filter := entity.AuditLogFilter{
+					UserID: 1,
+					Action: entity.AuditActionProfileUpdated,
+					From:   shared.UTC7(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+					To:     shared.UTC7(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)),
+					Limit:  10,
+					Offset: 20,
+				}
+				mockRepo.On(`ListAuditLogs`, context.Background(), filter).Return([]entity.AuditLog{
+					{
+						ID:        3,
+						ActorID:   2,
+						ActorRole: entity.RoleAdmin,
+						Action:    entity.AuditActionProfileUpdated,
+						UserID:    1,
+						Changes:   `{"full_name":{"old":"a","new":"b"}}`,
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+						CreatedAt: createdAt,
+					},
+				}, nil).Once()
+
+				return uc
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.ListAuditLogs(context.Background(), tt.args.form)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.ListAuditLogs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.ListAuditLogs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newAuditLog(t *testing.T) {
+	at := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	meta := user.RequestMeta{
+		Role:      entity.RoleUser,
+		IPAddress: `127.0.0.1`,
+		RequestID: `request-1`,
+	}
+
+	tests := []struct {
+		name    string
+		meta    user.RequestMeta
+		actorID int
+		changes map[string]entity.AuditChange
+		want    *entity.AuditLog
+	}{
+		{
+			name:    `TestNewAuditLog-User`,
+			meta:    meta,
+			actorID: 0,
+			changes: map[string]entity.AuditChange{`full_name`: {Old: `a`, New: `b`}},
+			want: &entity.AuditLog{
+				ActorID:   1,
+				ActorRole: entity.RoleUser,
+				Action:    entity.AuditActionProfileUpdated,
+				UserID:    1,
+				Changes:   `{"full_name":{"old":"a","new":"b"}}`,
+				IPAddress: `127.0.0.1`,
+				RequestID: `request-1`,
+				CreatedAt: at,
+			},
+		},
+		{
+			name:    `TestNewAuditLog-Impersonating`,
+			meta:    meta,
+			actorID: 2,
+			changes: nil,
+			want: &entity.AuditLog{
+				ActorID:   2,
+				ActorRole: entity.RoleAdmin,
+				Action:    entity.AuditActionProfileUpdated,
+				UserID:    1,
+				Changes:   `{}`,
+				IPAddress: `127.0.0.1`,
+				RequestID: `request-1`,
+				CreatedAt: at,
+			},
+		},
+		{
+			name:    `TestNewAuditLog-Anonymous`,
+			meta:    user.RequestMeta{},
+			actorID: 0,
+			changes: nil,
+			want: &entity.AuditLog{
+				ActorID:   1,
+				ActorRole: entity.RoleUser,
+				Action:    entity.AuditActionProfileUpdated,
+				UserID:    1,
+				Changes:   `{}`,
+				CreatedAt: at,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAuditLog(tt.meta, tt.actorID, 1, entity.AuditActionProfileUpdated, tt.changes, at)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
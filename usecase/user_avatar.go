@@ -0,0 +1,137 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/sawitpro/technical_test/avatar"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+// UploadAvatar stores the variants under a new key before switching the
+// profile to it, so the previous avatar stays served until the update has
+// committed, and is deleted only afterwards.
+func (u *userUsecaseCtx) UploadAvatar(ctx context.Context, form *user.UploadAvatarRequest, userID int) (*user.AvatarResponse, error) {
+	data, err := io.ReadAll(io.LimitReader(form.Body, int64(u.cfg.AvatarMaxSize)+1))
+	if err != nil {
+		return nil, shared.Validation("Avatar could not be read")
+	}
+	if len(data) == 0 {
+		return nil, shared.Validation("Avatar is required")
+	}
+	if len(data) > u.cfg.AvatarMaxSize {
+		return nil, &shared.ErrorMessage{
+			ErrorCode:    http.StatusRequestEntityTooLarge,
+			ErrorMessage: fmt.Sprintf("Avatar must not be larger than %d bytes", u.cfg.AvatarMaxSize),
+		}
+	}
+
+	variants, err := avatar.Process(data)
+	if err != nil {
+		return nil, avatarError(err)
+	}
+
+	token, err := newAvatarToken()
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	key := fmt.Sprintf(`avatars/%d/%s%s`, userID, token, variants[0].Extension)
+	for _, variant := range variants {
+		err = u.storage.Put(ctx, avatar.VariantKey(key, variant.Name), variant.Data, variant.ContentType)
+		if err != nil {
+			log.Printf(`Store avatar error %s`, err.Error())
+			u.deleteAvatar(ctx, key)
+			return nil, shared.Internal(err)
+		}
+	}
+
+	var oldKey *string
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		oldKey = existsUser.AvatarKey
+		change := entity.AuditChange{New: key}
+		if oldKey != nil {
+			change.Old = *oldKey
+		}
+		changes := map[string]entity.AuditChange{`avatar`: change}
+
+		timeNow := shared.UTC7(u.repo.Now())
+		existsUser.AvatarKey = &key
+		existsUser.UpdatedAt = &timeNow
+		return u.saveProfile(ctx, form.RequestMeta, form.ActorID, userID, existsUser, changes, timeNow)
+	})
+	if err != nil {
+		u.deleteAvatar(ctx, key)
+		return nil, transactionError(err)
+	}
+
+	if oldKey != nil {
+		u.deleteAvatar(ctx, *oldKey)
+	}
+
+	return u.avatarResponse(key), nil
+}
+
+func (u *userUsecaseCtx) avatarResponse(key string) *user.AvatarResponse {
+	return &user.AvatarResponse{
+		Small:  u.storage.URL(avatar.VariantKey(key, avatar.Small)),
+		Medium: u.storage.URL(avatar.VariantKey(key, avatar.Medium)),
+		Large:  u.storage.URL(avatar.VariantKey(key, avatar.Large)),
+	}
+}
+
+// deleteAvatar removes every variant of the avatar at key. A failure only
+// leaves unreferenced files behind, so it is logged rather than returned.
+func (u *userUsecaseCtx) deleteAvatar(ctx context.Context, key string) {
+	for _, size := range avatar.Sizes {
+		if err := u.storage.Delete(ctx, avatar.VariantKey(key, size.Name)); err != nil {
+			log.Printf(`Delete avatar error %s`, err.Error())
+		}
+	}
+}
+
+func avatarError(err error) error {
+	switch {
+	case errors.Is(err, avatar.ErrUnsupportedType):
+		return &shared.ErrorMessage{
+			ErrorCode:    http.StatusUnsupportedMediaType,
+			ErrorMessage: "Avatar must be a JPEG, PNG or WebP image",
+		}
+	case errors.Is(err, avatar.ErrTooLarge):
+		return &shared.ErrorMessage{
+			ErrorCode:    http.StatusBadRequest,
+			ErrorMessage: fmt.Sprintf("Avatar must not be larger than %dx%d pixels", avatar.MaxDimension, avatar.MaxDimension),
+		}
+	case errors.Is(err, avatar.ErrInvalidImage):
+		return shared.Validation("Avatar is not a valid image")
+	}
+
+	return shared.Internal(err)
+}
+
+// newAvatarToken returns 16 random bytes, hex encoded, so that every upload
+// gets new URLs that caches have not seen.
+func newAvatarToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(token), nil
+}
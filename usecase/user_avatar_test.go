@@ -0,0 +1,181 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/blob"
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_UploadAvatar(t *testing.T) {
+	var photo bytes.Buffer
+	if err := png.Encode(&photo, image.NewGray(image.Rect(0, 0, 100, 80))); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		AvatarMaxSize: 1 << 20,
+	}
+
+	// files lists the stored avatar variants.
+	files := func(dir string) []string {
+		paths, _ := filepath.Glob(filepath.Join(dir, `avatars`, `1`, `*`))
+		for i, path := range paths {
+			paths[i] = filepath.Base(path)
+		}
+		return paths
+	}
+
+	tests := []struct {
+		name      string
+		body      []byte
+		wantErr   bool
+		err       error
+		wantFiles int
+		before    func(storage blob.Storage) *userUsecaseCtx
+	}{
+		{
+			name:    `TestUploadAvatar-Empty`,
+			body:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Avatar is required",
+			},
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				return &userUsecaseCtx{cfg: cfg, storage: storage}
+			},
+		},
+		{
+			name:    `TestUploadAvatar-TooLarge`,
+			body:    make([]byte, cfg.AvatarMaxSize+1),
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusRequestEntityTooLarge,
+				ErrorMessage: "Avatar must not be larger than 1048576 bytes",
+			},
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				return &userUsecaseCtx{cfg: cfg, storage: storage}
+			},
+		},
+		{
+			name:    `TestUploadAvatar-UnsupportedType`,
+			body:    []byte(`GIF89a`),
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusUnsupportedMediaType,
+				ErrorMessage: "Avatar must be a JPEG, PNG or WebP image",
+			},
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				return &userUsecaseCtx{cfg: cfg, storage: storage}
+			},
+		},
+		{
+			name:    `TestUploadAvatar-UserNotExists`,
+			body:    photo.Bytes(),
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This user does not exists",
+			},
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
+
+				return &userUsecaseCtx{cfg: cfg, repo: mockRepo, storage: storage}
+			},
+		},
+		{
+			name:    `TestUploadAvatar-UpdateError`,
+			body:    photo.Bytes(),
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1}, nil).Once()
+				mockRepo.On(`Now`).Return(time.Now())
+				mockRepo.On(`UpdateProfile`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
+
+				return &userUsecaseCtx{cfg: cfg, repo: mockRepo, storage: storage}
+			},
+		},
+		{
+			name:      `TestUploadAvatar-ReplacesPrevious`,
+			body:      photo.Bytes(),
+			wantErr:   false,
+			err:       nil,
+			wantFiles: 3,
+			before: func(storage blob.Storage) *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockTransaction(mockRepo)
+
+				oldKey := `avatars/1/old.jpg`
+				for _, name := range []string{`small`, `medium`, `large`} {
+					_ = storage.Put(context.Background(), `avatars/1/old_`+name+`.jpg`, []byte(`old`), `image/jpeg`)
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1, AvatarKey: &oldKey}, nil).Once()
+				mockRepo.On(`Now`).Return(time.Now())
+				mockRepo.On(`UpdateProfile`, mock.Anything, mock.MatchedBy(func(u *entity.User) bool {
+					return u.AvatarKey != nil && *u.AvatarKey != oldKey
+				})).Return(nil).Once()
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.MatchedBy(func(a *entity.AuditLog) bool {
+					return bytes.Contains([]byte(a.Changes), []byte(`"avatar":{"old":"avatars/1/old.jpg","new":"avatars/1/`))
+				})).Return(nil).Once()
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, mock.Anything).Return(nil).Once()
+
+				return &userUsecaseCtx{cfg: cfg, repo: mockRepo, storage: storage}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			storage, err := blob.NewLocalStorage(dir, `/blobs`)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			u := tt.before(storage)
+			got, err := u.UploadAvatar(context.Background(), &user.UploadAvatarRequest{Body: bytes.NewReader(tt.body)}, 1)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.UploadAvatar() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			stored := files(dir)
+			assert.Len(t, stored, tt.wantFiles, `%v`, stored)
+			if tt.wantErr {
+				return
+			}
+
+			for _, url := range []string{got.Small, got.Medium, got.Large} {
+				_, err := os.Stat(filepath.Join(dir, filepath.FromSlash(url[len(`/blobs/`):])))
+				assert.NoError(t, err, url)
+			}
+		})
+	}
+}
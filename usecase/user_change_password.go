@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) ChangePassword(ctx context.Context, form *user.ChangePasswordRequest, userID int) error {
+	var err error
+	if err = form.Validation(); err != nil {
+		return err
+	}
+
+	if err = rejectImpersonation(form.ActorID); err != nil {
+		return err
+	}
+
+	if err = u.requireRecentAuthentication(form.AuthTime); err != nil {
+		return err
+	}
+
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		accountSalt := u.repo.RandomString(12)
+		timeNow := shared.UTC7(u.repo.Now())
+		existsUser.Password = shared.MD5(form.Password + accountSalt)
+		existsUser.AccountSalt = accountSalt
+		existsUser.UpdatedAt = &timeNow
+		err = u.repo.UpdatePassword(ctx, existsUser)
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		changes := map[string]entity.AuditChange{
+			`password`: {Old: entity.AuditRedacted, New: entity.AuditRedacted},
+		}
+		err = u.recordChange(ctx, form.RequestMeta, form.ActorID, userID, entity.AuditActionPasswordChanged, changes, timeNow)
+		if err != nil {
+			return err
+		}
+
+		event := entity.PasswordChangedEvent{UserID: userID}
+		return u.emitEvent(ctx, entity.EventPasswordChanged, userID, event, timeNow)
+	})
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,288 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_ChangePassword(t *testing.T) {
+	type args struct {
+		form   *user.ChangePasswordRequest
+		userID int
+	}
+
+	cfg := &config.Config{
+		StepUpWindow: 5 * time.Minute,
+	}
+	timeNow := time.Now()
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: "TestChangePassword-PasswordInvalid",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `password`,
+					AuthTime: timeNow,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "password must contains at least 1 uppercase, 1 number, and 1 special characters",
+			},
+			before: func() *userUsecaseCtx {
+				u := &userUsecaseCtx{}
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-Impersonating",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow,
+					ActorID:  2,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusForbidden,
+				ErrorMessage: "This action is not allowed while impersonating",
+			},
+			before: func() *userUsecaseCtx {
+				u := &userUsecaseCtx{}
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-RecentAuthenticationRequired",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow.Add(-10 * time.Minute),
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusUnauthorized,
+				ErrorMessage: "Recent authentication is required",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-GetUserError",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-UpdateError",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1}, nil).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				mockUserData := &entity.User{
+					ID:          1,
+					Password:    shared.MD5(`Password123!` + `123456789ABC`),
+					AccountSalt: `123456789ABC`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdatePassword`, mock.Anything, mockUserData).Return(errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-AuditLogError",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1}, nil).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				mockUserData := &entity.User{
+					ID:          1,
+					Password:    shared.MD5(`Password123!` + `123456789ABC`),
+					AccountSalt: `123456789ABC`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdatePassword`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestChangePassword-Success",
+			args: args{
+				form: &user.ChangePasswordRequest{
+					Password: `Password123!`,
+					AuthTime: timeNow,
+					RequestMeta: user.RequestMeta{
+						Role:      entity.RoleUser,
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+					},
+				},
+				userID: 1,
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1}, nil).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				mockUserData := &entity.User{
+					ID:          1,
+					Password:    shared.MD5(`Password123!` + `123456789ABC`),
+					AccountSalt: `123456789ABC`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdatePassword`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, &entity.AuditLog{
+					ActorID:   1,
+					ActorRole: entity.RoleUser,
+					Action:    entity.AuditActionPasswordChanged,
+					UserID:    1,
+					Changes:   `{"password":{"old":"[redacted]","new":"[redacted]"}}`,
+					IPAddress: `127.0.0.1`,
+					RequestID: `request-1`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, &entity.OutboxEvent{
+					Type:      entity.EventPasswordChanged,
+					UserID:    1,
+					Payload:   `{"user_id":1}`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				return u
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			err := u.ChangePassword(context.Background(), tt.args.form, tt.args.userID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
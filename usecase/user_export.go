@@ -0,0 +1,209 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+// exportPageSize is the number of users read from the database at a time.
+const exportPageSize = 500
+
+// ExportUsers writes the users to w a page at a time, so only one page is
+// held in memory. The pages are separate queries: users created during the
+// export are included when their ids come after the current page.
+//
+// An invalid request is reported before anything is written to w; any
+// other error may leave a partial export behind.
+func (u *userUsecaseCtx) ExportUsers(ctx context.Context, form *user.ExportUsersRequest, w io.Writer) error {
+	var err error
+	if err = form.Validation(); err != nil {
+		return err
+	}
+
+	fields, err := form.FieldList()
+	if err != nil {
+		return err
+	}
+
+	out := newExportWriter(form.Format, w, fields)
+	filter := form.Filter()
+	filter.Limit = exportPageSize
+	for {
+		users, err := u.repo.ListUsers(ctx, filter)
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		for i := range users {
+			if err := out.Write(&users[i]); err != nil {
+				return err
+			}
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+		if len(users) < exportPageSize {
+			return nil
+		}
+		filter.AfterID = users[len(users)-1].ID
+	}
+}
+
+// exportWriter encodes users in an export format. Flush must be called
+// after the last user.
+type exportWriter interface {
+	Write(user *entity.User) error
+	Flush() error
+}
+
+func newExportWriter(format string, w io.Writer, fields []string) exportWriter {
+	if format == user.ExportFormatJSONL {
+		return &jsonlExportWriter{w: bufio.NewWriter(w), fields: fields}
+	}
+
+	return &csvExportWriter{w: csv.NewWriter(w), fields: fields}
+}
+
+// csvExportWriter starts with a header row naming the fields. Missing values
+// are empty.
+type csvExportWriter struct {
+	w       *csv.Writer
+	fields  []string
+	started bool
+	record  []string
+}
+
+func (e *csvExportWriter) Write(user *entity.User) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.record = e.record[:0]
+	for _, field := range e.fields {
+		e.record = append(e.record, csvExportValue(exportValue(user, field)))
+	}
+
+	return e.w.Write(e.record)
+}
+
+// Flush writes the header when no user was written, so an empty export is
+// still a valid CSV file.
+func (e *csvExportWriter) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) writeHeader() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+	return e.w.Write(e.fields)
+}
+
+// jsonlExportWriter writes an object per line, with the fields in the
+// requested order. Missing values are null.
+type jsonlExportWriter struct {
+	w      *bufio.Writer
+	fields []string
+}
+
+func (e *jsonlExportWriter) Write(user *entity.User) error {
+	e.w.WriteByte('{')
+	for i, field := range e.fields {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+
+		value, err := json.Marshal(exportValue(user, field))
+		if err != nil {
+			return err
+		}
+		e.w.WriteString(strconv.Quote(field))
+		e.w.WriteByte(':')
+		e.w.Write(value)
+	}
+	_, err := e.w.WriteString("}\n")
+
+	return err
+}
+
+func (e *jsonlExportWriter) Flush() error {
+	return e.w.Flush()
+}
+
+// exportValue returns the value of one of the user.ExportFields.
+func exportValue(u *entity.User, field string) interface{} {
+	switch field {
+	case `id`:
+		return u.ID
+	case `full_name`:
+		return u.FullName
+	case `phone_number`:
+		return u.PhoneNumber
+	case `role`:
+		return u.Role
+	case `date_of_birth`:
+		return u.DateOfBirth
+	case `gender`:
+		return u.Gender
+	case `address`:
+		return u.Address
+	case `preferred_language`:
+		return u.PreferredLanguage
+	case `successful_login`:
+		return u.SuccessfulLogin
+	case `last_login_at`:
+		return u.LastLoginAt
+	case `failed_login_count`:
+		return u.FailedLoginCount
+	case `last_failed_login_at`:
+		return u.LastFailedLoginAt
+	case `version`:
+		return u.Version
+	case `created_at`:
+		return u.CreatedAt
+	case `updated_at`:
+		return u.UpdatedAt
+	}
+
+	return nil
+}
+
+// csvExportValue formats times like encoding/json does, so both formats
+// agree.
+func csvExportValue(value interface{}) string {
+	switch value := value.(type) {
+	case int:
+		return strconv.Itoa(value)
+	case string:
+		return value
+	case *string:
+		if value != nil {
+			return *value
+		}
+	case time.Time:
+		return value.Format(time.RFC3339Nano)
+	case *time.Time:
+		if value != nil {
+			return value.Format(time.RFC3339Nano)
+		}
+	}
+
+	return ``
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUsecaseCtx_ExportUsers(t *testing.T) {
+	ctx := context.Background()
+
+	createdAt := shared.UTC7(time.Date(2024, 3, 1, 3, 0, 0, 0, time.UTC))
+	gender := entity.GenderFemale
+
+	// users returns a repository with two users, the second one created a
+	// day after the first.
+	users := func(t *testing.T) repository.Repository {
+		repo := repository.NewMemoryRepository()
+		for i, phoneNumber := range []string{`+628123456781`, `+628123456782`} {
+			at := createdAt.AddDate(0, 0, i)
+			err := repo.Create(ctx, &entity.User{
+				FullName:    fmt.Sprintf(`User, %d`, i+1),
+				PhoneNumber: phoneNumber,
+				Password:    `hashed`,
+				AccountSalt: `salt`,
+				Gender:      &gender,
+				CreatedAt:   at,
+				UpdatedAt:   &at,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return repo
+	}
+
+	tests := []struct {
+		name    string
+		form    *user.ExportUsersRequest
+		want    string
+		wantErr bool
+		err     error
+	}{
+		{
+			name: `TestExportUsers-CSVFields`,
+			form: &user.ExportUsersRequest{
+				Fields: `phone_number, full_name,address,created_at`,
+			},
+			want: "phone_number,full_name,address,created_at\n" +
+				"+628123456781,\"User, 1\",,2024-03-01T10:00:00+07:00\n" +
+				"+628123456782,\"User, 2\",,2024-03-02T10:00:00+07:00\n",
+		},
+		{
+			name: `TestExportUsers-JSONLCreatedFrom`,
+			form: &user.ExportUsersRequest{
+				Format:      user.ExportFormatJSONL,
+				Fields:      `id,gender,address,updated_at`,
+				CreatedFrom: `2024-03-02T00:00:00+07:00`,
+			},
+			want: `{"id":2,"gender":"female","address":null,"updated_at":"2024-03-02T10:00:00+07:00"}` + "\n",
+		},
+		{
+			name: `TestExportUsers-EmptyCSV`,
+			form: &user.ExportUsersRequest{
+				Fields:    `id`,
+				UpdatedTo: `2024-01-01T00:00:00Z`,
+			},
+			want: "id\n",
+		},
+		{
+			name: `TestExportUsers-Password`,
+			form: &user.ExportUsersRequest{
+				Fields: `id,password`,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: `Field "password" cannot be exported`,
+			},
+		},
+		{
+			name: `TestExportUsers-InvalidTime`,
+			form: &user.ExportUsersRequest{
+				UpdatedFrom: `yesterday`,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Created and updated times must be RFC 3339 timestamps",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &userUsecaseCtx{repo: users(t)}
+
+			var out bytes.Buffer
+			err := u.ExportUsers(ctx, tt.form, &out)
+			if tt.wantErr {
+				assert.Equal(t, tt.err, err)
+				assert.Empty(t, out.String())
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func Test_userUsecaseCtx_ExportUsersPages(t *testing.T) {
+	ctx := context.Background()
+
+	repo := repository.NewMemoryRepository()
+	for i := 0; i < exportPageSize+1; i++ {
+		err := repo.Create(ctx, &entity.User{
+			FullName:    `User`,
+			PhoneNumber: fmt.Sprintf(`+62812%07d`, i),
+			Password:    `hashed`,
+			AccountSalt: `salt`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	u := &userUsecaseCtx{repo: repo}
+	var out bytes.Buffer
+	err := u.ExportUsers(ctx, &user.ExportUsersRequest{}, &out)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Len(t, lines, exportPageSize+2)
+	assert.Equal(t, strings.Join(user.ExportFields, `,`), lines[0])
+	assert.NotContains(t, out.String(), `hashed`)
+	assert.NotContains(t, out.String(), `salt`)
+}
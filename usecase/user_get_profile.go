@@ -2,8 +2,9 @@ package usecase
 
 import (
 	"context"
-	"net/http"
+	"errors"
 
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
 )
@@ -15,22 +16,24 @@ func (u *userUsecaseCtx) GetUserProfile(ctx context.Context, userID int) (*user.
 	)
 
 	existsUser, err := u.repo.GetUserByID(ctx, userID)
-	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
-		}
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUserNotFound
 	}
-	if existsUser == nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusForbidden,
-			ErrorMessage: "This user does not exists",
-		}
+	if err != nil {
+		return nil, shared.Internal(err)
 	}
 
 	res = &user.GetUserProfileResponse{
-		FullName:    existsUser.FullName,
-		PhoneNumber: existsUser.PhoneNumber,
+		FullName:          existsUser.FullName,
+		PhoneNumber:       existsUser.PhoneNumber,
+		DateOfBirth:       existsUser.DateOfBirth,
+		Gender:            existsUser.Gender,
+		Address:           existsUser.Address,
+		PreferredLanguage: existsUser.PreferredLanguage,
+		Version:           existsUser.Version,
+	}
+	if existsUser.AvatarKey != nil {
+		res.Avatar = u.avatarResponse(*existsUser.AvatarKey)
 	}
 
 	return res, nil
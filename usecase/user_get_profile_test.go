@@ -7,7 +7,9 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/sawitpro/technical_test/blob"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/repository/mocks"
 	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
@@ -34,10 +36,7 @@ func Test_userUsecaseCtx_GetUserProfile(t *testing.T) {
 			},
 			want:    nil,
 			wantErr: true,
-			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
-			},
+			err:     shared.Internal(errors.New(`error`)),
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
@@ -58,7 +57,7 @@ func Test_userUsecaseCtx_GetUserProfile(t *testing.T) {
 			want:    nil,
 			wantErr: true,
 			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusForbidden,
+				ErrorCode:    http.StatusNotFound,
 				ErrorMessage: "This user does not exists",
 			},
 			before: func() *userUsecaseCtx {
@@ -68,7 +67,7 @@ func Test_userUsecaseCtx_GetUserProfile(t *testing.T) {
 					repo: mockRepo,
 				}
 
-				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, nil).Once()
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
 
 				return uc
 			},
@@ -81,6 +80,7 @@ func Test_userUsecaseCtx_GetUserProfile(t *testing.T) {
 			want: &user.GetUserProfileResponse{
 				FullName:    `User123`,
 				PhoneNumber: `+62123456789`,
+				Version:     3,
 			},
 			wantErr: false,
 			err:     nil,
@@ -95,6 +95,46 @@ func Test_userUsecaseCtx_GetUserProfile(t *testing.T) {
 					ID:          1,
 					FullName:    "User123",
 					PhoneNumber: `+62123456789`,
+					Version:     3,
+				}
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(mockUserData, nil).Once()
+
+				return uc
+			},
+		},
+		{
+			name: `TestGetProfile-WithAvatar`,
+			args: args{
+				userID: 1,
+			},
+			want: &user.GetUserProfileResponse{
+				FullName:    `User123`,
+				PhoneNumber: `+62123456789`,
+				Avatar: &user.AvatarResponse{
+					Small:  `/blobs/avatars/1/abc_small.jpg`,
+					Medium: `/blobs/avatars/1/abc_medium.jpg`,
+					Large:  `/blobs/avatars/1/abc_large.jpg`,
+				},
+				Version: 3,
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+				storage, _ := blob.NewLocalStorage(t.TempDir(), `/blobs`)
+
+				uc := &userUsecaseCtx{
+					repo:    mockRepo,
+					storage: storage,
+				}
+
+				avatarKey := `avatars/1/abc.jpg`
+				mockUserData := &entity.User{
+					ID:          1,
+					FullName:    "User123",
+					PhoneNumber: `+62123456789`,
+					AvatarKey:   &avatarKey,
+					Version:     3,
 				}
 				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(mockUserData, nil).Once()
 
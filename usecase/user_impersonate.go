@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) Impersonate(ctx context.Context, form *user.ImpersonateRequest, adminID int) (*user.UserLoginResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	admin, err := u.repo.GetUserByID(ctx, adminID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, shared.Internal(err)
+	}
+	if admin == nil || admin.Role != entity.RoleAdmin {
+		return nil, shared.Forbidden("Admin access is required")
+	}
+
+	target, err := u.repo.GetUserByID(ctx, form.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+	if target.Role == entity.RoleAdmin {
+		return nil, shared.Forbidden("Admin accounts cannot be impersonated")
+	}
+
+	// No auth_time is set, so the token can never pass the step-up check.
+	claim := entity.AccessTokenClaim{
+		UserID:         target.ID,
+		PhoneNumber:    target.PhoneNumber,
+		Role:           target.Role,
+		OrganizationID: tenant.IDOrDefault(ctx),
+		Actor: &entity.ActorClaim{
+			UserID: admin.ID,
+		},
+	}
+
+	now := u.repo.Now()
+	end := now.Add(u.cfg.ImpersonationTTL)
+
+	claim.IssuedAt = jwt.NewNumericDate(now)
+	claim.ExpiresAt = jwt.NewNumericDate(end)
+
+	return u.signAccessToken(claim, end)
+}
+
+func (u *userUsecaseCtx) RecordImpersonation(ctx context.Context, audit *entity.ImpersonationAudit) error {
+	audit.CreatedAt = shared.UTC7(u.repo.Now())
+
+	err := u.repo.CreateImpersonationAudit(ctx, audit)
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	return nil
+}
+
+// rejectImpersonation blocks account takeover actions, such as changing the
+// login phone number or password, for tokens carrying an act claim.
+func rejectImpersonation(actorID int) error {
+	if actorID != 0 {
+		return shared.Forbidden("This action is not allowed while impersonating")
+	}
+
+	return nil
+}
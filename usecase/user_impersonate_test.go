@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_Impersonate(t *testing.T) {
+	type args struct {
+		form    *user.ImpersonateRequest
+		adminID int
+	}
+
+	adminData := &entity.User{
+		ID:   1,
+		Role: entity.RoleAdmin,
+	}
+	targetData := &entity.User{
+		ID:          2,
+		PhoneNumber: `+62123456789`,
+		Role:        entity.RoleUser,
+	}
+	timeNow := time.Now()
+	privateKey := mockInitPrivateKey()
+	cfg := &config.Config{
+		PrivateKey:       privateKey,
+		ImpersonationTTL: 15 * time.Minute,
+	}
+	impersonateResponse := mockCreateImpersonationToken(targetData, adminData.ID, privateKey, timeNow, cfg.ImpersonationTTL)
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.UserLoginResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestImpersonate-UserIDEmpty`,
+			args: args{
+				form:    &user.ImpersonateRequest{},
+				adminID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "User id is required",
+			},
+			before: func() *userUsecaseCtx {
+				u := &userUsecaseCtx{}
+
+				return u
+			},
+		},
+		{
+			name: `TestImpersonate-GetAdminError`,
+			args: args{
+				form: &user.ImpersonateRequest{
+					UserID: 2,
+				},
+				adminID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestImpersonate-NotAdmin`,
+			args: args{
+				form: &user.ImpersonateRequest{
+					UserID: 2,
+				},
+				adminID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusForbidden,
+				ErrorMessage: "Admin access is required",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(&entity.User{ID: 1, Role: entity.RoleUser}, nil).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestImpersonate-TargetNotExists`,
+			args: args{
+				form: &user.ImpersonateRequest{
+					UserID: 2,
+				},
+				adminID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This user does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(adminData, nil).Once()
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 2).Return(nil, repository.ErrNotFound).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestImpersonate-TargetIsAdmin`,
+			args: args{
+				form: &user.ImpersonateRequest{
+					UserID: 2,
+				},
+				adminID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusForbidden,
+				ErrorMessage: "Admin accounts cannot be impersonated",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(adminData, nil).Once()
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 2).Return(&entity.User{ID: 2, Role: entity.RoleAdmin}, nil).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestImpersonate-Success`,
+			args: args{
+				form: &user.ImpersonateRequest{
+					UserID: 2,
+				},
+				adminID: 1,
+			},
+			want:    impersonateResponse,
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(adminData, nil).Once()
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 2).Return(targetData, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				return u
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.Impersonate(context.Background(), tt.args.form, tt.args.adminID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.Impersonate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.Impersonate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userUsecaseCtx_RecordImpersonation(t *testing.T) {
+	timeNow := time.Now()
+	now := shared.UTC7(timeNow)
+
+	tests := []struct {
+		name    string
+		audit   *entity.ImpersonationAudit
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestRecordImpersonation-CreateError`,
+			audit: &entity.ImpersonationAudit{
+				ActorID: 1,
+				UserID:  2,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockRepo.On(`CreateImpersonationAudit`, mock.Anything, &entity.ImpersonationAudit{
+					ActorID:   1,
+					UserID:    2,
+					CreatedAt: now,
+				}).Return(errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestRecordImpersonation-Success`,
+			audit: &entity.ImpersonationAudit{
+				ActorID: 1,
+				UserID:  2,
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockRepo.On(`CreateImpersonationAudit`, mock.Anything, &entity.ImpersonationAudit{
+					ActorID:   1,
+					UserID:    2,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				return u
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			err := u.RecordImpersonation(context.Background(), tt.audit)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.RecordImpersonation() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func mockCreateImpersonationToken(data *entity.User, adminID int, privateKey *rsa.PrivateKey, now time.Time, ttl time.Duration) *user.UserLoginResponse {
+	claim := entity.AccessTokenClaim{
+		UserID:         data.ID,
+		PhoneNumber:    data.PhoneNumber,
+		Role:           data.Role,
+		OrganizationID: tenant.DefaultID,
+		Actor: &entity.ActorClaim{
+			UserID: adminID,
+		},
+	}
+
+	end := now.Add(ttl)
+
+	claim.IssuedAt = jwt.NewNumericDate(now)
+	claim.ExpiresAt = jwt.NewNumericDate(end)
+
+	newToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
+	tokenString, _ := newToken.SignedString(privateKey)
+
+	res := &user.UserLoginResponse{
+		UserID:         data.ID,
+		OrganizationID: claim.OrganizationID,
+		Token:          tokenString,
+		ExpiredAt:      end.Format(time.RFC3339),
+	}
+	return res
+}
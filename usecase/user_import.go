@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+// ImportUsers reads the file a row at a time, so only the current batch and
+// the phone numbers seen so far are held in memory. Each batch is created in
+// its own transaction: when reading the file fails part way, the earlier
+// batches stay imported.
+func (u *userUsecaseCtx) ImportUsers(ctx context.Context, form *user.ImportUsersRequest) (*user.ImportUsersResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	rows, err := newImportReader(form.Format, form.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &user.ImportUsersResponse{
+		DryRun: form.DryRun,
+		Errors: []user.ImportRowError{},
+	}
+
+	// firstRows remembers the row each phone number was first seen on, to
+	// reject the later duplicates in the file.
+	firstRows := make(map[string]int)
+	batch := make([]*importRow, 0, form.BatchSize)
+	for {
+		row, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, importReadError(form, res, err)
+		}
+
+		res.Total++
+		if row.err != `` {
+			res.Errors = append(res.Errors, user.ImportRowError{Row: row.line, Message: row.err})
+			continue
+		}
+
+		if err := row.form.Validation(); err != nil {
+			res.Errors = append(res.Errors, rowError(row, err))
+			continue
+		}
+
+		if firstRow, ok := firstRows[row.form.PhoneNumber]; ok {
+			res.Errors = append(res.Errors, user.ImportRowError{
+				Row:         row.line,
+				PhoneNumber: row.form.PhoneNumber,
+				Message:     fmt.Sprintf("Phone number is already used in row %d", firstRow),
+			})
+			continue
+		}
+		firstRows[row.form.PhoneNumber] = row.line
+
+		batch = append(batch, row)
+		if len(batch) < form.BatchSize {
+			continue
+		}
+		if err := u.importBatch(ctx, form, batch, res); err != nil {
+			return nil, err
+		}
+		batch = batch[:0]
+	}
+
+	if err := u.importBatch(ctx, form, batch, res); err != nil {
+		return nil, err
+	}
+
+	// Rows failing in a batch are reported after the rows read later that
+	// failed validation.
+	sort.SliceStable(res.Errors, func(i, j int) bool {
+		return res.Errors[i].Row < res.Errors[j].Row
+	})
+	res.Failed = len(res.Errors)
+
+	return res, nil
+}
+
+// importBatch creates the users of batch whose phone numbers are not taken
+// yet. When a concurrent registration takes one of them between the lookup
+// and the insert, the batch is retried a user at a time so that only that
+// row fails.
+func (u *userUsecaseCtx) importBatch(ctx context.Context, form *user.ImportUsersRequest, batch []*importRow, res *user.ImportUsersResponse) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	phoneNumbers := make([]string, 0, len(batch))
+	for _, row := range batch {
+		phoneNumbers = append(phoneNumbers, row.form.PhoneNumber)
+	}
+	takenPhoneNumbers, err := u.repo.ListTakenPhoneNumbers(ctx, phoneNumbers)
+	if err != nil {
+		return shared.Internal(err)
+	}
+	taken := make(map[string]bool, len(takenPhoneNumbers))
+	for _, phoneNumber := range takenPhoneNumbers {
+		taken[phoneNumber] = true
+	}
+
+	rows := make([]*importRow, 0, len(batch))
+	for _, row := range batch {
+		if taken[row.form.PhoneNumber] {
+			res.Errors = append(res.Errors, rowError(row, errPhoneNumberExists))
+			continue
+		}
+		rows = append(rows, row)
+	}
+
+	if form.DryRun || len(rows) == 0 {
+		res.Imported += len(rows)
+		return nil
+	}
+
+	err = u.createImportedUsers(ctx, form, rows)
+	if repository.IsConflict(err) {
+		for _, row := range rows {
+			err := u.createImportedUsers(ctx, form, []*importRow{row})
+			if repository.IsConflict(err) {
+				res.Errors = append(res.Errors, rowError(row, errPhoneNumberExists))
+				continue
+			}
+			if err != nil {
+				return transactionError(err)
+			}
+			res.Imported++
+		}
+		return nil
+	}
+	if err != nil {
+		return transactionError(err)
+	}
+
+	res.Imported += len(rows)
+	return nil
+}
+
+// createImportedUsers creates the users of rows in one transaction. A taken
+// phone number is returned as the repository's ConflictError.
+func (u *userUsecaseCtx) createImportedUsers(ctx context.Context, form *user.ImportUsersRequest, rows []*importRow) error {
+	return u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		timeNow := shared.UTC7(u.repo.Now())
+
+		users := make([]*entity.User, 0, len(rows))
+		for _, row := range rows {
+			users = append(users, u.newAccount(ctx, &row.form, timeNow))
+		}
+
+		err := u.repo.CreateUsers(ctx, users)
+		if repository.IsConflict(err) {
+			return err
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		for _, userData := range users {
+			err = u.recordRegistration(ctx, form.RequestMeta, form.ActorID, userData, timeNow)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func rowError(row *importRow, err error) user.ImportRowError {
+	rowErr := user.ImportRowError{
+		Row:         row.line,
+		PhoneNumber: row.form.PhoneNumber,
+		Message:     "Internal server error",
+	}
+	if errMessage, ok := err.(*shared.ErrorMessage); ok {
+		rowErr.Message = errMessage.ErrorMessage
+	}
+
+	return rowErr
+}
+
+// importReadError reports a file that could not be read to the end. The
+// rows of the batches created before stay imported.
+func importReadError(form *user.ImportUsersRequest, res *user.ImportUsersResponse, err error) error {
+	reason := "the file could not be read"
+	if errMessage, ok := err.(*shared.ErrorMessage); ok {
+		reason = errMessage.ErrorMessage
+	}
+
+	message := fmt.Sprintf("Import stopped after %d users were imported: %s", res.Imported, reason)
+	if form.DryRun {
+		message = fmt.Sprintf("Import stopped: %s", reason)
+	}
+
+	return shared.Validation(message)
+}
@@ -0,0 +1,151 @@
+package usecase
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+// maxImportLineSize bounds a JSONL line, far above what a valid registration
+// needs.
+const maxImportLineSize = 64 << 10
+
+// importColumns are the CSV columns read into a registration.
+var importColumns = []string{`phone_number`, `full_name`, `password`}
+
+// importRow is a row read from an import file. err explains why the row
+// could not be parsed, in which case form is empty.
+type importRow struct {
+	line int
+	form user.UserRegistrationRequest
+	err  string
+}
+
+// importReader reads an import file a row at a time. Next returns io.EOF
+// after the last row, and any other error when the rest of the file cannot
+// be read.
+type importReader interface {
+	Next() (*importRow, error)
+}
+
+func newImportReader(format string, body io.Reader) (importReader, error) {
+	if format == user.ImportFormatJSONL {
+		return newJSONLImportReader(body), nil
+	}
+
+	return newCSVImportReader(body)
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	fields  int
+	columns map[string]int
+}
+
+// newCSVImportReader reads the header, which must name every one of the
+// importColumns. Other columns are ignored.
+func newCSVImportReader(body io.Reader) (*csvImportReader, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, shared.Validation("CSV file must start with a header")
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet applications often start UTF-8 files with a byte
+		// order mark.
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range importColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, shared.Validation("CSV header must name the phone_number, full_name and password columns")
+		}
+	}
+
+	return &csvImportReader{
+		reader:  reader,
+		fields:  len(header),
+		columns: columns,
+	}, nil
+}
+
+func (r *csvImportReader) Next() (*importRow, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return &importRow{line: parseErr.StartLine, err: "Row is not valid CSV"}, nil
+		}
+		return nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	row := &importRow{line: line}
+	if len(record) != r.fields {
+		row.err = fmt.Sprintf("Row has %d fields but the header has %d", len(record), r.fields)
+		return row, nil
+	}
+
+	row.form.PhoneNumber = record[r.columns[`phone_number`]]
+	row.form.FullName = record[r.columns[`full_name`]]
+	row.form.Password = record[r.columns[`password`]]
+	return row, nil
+}
+
+type jsonlImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newJSONLImportReader(body io.Reader) *jsonlImportReader {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLineSize)
+
+	return &jsonlImportReader{scanner: scanner}
+}
+
+// Next skips blank lines.
+func (r *jsonlImportReader) Next() (*importRow, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		data := r.scanner.Bytes()
+		if r.line == 1 {
+			data = bytes.TrimPrefix(data, []byte("\ufeff"))
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		row := &importRow{line: r.line}
+		if err := json.Unmarshal(data, &row.form); err != nil {
+			row.form = user.UserRegistrationRequest{}
+			row.err = "Row is not a valid JSON object"
+		}
+		return row, nil
+	}
+
+	if errors.Is(r.scanner.Err(), bufio.ErrTooLong) {
+		return nil, shared.Validation(fmt.Sprintf("line %d is longer than %d bytes", r.line+1, maxImportLineSize))
+	}
+	if r.scanner.Err() != nil {
+		return nil, r.scanner.Err()
+	}
+
+	return nil, io.EOF
+}
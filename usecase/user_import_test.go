@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_ImportUsers(t *testing.T) {
+	ctx := context.Background()
+
+	// existing returns a repository where +628123456780 is taken.
+	existing := func(t *testing.T) repository.Repository {
+		repo := repository.NewMemoryRepository()
+		err := repo.Create(ctx, &entity.User{FullName: `Existing`, PhoneNumber: `+628123456780`})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return repo
+	}
+
+	tests := []struct {
+		name      string
+		form      *user.ImportUsersRequest
+		want      *user.ImportUsersResponse
+		wantErr   bool
+		err       error
+		wantUsers []string
+	}{
+		{
+			name: `TestImportUsers-CSV`,
+			form: &user.ImportUsersRequest{
+				Format: user.ImportFormatCSV,
+				Body: strings.NewReader("\ufeffFull_Name,phone_number,note,password\n" +
+					"User One,+628123456781,,Password1!\n" +
+					"User Two,0812345678,,Password1!\n" +
+					"User Three,+628123456781,,Password1!\n" +
+					"User Four,+628123456780,,Password1!\n" +
+					"User Five,+628123456785\n" +
+					"\"User, Six\",+628123456786,\"multi\nline\",Password1!\n" +
+					"User Seven,+628123456787,,Password1!\n"),
+			},
+			want: &user.ImportUsersResponse{
+				Total:    7,
+				Imported: 3,
+				Failed:   4,
+				Errors: []user.ImportRowError{
+					{Row: 3, PhoneNumber: `0812345678`, Message: "Invalid phone number format"},
+					{Row: 4, PhoneNumber: `+628123456781`, Message: "Phone number is already used in row 2"},
+					{Row: 5, PhoneNumber: `+628123456780`, Message: "Phone number already exists"},
+					{Row: 6, Message: "Row has 2 fields but the header has 4"},
+				},
+			},
+			wantUsers: []string{`+628123456781`, `+628123456786`, `+628123456787`},
+		},
+		{
+			name: `TestImportUsers-JSONLDryRun`,
+			form: &user.ImportUsersRequest{
+				Format:    user.ImportFormatJSONL,
+				DryRun:    true,
+				BatchSize: 1,
+				Body: strings.NewReader(`{"phone_number":"+628123456781","full_name":"User One","password":"Password1!"}` + "\n\n" +
+					`{"phone_number":"+628123456782",` + "\n" +
+					`{"phone_number":"+628123456783","full_name":"Me","password":"Password1!"}` + "\n" +
+					`{"phone_number":"+628123456780","full_name":"User Four","password":"Password1!"}` + "\n"),
+			},
+			want: &user.ImportUsersResponse{
+				DryRun:   true,
+				Total:    4,
+				Imported: 1,
+				Failed:   3,
+				Errors: []user.ImportRowError{
+					{Row: 3, Message: "Row is not a valid JSON object"},
+					{Row: 4, PhoneNumber: `+628123456783`, Message: "Full name length must be between 3 to 60 characters"},
+					{Row: 5, PhoneNumber: `+628123456780`, Message: "Phone number already exists"},
+				},
+			},
+		},
+		{
+			name: `TestImportUsers-MissingColumn`,
+			form: &user.ImportUsersRequest{
+				Format: user.ImportFormatCSV,
+				Body:   strings.NewReader("phone_number,full_name\n+628123456781,User One\n"),
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "CSV header must name the phone_number, full_name and password columns",
+			},
+		},
+		{
+			name: `TestImportUsers-LineTooLong`,
+			form: &user.ImportUsersRequest{
+				Format: user.ImportFormatJSONL,
+				Body:   strings.NewReader(`{"phone_number":"` + strings.Repeat(`1`, maxImportLineSize) + `"}`),
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Import stopped after 0 users were imported: line 1 is longer than 65536 bytes",
+			},
+		},
+		{
+			name: `TestImportUsers-InvalidFormat`,
+			form: &user.ImportUsersRequest{
+				Format: `xlsx`,
+				Body:   strings.NewReader(``),
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Format must be one of csv or jsonl",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := existing(t)
+			u := &userUsecaseCtx{cfg: &config.Config{}, repo: repo}
+
+			got, err := u.ImportUsers(ctx, tt.form)
+			if tt.wantErr {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+
+			taken, err := repo.ListTakenPhoneNumbers(ctx, []string{
+				`+628123456781`, `+628123456782`, `+628123456783`, `+628123456785`, `+628123456786`, `+628123456787`,
+			})
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, tt.wantUsers, taken)
+		})
+	}
+}
+
+func Test_userUsecaseCtx_ImportUsersConcurrentRegistration(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	mockTransaction(mockRepo)
+	mockRepo.On(`Now`).Return(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC))
+	mockRepo.On(`RandomString`, 12).Return(`abcdefghijkl`)
+	mockRepo.On(`ListTakenPhoneNumbers`, mock.Anything, []string{`+628123456781`, `+628123456782`}).Return(nil, nil)
+	mockRepo.On(`CreateUsers`, mock.Anything, mock.MatchedBy(func(users []*entity.User) bool { return len(users) == 2 })).
+		Return(&repository.ConflictError{Constraint: `user_phone_number_key`}).Once()
+	mockRepo.On(`CreateUsers`, mock.Anything, mock.MatchedBy(func(users []*entity.User) bool {
+		return len(users) == 1 && users[0].PhoneNumber == `+628123456781`
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).([]*entity.User)[0].ID = 1
+	}).Return(nil).Once()
+	mockRepo.On(`CreateUsers`, mock.Anything, mock.MatchedBy(func(users []*entity.User) bool {
+		return len(users) == 1 && users[0].PhoneNumber == `+628123456782`
+	})).Return(&repository.ConflictError{Constraint: `user_phone_number_key`}).Once()
+	mockRepo.On(`CreateAuditLog`, mock.Anything, mock.MatchedBy(func(auditLog *entity.AuditLog) bool {
+		return auditLog.UserID == 1 && auditLog.ActorID == 9 && auditLog.ActorRole == entity.RoleAdmin
+	})).Return(nil).Once()
+	mockRepo.On(`CreateOutboxEvent`, mock.Anything, mock.Anything).Return(nil).Once()
+
+	u := &userUsecaseCtx{cfg: &config.Config{}, repo: mockRepo}
+	got, err := u.ImportUsers(context.Background(), &user.ImportUsersRequest{
+		Format: user.ImportFormatCSV,
+		Body: strings.NewReader("phone_number,full_name,password\n" +
+			"+628123456781,User One,Password1!\n" +
+			"+628123456782,User Two,Password1!\n"),
+		ActorID: 9,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, &user.ImportUsersResponse{
+		Total:    2,
+		Imported: 1,
+		Failed:   1,
+		Errors: []user.ImportRowError{
+			{Row: 3, PhoneNumber: `+628123456782`, Message: "Phone number already exists"},
+		},
+	}, got)
+	mockRepo.AssertExpectations(t)
+}
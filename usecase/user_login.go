@@ -2,12 +2,14 @@ package usecase
 
 import (
 	"context"
-	"net/http"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
 	"github.com/sawitpro/technical_test/usecase/user"
 )
 
@@ -16,51 +18,95 @@ func (u *userUsecaseCtx) UserLogin(ctx context.Context, form *user.UserLoginRequ
 	if err = form.Validation(); err != nil {
 		return nil, err
 	}
+	ctx = tenant.WithID(ctx, form.OrganizationID)
 
 	existsUser, err := u.repo.GetUserByPhoneNumber(ctx, form.PhoneNumber)
-	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
-		}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, shared.Internal(err)
+	}
+
+	timeNow := shared.UTC7(u.repo.Now())
+	event := &entity.LoginEvent{
+		PhoneNumber: form.PhoneNumber,
+		IPAddress:   form.IPAddress,
+		UserAgent:   form.UserAgent,
+		CreatedAt:   timeNow,
 	}
+
 	if existsUser == nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "This phone number is not registered",
+		event.FailureReason = entity.LoginFailureUnknownPhoneNumber
+		if err = u.recordFailedLogin(ctx, event); err != nil {
+			return nil, err
 		}
+
+		return nil, shared.Validation("This phone number is not registered")
 	}
+	event.UserID = &existsUser.ID
 
 	hashedPassword := shared.MD5(form.Password + existsUser.AccountSalt)
 	if hashedPassword != existsUser.Password {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusBadRequest,
-			ErrorMessage: "Wrong password",
+		event.FailureReason = entity.LoginFailureWrongPassword
+		if err = u.recordFailedLogin(ctx, event); err != nil {
+			return nil, err
 		}
+
+		return nil, shared.Validation("Wrong password")
 	}
 
-	res, err := u.createAccessToken(existsUser)
+	res, err := u.createAccessToken(ctx, existsUser)
 	if err != nil {
 		return nil, err
 	}
 
-	err = u.repo.IncrementSuccessfulLogin(ctx, existsUser.ID)
-	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
+	event.Success = true
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.IncrementSuccessfulLogin(ctx, existsUser.ID, timeNow); err != nil {
+			return err
+		}
+		if err := u.repo.CreateLoginEvent(ctx, event); err != nil {
+			return err
 		}
+
+		loggedIn := entity.UserLoggedInEvent{
+			UserID:    existsUser.ID,
+			IPAddress: form.IPAddress,
+			UserAgent: form.UserAgent,
+		}
+		return u.emitEvent(ctx, entity.EventUserLoggedIn, existsUser.ID, loggedIn, timeNow)
+	})
+	if err != nil {
+		return nil, transactionError(err)
 	}
 
 	return res, nil
 }
 
-func (u *userUsecaseCtx) createAccessToken(data *entity.User) (*user.UserLoginResponse, error) {
-	var err error
+// recordFailedLogin saves a failed attempt in its own transaction, since the
+// login itself returns an error.
+func (u *userUsecaseCtx) recordFailedLogin(ctx context.Context, event *entity.LoginEvent) error {
+	err := u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if event.UserID != nil {
+			if err := u.repo.IncrementFailedLogin(ctx, *event.UserID, event.CreatedAt); err != nil {
+				return err
+			}
+		}
+		return u.repo.CreateLoginEvent(ctx, event)
+	})
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return nil
+}
 
+// createAccessToken returns a token acting as data in the organization ctx
+// acts in.
+func (u *userUsecaseCtx) createAccessToken(ctx context.Context, data *entity.User) (*user.UserLoginResponse, error) {
 	claim := entity.AccessTokenClaim{
-		UserID:      data.ID,
-		PhoneNumber: data.PhoneNumber,
+		UserID:         data.ID,
+		PhoneNumber:    data.PhoneNumber,
+		Role:           data.Role,
+		OrganizationID: tenant.IDOrDefault(ctx),
 	}
 
 	now := u.repo.Now()
@@ -68,20 +114,25 @@ func (u *userUsecaseCtx) createAccessToken(data *entity.User) (*user.UserLoginRe
 
 	claim.IssuedAt = jwt.NewNumericDate(now)
 	claim.ExpiresAt = jwt.NewNumericDate(end)
+	claim.AuthTime = jwt.NewNumericDate(now)
+
+	return u.signAccessToken(claim, end)
+}
+
+func (u *userUsecaseCtx) signAccessToken(claim entity.AccessTokenClaim, end time.Time) (*user.UserLoginResponse, error) {
+	var err error
 
 	newToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
 	tokenString, err := newToken.SignedString(u.cfg.PrivateKey)
 	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Failed sign access token",
-		}
+		return nil, shared.Internal(err)
 	}
 
 	res := &user.UserLoginResponse{
-		UserID:    data.ID,
-		Token:     tokenString,
-		ExpiredAt: end.Format(time.RFC3339),
+		UserID:         claim.UserID,
+		OrganizationID: claim.OrganizationID,
+		Token:          tokenString,
+		ExpiredAt:      end.Format(time.RFC3339),
 	}
 	return res, nil
 }
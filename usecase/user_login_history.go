@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) ListLogins(ctx context.Context, form *user.ListLoginsRequest, userID int) (*user.ListLoginsResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	events, err := u.repo.ListLoginEvents(ctx, userID, form.PageSize(), form.Offset)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.ListLoginsResponse{
+		Logins: make([]user.LoginResponse, 0, len(events)),
+	}
+	for _, event := range events {
+		res.Logins = append(res.Logins, user.LoginResponse{
+			ID:            event.ID,
+			Success:       event.Success,
+			FailureReason: event.FailureReason,
+			IPAddress:     event.IPAddress,
+			UserAgent:     event.UserAgent,
+			CreatedAt:     event.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
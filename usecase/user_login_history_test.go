@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_userUsecaseCtx_ListLogins(t *testing.T) {
+	type args struct {
+		form   *user.ListLoginsRequest
+		userID int
+	}
+
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	userID := 1
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.ListLoginsResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestListLogins-OffsetInvalid`,
+			args: args{
+				form: &user.ListLoginsRequest{
+					Offset: -1,
+				},
+				userID: 1,
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Offset must not be negative",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestListLogins-ListError`,
+			args: args{
+				form:   &user.ListLoginsRequest{},
+				userID: 1,
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`ListLoginEvents`, context.Background(), 1, 50, 0).Return(nil, errors.New(`error`)).Once()
+
+				return uc
+			},
+		},
+		{
+			name: `TestListLogins-Success`,
+			args: args{
+				form: &user.ListLoginsRequest{
+					Limit:  10,
+					Offset: 20,
+				},
+				userID: 1,
+			},
+			want: &user.ListLoginsResponse{
+				Logins: []user.LoginResponse{
+					{
+						ID:            2,
+						Success:       false,
+						FailureReason: entity.LoginFailureWrongPassword,
+						IPAddress:     `127.0.0.1`,
+						UserAgent:     `curl/8.0`,
+						CreatedAt:     createdAt,
+					},
+				},
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`ListLoginEvents`, context.Background(), 1, 10, 20).Return([]entity.LoginEvent{
+					{
+						ID:            2,
+						UserID:        &userID,
+						PhoneNumber:   `+62123456789`,
+						FailureReason: entity.LoginFailureWrongPassword,
+						IPAddress:     `127.0.0.1`,
+						UserAgent:     `curl/8.0`,
+						CreatedAt:     createdAt,
+					},
+				}, nil).Once()
+
+				return uc
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.ListLogins(context.Background(), tt.args.form, tt.args.userID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.ListLogins() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.ListLogins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -14,8 +14,10 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sawitpro/technical_test/config"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/repository/mocks"
 	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
 	"github.com/sawitpro/technical_test/usecase/user"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -99,7 +101,17 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 					repo: mockRepo,
 				}
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(nil, nil).Once()
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`CreateLoginEvent`, mock.Anything, &entity.LoginEvent{
+					PhoneNumber:   `+62123456789`,
+					FailureReason: entity.LoginFailureUnknownPhoneNumber,
+					CreatedAt:     shared.UTC7(timeNow),
+				}).Return(nil).Once()
 
 				return u
 			},
@@ -130,6 +142,16 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 				}
 				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(mockUserData, nil).Once()
 
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`IncrementFailedLogin`, mock.Anything, 0, shared.UTC7(timeNow)).Return(nil).Once()
+
+				mockRepo.On(`CreateLoginEvent`, mock.Anything, mock.MatchedBy(func(event *entity.LoginEvent) bool {
+					return !event.Success && event.FailureReason == entity.LoginFailureWrongPassword
+				})).Return(nil).Once()
+
 				return u
 			},
 		},
@@ -142,10 +164,7 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 				},
 			},
 			wantErr: true,
-			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
-			},
+			err:     shared.Internal(errors.New(`error`)),
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 				cfg := &config.Config{}
@@ -160,7 +179,37 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 
 				mockRepo.On(`Now`).Return(timeNow)
 
-				mockRepo.On(`IncrementSuccessfulLogin`, mock.Anything, 1).Return(errors.New(`error`)).Once()
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`IncrementSuccessfulLogin`, mock.Anything, 1, shared.UTC7(timeNow)).Return(errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestLogin-LoginEventError`,
+			args: args{
+				form: &user.UserLoginRequest{
+					PhoneNumber: `+62123456789`,
+					Password:    `Password123!`,
+				},
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`CreateLoginEvent`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
 
 				return u
 			},
@@ -190,7 +239,20 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 
 				mockRepo.On(`Now`).Return(timeNow)
 
-				mockRepo.On(`IncrementSuccessfulLogin`, mock.Anything, 1).Return(nil).Once()
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`IncrementSuccessfulLogin`, mock.Anything, 1, shared.UTC7(timeNow)).Return(nil).Once()
+
+				mockRepo.On(`CreateLoginEvent`, mock.Anything, mock.MatchedBy(func(event *entity.LoginEvent) bool {
+					return event.Success && *event.UserID == 1
+				})).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, &entity.OutboxEvent{
+					Type:      entity.EventUserLoggedIn,
+					UserID:    1,
+					Payload:   `{"user_id":1,"ip_address":"","user_agent":""}`,
+					CreatedAt: shared.UTC7(timeNow),
+				}).Return(nil).Once()
 
 				return u
 			},
@@ -213,22 +275,26 @@ func Test_userUsecaseCtx_UserLogin(t *testing.T) {
 
 func mockCreateAccessToken(data *entity.User, privateKey *rsa.PrivateKey, now time.Time) *user.UserLoginResponse {
 	claim := entity.AccessTokenClaim{
-		UserID:      data.ID,
-		PhoneNumber: data.PhoneNumber,
+		UserID:         data.ID,
+		PhoneNumber:    data.PhoneNumber,
+		Role:           data.Role,
+		OrganizationID: tenant.DefaultID,
 	}
 
 	end := now.Add(time.Hour)
 
 	claim.IssuedAt = jwt.NewNumericDate(now)
 	claim.ExpiresAt = jwt.NewNumericDate(end)
+	claim.AuthTime = jwt.NewNumericDate(now)
 
 	newToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claim)
 	tokenString, _ := newToken.SignedString(privateKey)
 
 	res := &user.UserLoginResponse{
-		UserID:    data.ID,
-		Token:     tokenString,
-		ExpiredAt: end.Format(time.RFC3339),
+		UserID:         data.ID,
+		OrganizationID: claim.OrganizationID,
+		Token:          tokenString,
+		ExpiredAt:      end.Format(time.RFC3339),
 	}
 	return res
 }
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+var errNotDefaultOrganization = shared.Forbidden("Organizations can only be managed by admins of the default organization")
+
+func (u *userUsecaseCtx) CreateOrganization(ctx context.Context, form *user.CreateOrganizationRequest) (*user.OrganizationResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	if tenant.IDOrDefault(ctx) != tenant.DefaultID {
+		return nil, errNotDefaultOrganization
+	}
+
+	organization := &entity.Organization{
+		Name:      form.Name,
+		CreatedAt: shared.UTC7(u.repo.Now()),
+	}
+	err = u.repo.CreateOrganization(ctx, organization)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		CreatedAt: organization.CreatedAt,
+	}
+	return res, nil
+}
+
+// AddOrganizationMember lets an existing user of any organization log in to
+// organizationID as well. A user whose phone number is already used by
+// another member of it cannot be added, since logging in would be ambiguous.
+func (u *userUsecaseCtx) AddOrganizationMember(ctx context.Context, form *user.AddOrganizationMemberRequest, organizationID int) (*user.OrganizationMemberResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	if tenant.IDOrDefault(ctx) != tenant.DefaultID {
+		return nil, errNotDefaultOrganization
+	}
+
+	_, err = u.repo.GetOrganization(ctx, organizationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errOrganizationNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	// The user may belong to any organization.
+	member, err := u.repo.GetUserByID(tenant.WithID(ctx, 0), form.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	existsUser, err := u.repo.GetUserByPhoneNumber(tenant.WithID(ctx, organizationID), member.PhoneNumber)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, shared.Internal(err)
+	}
+	if err == nil && existsUser.ID != member.ID {
+		return nil, errPhoneNumberExists
+	}
+
+	membership := &entity.OrganizationMember{
+		OrganizationID: organizationID,
+		UserID:         member.ID,
+		Role:           form.Role,
+		CreatedAt:      shared.UTC7(u.repo.Now()),
+	}
+	err = u.repo.AddOrganizationMember(ctx, membership)
+	if err != nil {
+		if repository.IsConflict(err) {
+			return nil, shared.Conflict("User is already a member of this organization")
+		}
+
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.OrganizationMemberResponse{
+		OrganizationID: membership.OrganizationID,
+		UserID:         membership.UserID,
+		Role:           membership.Role,
+		CreatedAt:      membership.CreatedAt,
+	}
+	return res, nil
+}
+
+// ListOrganizations returns every organization userID can log in to.
+func (u *userUsecaseCtx) ListOrganizations(ctx context.Context, userID int) (*user.ListOrganizationsResponse, error) {
+	members, err := u.repo.ListMemberships(ctx, userID)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.ListOrganizationsResponse{
+		Organizations: make([]user.MembershipResponse, 0, len(members)),
+	}
+	for _, member := range members {
+		organization, err := u.repo.GetOrganization(ctx, member.OrganizationID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, shared.Internal(err)
+		}
+
+		res.Organizations = append(res.Organizations, user.MembershipResponse{
+			ID:   organization.ID,
+			Name: organization.Name,
+			Role: member.Role,
+		})
+	}
+
+	return res, nil
+}
@@ -0,0 +1,249 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_CreateOrganization(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		form *user.CreateOrganizationRequest
+	}
+
+	timeNow := time.Now()
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.OrganizationResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestCreateOrganization-NameInvalid`,
+			args: args{
+				ctx:  context.Background(),
+				form: &user.CreateOrganizationRequest{Name: ` A `},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Organization name length must be between 2 to 100 characters",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestCreateOrganization-NotDefaultOrganization`,
+			args: args{
+				ctx:  tenant.WithID(context.Background(), 2),
+				form: &user.CreateOrganizationRequest{Name: `Acme`},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusForbidden,
+				ErrorMessage: "Organizations can only be managed by admins of the default organization",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestCreateOrganization-CreateError`,
+			args: args{
+				ctx:  context.Background(),
+				form: &user.CreateOrganizationRequest{Name: `Acme`},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockRepo.On(`CreateOrganization`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name: `TestCreateOrganization-Success`,
+			args: args{
+				ctx:  tenant.WithID(context.Background(), tenant.DefaultID),
+				form: &user.CreateOrganizationRequest{Name: ` Acme `},
+			},
+			want: &user.OrganizationResponse{
+				ID:        2,
+				Name:      `Acme`,
+				CreatedAt: shared.UTC7(timeNow),
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				organization := &entity.Organization{
+					Name:      `Acme`,
+					CreatedAt: shared.UTC7(timeNow),
+				}
+				mockRepo.On(`CreateOrganization`, mock.Anything, organization).Run(func(args mock.Arguments) {
+					args.Get(1).(*entity.Organization).ID = 2
+				}).Return(nil).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.CreateOrganization(tt.args.ctx, tt.args.form)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.CreateOrganization() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.CreateOrganization() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userUsecaseCtx_AddOrganizationMember(t *testing.T) {
+	ctx := context.Background()
+
+	// organizations returns a repository with the organization 2, where
+	// user 1 of the default organization and user 2 of the organization 2
+	// share a phone number, and user 3 of the default organization does not.
+	organizations := func(t *testing.T) repository.Repository {
+		repo := repository.NewMemoryRepository()
+		assert.NoError(t, repo.CreateOrganization(ctx, &entity.Organization{Name: `Acme`}))
+		assert.NoError(t, repo.Create(ctx, &entity.User{FullName: `One`, PhoneNumber: `+628123456781`}))
+		assert.NoError(t, repo.Create(ctx, &entity.User{FullName: `Two`, PhoneNumber: `+628123456781`, OrganizationID: 2, PhoneNumberScope: 2}))
+		assert.NoError(t, repo.Create(ctx, &entity.User{FullName: `Three`, PhoneNumber: `+628123456783`}))
+		return repo
+	}
+
+	tests := []struct {
+		name           string
+		form           *user.AddOrganizationMemberRequest
+		organizationID int
+		err            error
+	}{
+		{
+			name:           `TestAddOrganizationMember-RoleInvalid`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 3, Role: `owner`},
+			organizationID: 2,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Role must be one of user or admin",
+			},
+		},
+		{
+			name:           `TestAddOrganizationMember-OrganizationNotFound`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 3},
+			organizationID: 3,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This organization does not exist",
+			},
+		},
+		{
+			name:           `TestAddOrganizationMember-UserNotFound`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 4},
+			organizationID: 2,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This user does not exists",
+			},
+		},
+		{
+			name:           `TestAddOrganizationMember-PhoneNumberExists`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 1},
+			organizationID: 2,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusConflict,
+				ErrorMessage: "Phone number already exists",
+			},
+		},
+		{
+			name:           `TestAddOrganizationMember-AlreadyMember`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 2},
+			organizationID: 2,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusConflict,
+				ErrorMessage: "User is already a member of this organization",
+			},
+		},
+		{
+			name:           `TestAddOrganizationMember-Success`,
+			form:           &user.AddOrganizationMemberRequest{UserID: 3, Role: entity.RoleAdmin},
+			organizationID: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := organizations(t)
+			u := &userUsecaseCtx{repo: repo}
+
+			got, err := u.AddOrganizationMember(ctx, tt.form, tt.organizationID)
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, 2, got.OrganizationID)
+			assert.Equal(t, 3, got.UserID)
+			assert.Equal(t, entity.RoleAdmin, got.Role)
+
+			member, err := repo.GetUserByPhoneNumber(tenant.WithID(ctx, 2), `+628123456783`)
+			assert.NoError(t, err)
+			if assert.NotNil(t, member) {
+				assert.Equal(t, 3, member.ID)
+				assert.Equal(t, entity.RoleAdmin, member.Role)
+			}
+
+			res, err := u.ListOrganizations(ctx, 3)
+			assert.NoError(t, err)
+			assert.Equal(t, &user.ListOrganizationsResponse{
+				Organizations: []user.MembershipResponse{
+					{ID: 1, Name: `Default`, Role: entity.RoleUser},
+					{ID: 2, Name: `Acme`, Role: entity.RoleAdmin},
+				},
+			}, res)
+		})
+	}
+
+	t.Run(`TestAddOrganizationMember-NotDefaultOrganization`, func(t *testing.T) {
+		u := &userUsecaseCtx{repo: organizations(t)}
+
+		_, err := u.AddOrganizationMember(tenant.WithID(ctx, 2), &user.AddOrganizationMemberRequest{UserID: 3}, 2)
+		assert.Equal(t, &shared.ErrorMessage{
+			ErrorCode:    http.StatusForbidden,
+			ErrorMessage: "Organizations can only be managed by admins of the default organization",
+		}, err)
+	})
+}
@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/shared"
+)
+
+// emitEvent adds a domain event to the outbox. It is called inside the
+// transaction making the change, so the event is published exactly when the
+// change is committed.
+func (u *userUsecaseCtx) emitEvent(ctx context.Context, eventType string, userID int, payload interface{}, at time.Time) error {
+	value, err := json.Marshal(payload)
+	if err == nil {
+		err = u.repo.CreateOutboxEvent(ctx, &entity.OutboxEvent{
+			Type:      eventType,
+			UserID:    userID,
+			Payload:   string(value),
+			CreatedAt: at,
+		})
+	}
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	return nil
+}
+
+// eventChanges returns changes with the phone numbers masked like in the
+// payloads of events, which leave the service.
+func eventChanges(changes map[string]entity.AuditChange) map[string]entity.AuditChange {
+	masked := make(map[string]entity.AuditChange, len(changes))
+	for field, change := range changes {
+		if field == `phone_number` {
+			change = entity.AuditChange{Old: maskPhoneNumber(change.Old), New: maskPhoneNumber(change.New)}
+		}
+		masked[field] = change
+	}
+
+	return masked
+}
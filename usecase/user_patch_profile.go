@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) PatchProfile(ctx context.Context, form *user.PatchProfileRequest, userID int) error {
+	var err error
+	if err = form.Validation(); err != nil {
+		return err
+	}
+
+	if form.Version == 0 && u.cfg.RequireIfMatch {
+		return &shared.ErrorMessage{
+			ErrorCode:    http.StatusPreconditionRequired,
+			ErrorMessage: "If-Match header is required",
+		}
+	}
+
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+		if form.Version > 0 && form.Version != existsUser.Version {
+			return errProfileModified
+		}
+
+		// Sending the current phone number is not a change.
+		if form.PhoneNumber.Set && *form.PhoneNumber.Value != existsUser.PhoneNumber {
+			return errPhoneNumberChangeRequired
+		}
+
+		changes := map[string]entity.AuditChange{}
+		fullName := patchField(changes, `full_name`, &existsUser.FullName, form.FullName)
+		existsUser.FullName = *fullName
+		existsUser.DateOfBirth = patchField(changes, `date_of_birth`, existsUser.DateOfBirth, form.DateOfBirth)
+		existsUser.Gender = patchField(changes, `gender`, existsUser.Gender, form.Gender)
+		existsUser.Address = patchField(changes, `address`, existsUser.Address, form.Address)
+		existsUser.PreferredLanguage = patchField(changes, `preferred_language`, existsUser.PreferredLanguage, form.PreferredLanguage)
+
+		timeNow := shared.UTC7(u.repo.Now())
+		existsUser.UpdatedAt = &timeNow
+		return u.saveProfile(ctx, form.RequestMeta, form.ActorID, userID, existsUser, changes, timeNow)
+	})
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return nil
+}
+
+// patchField returns the value of a field after applying patch to old, and
+// adds the change to changes when the value differs. A removed field is
+// audited as an empty string.
+func patchField(changes map[string]entity.AuditChange, name string, old *string, patch user.PatchString) *string {
+	if !patch.Set {
+		return old
+	}
+
+	var oldValue, newValue string
+	if old != nil {
+		oldValue = *old
+	}
+	if patch.Value != nil {
+		newValue = *patch.Value
+	}
+	if (old == nil) != (patch.Value == nil) || oldValue != newValue {
+		changes[name] = entity.AuditChange{Old: oldValue, New: newValue}
+	}
+
+	return patch.Value
+}
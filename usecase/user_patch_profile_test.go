@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_PatchProfile(t *testing.T) {
+	type args struct {
+		form   *user.PatchProfileRequest
+		userID int
+	}
+
+	cfg := &config.Config{}
+
+	// patch decodes a merge patch document the way the handler does.
+	patch := func(body string) *user.PatchProfileRequest {
+		form := &user.PatchProfileRequest{}
+		if err := json.Unmarshal([]byte(body), form); err != nil {
+			t.Fatal(err)
+		}
+		return form
+	}
+
+	address, gender := `Jl. Sudirman 1`, entity.GenderMale
+	existsUser := func() *entity.User {
+		return &entity.User{
+			FullName:    `user123`,
+			PhoneNumber: `+62123456789`,
+			Address:     &address,
+			Gender:      &gender,
+			Version:     1,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: "TestPatchProfile-FullNameRemoved",
+			args: args{
+				form:   patch(`{"full_name":null}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Full name cannot be removed",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: "TestPatchProfile-DateOfBirthInvalid",
+			args: args{
+				form:   patch(`{"date_of_birth":"02-01-1990"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Date of birth must be a date in YYYY-MM-DD format",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: "TestPatchProfile-DateOfBirthInFuture",
+			args: args{
+				form:   patch(`{"date_of_birth":"2999-01-01"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Date of birth must be between 1900-01-01 and today",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: "TestPatchProfile-GenderInvalid",
+			args: args{
+				form:   patch(`{"gender":"unknown"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Gender must be one of male, female or other",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: "TestPatchProfile-PreferredLanguageInvalid",
+			args: args{
+				form:   patch(`{"preferred_language":"english language"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Preferred language must be a BCP 47 language tag, such as en or id-ID",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: "TestPatchProfile-UserNotExists",
+			args: args{
+				form:   patch(`{"gender":"female"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This user does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestPatchProfile-PhoneNumberChangeRequired",
+			args: args{
+				form:   patch(`{"phone_number":"+62123456780"}`),
+				userID: 1,
+			},
+			wantErr: true,
+			err:     errPhoneNumberChangeRequired,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(existsUser(), nil).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestPatchProfile-Success",
+			args: args{
+				form: func() *user.PatchProfileRequest {
+					form := patch(`{"phone_number":"+62123456789","date_of_birth":"1990-01-02","gender":"female","address":null}`)
+					form.RequestMeta = user.RequestMeta{
+						Role:      entity.RoleUser,
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+					}
+					return form
+				}(),
+				userID: 1,
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(existsUser(), nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				dateOfBirth, female := `1990-01-02`, entity.GenderFemale
+				mockRepo.On(`UpdateProfile`, mock.Anything, &entity.User{
+					FullName:    `user123`,
+					PhoneNumber: `+62123456789`,
+					DateOfBirth: &dateOfBirth,
+					Gender:      &female,
+					UpdatedAt:   &now,
+					Version:     1,
+				}).Return(nil).Once()
+
+				changes := `{"address":{"old":"Jl. Sudirman 1","new":""},"date_of_birth":{"old":"","new":"1990-01-02"},"gender":{"old":"male","new":"female"}}`
+				mockRepo.On(`CreateAuditLog`, mock.Anything, &entity.AuditLog{
+					ActorID:   1,
+					ActorRole: entity.RoleUser,
+					Action:    entity.AuditActionProfileUpdated,
+					UserID:    1,
+					Changes:   changes,
+					IPAddress: `127.0.0.1`,
+					RequestID: `request-1`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, &entity.OutboxEvent{
+					Type:      entity.EventProfileUpdated,
+					UserID:    1,
+					Payload:   `{"user_id":1,"full_name":"user123","phone_number":"*********789","date_of_birth":"1990-01-02","gender":"female","address":null,"preferred_language":null,"changes":` + changes + `}`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				return u
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			err := u.PatchProfile(context.Background(), tt.args.form, tt.args.userID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.PatchProfile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
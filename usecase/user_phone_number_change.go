@@ -0,0 +1,227 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/job"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+const (
+	// phoneNumberChangeResendInterval is how long after a code was sent
+	// another one may be requested.
+	phoneNumberChangeResendInterval = time.Minute
+	// phoneNumberChangeMaxAttempts is how many wrong codes are accepted
+	// before a new one has to be requested.
+	phoneNumberChangeMaxAttempts = 5
+)
+
+var errPhoneNumberChangeNotFound = shared.NotFound("There is no pending phone number change, please request a new code")
+
+// errPhoneNumberChangeRequired rejects profile updates that change the phone
+// number, which can only be changed once the new one is verified.
+var errPhoneNumberChangeRequired = shared.Validation("Phone number can only be changed by confirming a code sent to the new number")
+
+// RequestPhoneNumberChange sends a code to the new phone number, which
+// replaces the current one once ConfirmPhoneNumberChange is called with
+// it. The current phone number is told about the change. Both messages are
+// sent by background jobs.
+func (u *userUsecaseCtx) RequestPhoneNumberChange(ctx context.Context, form *user.RequestPhoneNumberChangeRequest, userID int) (*user.PhoneNumberChangeResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	if err = rejectImpersonation(form.ActorID); err != nil {
+		return nil, err
+	}
+	if err = u.requireRecentAuthentication(form.AuthTime); err != nil {
+		return nil, err
+	}
+
+	existsUser, err := u.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+	if form.PhoneNumber == existsUser.PhoneNumber {
+		return nil, shared.Validation("New phone number must be different from the current one")
+	}
+
+	_, err = u.repo.GetUserByPhoneNumber(ctx, form.PhoneNumber)
+	if err == nil {
+		return nil, errPhoneNumberExists
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, shared.Internal(err)
+	}
+
+	timeNow := shared.UTC7(u.repo.Now())
+
+	changed, err := u.repo.CountPhoneNumberChanges(ctx, entity.PhoneNumberChangeFilter{
+		UserID:        userID,
+		ConfirmedFrom: timeNow.Add(-u.cfg.PhoneChangeCooldown),
+	})
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+	if changed > 0 {
+		return nil, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "Phone number was changed recently, please try again later",
+		}
+	}
+
+	requested, err := u.repo.CountPhoneNumberChanges(ctx, entity.PhoneNumberChangeFilter{
+		UserID:      userID,
+		CreatedFrom: timeNow.Add(-phoneNumberChangeResendInterval),
+	})
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+	if requested > 0 {
+		return nil, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "A code was sent recently, please wait before requesting another one",
+		}
+	}
+
+	code, err := shared.RandomDigits(user.PhoneNumberChangeCodeLength)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	change := &entity.PhoneNumberChange{
+		UserID:      userID,
+		PhoneNumber: form.PhoneNumber,
+		CodeHash:    shared.SHA256(code),
+		ExpiresAt:   timeNow.Add(u.cfg.PhoneChangeCodeTTL),
+		CreatedAt:   timeNow,
+	}
+	// The code is sent by a job enqueued with the change, which retries
+	// while the gateway fails. The current number is told about the change
+	// the same way.
+	message := fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", code, int(u.cfg.PhoneChangeCodeTTL.Minutes()))
+	notice := fmt.Sprintf("A change of your phone number to %s was requested. If it was not you, change your password.", maskPhoneNumber(form.PhoneNumber))
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := u.repo.CreatePhoneNumberChange(ctx, change); err != nil {
+			return shared.Internal(err)
+		}
+		if err := u.sendSMS(ctx, form.PhoneNumber, message, &change.ExpiresAt); err != nil {
+			return err
+		}
+		return u.sendSMS(ctx, existsUser.PhoneNumber, notice, nil)
+	})
+	if err != nil {
+		return nil, transactionError(err)
+	}
+
+	res := &user.PhoneNumberChangeResponse{
+		PhoneNumber: form.PhoneNumber,
+		ExpiresAt:   change.ExpiresAt,
+	}
+	return res, nil
+}
+
+// ConfirmPhoneNumberChange replaces the phone number of userID with the one
+// of their latest change request when form carries its code.
+func (u *userUsecaseCtx) ConfirmPhoneNumberChange(ctx context.Context, form *user.ConfirmPhoneNumberChangeRequest, userID int) error {
+	var err error
+	if err = form.Validation(); err != nil {
+		return err
+	}
+
+	if err = rejectImpersonation(form.ActorID); err != nil {
+		return err
+	}
+
+	timeNow := shared.UTC7(u.repo.Now())
+
+	change, err := u.repo.GetPendingPhoneNumberChange(ctx, userID, timeNow)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errPhoneNumberChangeNotFound
+	}
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	// The attempt is counted before the code is compared, and outside of the
+	// transaction below, which is rolled back on any error. Counting it in
+	// one statement keeps concurrent guesses within the limit.
+	counted, err := u.repo.IncrementPhoneNumberChangeAttempts(ctx, change.ID, phoneNumberChangeMaxAttempts)
+	if err != nil {
+		return shared.Internal(err)
+	}
+	if !counted {
+		return &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "Too many invalid codes, please request a new one",
+		}
+	}
+	change.Attempts++
+
+	if subtle.ConstantTimeCompare([]byte(shared.SHA256(form.Code)), []byte(change.CodeHash)) != 1 {
+		return shared.Validation("Invalid code")
+	}
+
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errUserNotFound
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		change.ConfirmedAt = &timeNow
+		if err = u.repo.UpdatePhoneNumberChange(ctx, change); err != nil {
+			return shared.Internal(err)
+		}
+
+		changes := map[string]entity.AuditChange{
+			`phone_number`: {Old: existsUser.PhoneNumber, New: change.PhoneNumber},
+		}
+		existsUser.PhoneNumber = change.PhoneNumber
+		existsUser.UpdatedAt = &timeNow
+		return u.saveProfile(ctx, form.RequestMeta, form.ActorID, userID, existsUser, changes, timeNow)
+	})
+	if err != nil {
+		return transactionError(err)
+	}
+
+	return nil
+}
+
+// sendSMS enqueues a job sending message to phoneNumber until expiresAt,
+// when it is set.
+func (u *userUsecaseCtx) sendSMS(ctx context.Context, phoneNumber string, message string, expiresAt *time.Time) error {
+	payload, err := job.NewSMS(u.cfg.Keyring, phoneNumber, message, expiresAt)
+	if err == nil {
+		_, err = job.SendSMS.Enqueue(ctx, u.repo, payload)
+	}
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	return nil
+}
+
+// maskPhoneNumber hides all but the last 3 digits of phoneNumber.
+func maskPhoneNumber(phoneNumber string) string {
+	if len(phoneNumber) <= 3 {
+		return phoneNumber
+	}
+
+	return strings.Repeat(`*`, len(phoneNumber)-3) + phoneNumber[len(phoneNumber)-3:]
+}
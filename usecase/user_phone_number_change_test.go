@@ -0,0 +1,270 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/job"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type sentSMS struct {
+	phoneNumber string
+	message     string
+	expiresAt   *time.Time
+}
+
+// sentMessages returns the text messages queued to be sent since it was
+// last called, claiming their jobs.
+func sentMessages(t *testing.T, repo repository.Repository) []sentSMS {
+	jobs, err := repo.ClaimJobs(context.Background(), entity.JobClaim{
+		Types: []string{job.SendSMS.Name},
+		Now:   time.Now().Add(time.Hour),
+		Limit: 100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []sentSMS
+	for _, j := range jobs {
+		var payload job.SendSMSPayload
+		if err = json.Unmarshal([]byte(j.Payload), &payload); err != nil {
+			t.Fatal(err)
+		}
+		sent = append(sent, sentSMS{phoneNumber: payload.PhoneNumber, message: payload.Message, expiresAt: payload.ExpiresAt})
+	}
+	return sent
+}
+
+var sentCode = regexp.MustCompile(`code is ([0-9]{6})`)
+
+func Test_userUsecaseCtx_PhoneNumberChange(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, cfg *config.Config) *userUsecaseCtx {
+		repo := repository.NewMemoryRepository()
+		assert.NoError(t, repo.Create(ctx, &entity.User{FullName: `One`, PhoneNumber: `+628123456781`}))
+		assert.NoError(t, repo.Create(ctx, &entity.User{FullName: `Two`, PhoneNumber: `+628123456782`}))
+
+		return &userUsecaseCtx{cfg: cfg, repo: repo}
+	}
+
+	cfg := &config.Config{
+		StepUpWindow:        5 * time.Minute,
+		PhoneChangeCodeTTL:  10 * time.Minute,
+		PhoneChangeCooldown: 24 * time.Hour,
+	}
+	request := func(phoneNumber string) *user.RequestPhoneNumberChangeRequest {
+		return &user.RequestPhoneNumberChangeRequest{PhoneNumber: phoneNumber, AuthTime: time.Now()}
+	}
+
+	t.Run(`TestRequestPhoneNumberChange-Rejected`, func(t *testing.T) {
+		u := setup(t, cfg)
+
+		tests := []struct {
+			name string
+			form *user.RequestPhoneNumberChangeRequest
+			err  error
+		}{
+			{
+				name: `Impersonating`,
+				form: &user.RequestPhoneNumberChangeRequest{PhoneNumber: `+628123456783`, AuthTime: time.Now(), ActorID: 2},
+				err: &shared.ErrorMessage{
+					ErrorCode:    http.StatusForbidden,
+					ErrorMessage: "This action is not allowed while impersonating",
+				},
+			},
+			{
+				name: `RecentAuthenticationRequired`,
+				form: &user.RequestPhoneNumberChangeRequest{PhoneNumber: `+628123456783`, AuthTime: time.Now().Add(-10 * time.Minute)},
+				err: &shared.ErrorMessage{
+					ErrorCode:    http.StatusUnauthorized,
+					ErrorMessage: "Recent authentication is required",
+				},
+			},
+			{
+				name: `SamePhoneNumber`,
+				form: request(`+628123456781`),
+				err: &shared.ErrorMessage{
+					ErrorCode:    http.StatusBadRequest,
+					ErrorMessage: "New phone number must be different from the current one",
+				},
+			},
+			{
+				name: `PhoneNumberExists`,
+				form: request(`+628123456782`),
+				err:  errPhoneNumberExists,
+			},
+		}
+		for _, tt := range tests {
+			_, err := u.RequestPhoneNumberChange(ctx, tt.form, 1)
+			assert.Equal(t, tt.err, err, tt.name)
+		}
+		assert.Empty(t, sentMessages(t, u.repo))
+	})
+
+	t.Run(`TestPhoneNumberChange-Success`, func(t *testing.T) {
+		u := setup(t, cfg)
+
+		res, err := u.RequestPhoneNumberChange(ctx, request(`+628123456783`), 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, `+628123456783`, res.PhoneNumber)
+		assert.WithinDuration(t, time.Now().Add(10*time.Minute), res.ExpiresAt, time.Minute)
+
+		sent := sentMessages(t, u.repo)
+		if !assert.Len(t, sent, 2) {
+			return
+		}
+		assert.Equal(t, `+628123456783`, sent[0].phoneNumber)
+		if assert.NotNil(t, sent[0].expiresAt) {
+			assert.True(t, res.ExpiresAt.Equal(*sent[0].expiresAt))
+		}
+		assert.Equal(t, `+628123456781`, sent[1].phoneNumber)
+		assert.Contains(t, sent[1].message, `**********783`)
+		assert.Nil(t, sent[1].expiresAt)
+		code := sentCode.FindStringSubmatch(sent[0].message)[1]
+
+		// The phone number is unchanged until the code is confirmed.
+		existsUser, err := u.repo.GetUserByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, `+628123456781`, existsUser.PhoneNumber)
+
+		_, err = u.RequestPhoneNumberChange(ctx, request(`+628123456784`), 1)
+		assert.Equal(t, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "A code was sent recently, please wait before requesting another one",
+		}, err)
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: wrongCode(code)}, 1)
+		assert.Equal(t, shared.Validation("Invalid code"), err)
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: code}, 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		existsUser, err = u.repo.GetUserByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, `+628123456783`, existsUser.PhoneNumber)
+		_, err = u.repo.GetUserByPhoneNumber(ctx, `+628123456781`)
+		assert.ErrorIs(t, err, repository.ErrNotFound)
+
+		logs, err := u.repo.ListAuditLogs(ctx, entity.AuditLogFilter{UserID: 1})
+		assert.NoError(t, err)
+		if assert.Len(t, logs, 1) {
+			assert.Equal(t, `{"phone_number":{"old":"+628123456781","new":"+628123456783"}}`, logs[0].Changes)
+		}
+
+		// Events leave the service, so they only carry masked numbers.
+		events, err := u.repo.ListPendingOutboxEvents(ctx, 10)
+		assert.NoError(t, err)
+		if assert.Len(t, events, 1) {
+			assert.Contains(t, events[0].Payload, `"phone_number":"**********783"`)
+			assert.Contains(t, events[0].Payload, `"changes":{"phone_number":{"old":"**********781","new":"**********783"}}`)
+			assert.NotContains(t, events[0].Payload, `+62`)
+		}
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: code}, 1)
+		assert.Equal(t, errPhoneNumberChangeNotFound, err)
+
+		_, err = u.RequestPhoneNumberChange(ctx, request(`+628123456784`), 1)
+		assert.Equal(t, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "Phone number was changed recently, please try again later",
+		}, err)
+	})
+
+	t.Run(`TestConfirmPhoneNumberChange-TooManyAttempts`, func(t *testing.T) {
+		u := setup(t, cfg)
+
+		_, err := u.RequestPhoneNumberChange(ctx, request(`+628123456783`), 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		code := sentCode.FindStringSubmatch(sentMessages(t, u.repo)[0].message)[1]
+
+		for i := 0; i < phoneNumberChangeMaxAttempts; i++ {
+			err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: wrongCode(code)}, 1)
+			assert.Equal(t, shared.Validation("Invalid code"), err)
+		}
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: code}, 1)
+		assert.Equal(t, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "Too many invalid codes, please request a new one",
+		}, err)
+	})
+
+	t.Run(`TestConfirmPhoneNumberChange-ConcurrentAttempts`, func(t *testing.T) {
+		u := setup(t, cfg)
+
+		_, err := u.RequestPhoneNumberChange(ctx, request(`+628123456783`), 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		code := sentCode.FindStringSubmatch(sentMessages(t, u.repo)[0].message)[1]
+
+		// Guesses made at once are limited like those made one after the
+		// other.
+		const guesses = 4 * phoneNumberChangeMaxAttempts
+		errs := make(chan error, guesses)
+		var wg sync.WaitGroup
+		for i := 0; i < guesses; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: wrongCode(code)}, 1)
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		invalid := 0
+		for err := range errs {
+			if assert.ObjectsAreEqual(shared.Validation("Invalid code"), err) {
+				invalid++
+			}
+		}
+		assert.Equal(t, phoneNumberChangeMaxAttempts, invalid)
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: code}, 1)
+		assert.Equal(t, &shared.ErrorMessage{
+			ErrorCode:    http.StatusTooManyRequests,
+			ErrorMessage: "Too many invalid codes, please request a new one",
+		}, err)
+	})
+
+	t.Run(`TestConfirmPhoneNumberChange-Expired`, func(t *testing.T) {
+		u := setup(t, &config.Config{StepUpWindow: 5 * time.Minute})
+
+		_, err := u.RequestPhoneNumberChange(ctx, request(`+628123456783`), 1)
+		if !assert.NoError(t, err) {
+			return
+		}
+		code := sentCode.FindStringSubmatch(sentMessages(t, u.repo)[0].message)[1]
+
+		err = u.ConfirmPhoneNumberChange(ctx, &user.ConfirmPhoneNumberChangeRequest{Code: code}, 1)
+		assert.Equal(t, errPhoneNumberChangeNotFound, err)
+	})
+}
+
+// wrongCode returns a code of the same length that differs from code.
+func wrongCode(code string) string {
+	if code == `000000` {
+		return `111111`
+	}
+	return `000000`
+}
@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) Reauthenticate(ctx context.Context, form *user.ReauthenticateRequest, userID int) (*user.UserLoginResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	existsUser, err := u.repo.GetUserByID(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errUserNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	hashedPassword := shared.MD5(form.Password + existsUser.AccountSalt)
+	if hashedPassword != existsUser.Password {
+		return nil, shared.Validation("Wrong password")
+	}
+
+	return u.createAccessToken(ctx, existsUser)
+}
+
+// requireRecentAuthentication rejects sensitive operations when the access
+// token was not obtained by entering the password within the step-up window.
+func (u *userUsecaseCtx) requireRecentAuthentication(authTime time.Time) error {
+	if authTime.IsZero() || u.repo.Now().Sub(authTime) > u.cfg.StepUpWindow {
+		return shared.Unauthorized("Recent authentication is required")
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_Reauthenticate(t *testing.T) {
+	type args struct {
+		form   *user.ReauthenticateRequest
+		userID int
+	}
+
+	mockUserData := &entity.User{
+		ID:          1,
+		Password:    `d1a7c9c2fba028ce3899850143ab504f`,
+		AccountSalt: `SALT_STRING`,
+	}
+	timeNow := time.Now()
+	privateKey := mockInitPrivateKey()
+	loginResponse := mockCreateAccessToken(mockUserData, privateKey, timeNow)
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.UserLoginResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestReauthenticate-PasswordEmpty`,
+			args: args{
+				form:   &user.ReauthenticateRequest{},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Password is required",
+			},
+			before: func() *userUsecaseCtx {
+				u := &userUsecaseCtx{}
+
+				return u
+			},
+		},
+		{
+			name: `TestReauthenticate-GetUserByIDError`,
+			args: args{
+				form: &user.ReauthenticateRequest{
+					Password: `Password123!`,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestReauthenticate-UserNotExists`,
+			args: args{
+				form: &user.ReauthenticateRequest{
+					Password: `Password123!`,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This user does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestReauthenticate-WrongPassword`,
+			args: args{
+				form: &user.ReauthenticateRequest{
+					Password: `Password123?`,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Wrong password",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(mockUserData, nil).Once()
+
+				return u
+			},
+		},
+		{
+			name: `TestReauthenticate-Success`,
+			args: args{
+				form: &user.ReauthenticateRequest{
+					Password: `Password123!`,
+				},
+				userID: 1,
+			},
+			want:    loginResponse,
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+				cfg := &config.Config{}
+				cfg.PrivateKey = privateKey
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockRepo.On(`GetUserByID`, mock.Anything, 1).Return(mockUserData, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				return u
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.Reauthenticate(context.Background(), tt.args.form, tt.args.userID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.Reauthenticate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.Reauthenticate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,10 +2,14 @@ package usecase
 
 import (
 	"context"
-	"net/http"
+	"errors"
+	"time"
 
+	"github.com/sawitpro/technical_test/config"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
 	"github.com/sawitpro/technical_test/usecase/user"
 )
 
@@ -15,43 +19,102 @@ func (u *userUsecaseCtx) UserRegistration(ctx context.Context, form *user.UserRe
 		return nil, err
 	}
 
-	existsUser, err := u.repo.GetUserByPhoneNumber(ctx, form.PhoneNumber)
-	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
+	// The default organization always exists.
+	if form.OrganizationID != tenant.DefaultID {
+		_, err := u.repo.GetOrganization(ctx, form.OrganizationID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, errOrganizationNotFound
+		}
+		if err != nil {
+			return nil, shared.Internal(err)
 		}
 	}
-	if existsUser != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusConflict,
-			ErrorMessage: "Phone number already exists",
+	ctx = tenant.WithID(ctx, form.OrganizationID)
+
+	var res *user.UserRegistrationResponse
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := u.repo.GetUserByPhoneNumber(ctx, form.PhoneNumber)
+		if err == nil {
+			return errPhoneNumberExists
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return shared.Internal(err)
+		}
+
+		timeNow := shared.UTC7(u.repo.Now())
+		userData := u.newAccount(ctx, form, timeNow)
+
+		// The lookup above cannot see a concurrent registration of the same
+		// number; the unique constraint reports it as a conflict instead.
+		err = u.repo.Create(ctx, userData)
+		if repository.IsConflict(err) {
+			return errPhoneNumberExists
+		}
+		if err != nil {
+			return shared.Internal(err)
+		}
+
+		err = u.recordRegistration(ctx, form.RequestMeta, 0, userData, timeNow)
+		if err != nil {
+			return err
+		}
+
+		res = &user.UserRegistrationResponse{
+			UserID: userData.ID,
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, transactionError(err)
 	}
 
+	return res, nil
+}
+
+// newAccount returns the user to create in the tenant carried by ctx for a
+// validated registration, with the password salted and hashed.
+func (u *userUsecaseCtx) newAccount(ctx context.Context, form *user.UserRegistrationRequest, at time.Time) *entity.User {
 	accountSalt := u.repo.RandomString(12)
-	hashedPassword := shared.MD5(form.Password + accountSalt)
 
-	timeNow := shared.UTC7(u.repo.Now())
-	userData := &entity.User{
-		FullName:    form.FullName,
-		PhoneNumber: form.PhoneNumber,
-		Password:    hashedPassword,
-		AccountSalt: accountSalt,
-		CreatedAt:   timeNow,
-		UpdatedAt:   &timeNow,
+	return &entity.User{
+		FullName:         form.FullName,
+		PhoneNumber:      form.PhoneNumber,
+		PhoneNumberScope: u.phoneNumberScope(ctx),
+		Password:         shared.MD5(form.Password + accountSalt),
+		AccountSalt:      accountSalt,
+		CreatedAt:        at,
+		UpdatedAt:        &at,
+	}
+}
+
+// phoneNumberScope returns the PhoneNumberScope of the users created in the
+// tenant carried by ctx.
+func (u *userUsecaseCtx) phoneNumberScope(ctx context.Context) int {
+	if u.cfg.PhoneNumberUniqueness == config.PhoneNumberUniqueTenant {
+		return tenant.IDOrDefault(ctx)
 	}
 
-	err = u.repo.Create(ctx, userData)
+	return 0
+}
+
+// recordRegistration writes the audit log entry and the outbox event for a
+// created user, in the transaction carried by ctx. actorID is the admin who
+// created the user, 0 when the user registered themselves.
+func (u *userUsecaseCtx) recordRegistration(ctx context.Context, meta user.RequestMeta, actorID int, userData *entity.User, at time.Time) error {
+	changes := map[string]entity.AuditChange{
+		`full_name`:    {New: userData.FullName},
+		`phone_number`: {New: userData.PhoneNumber},
+		`password`:     {New: entity.AuditRedacted},
+	}
+	err := u.recordChange(ctx, meta, actorID, userData.ID, entity.AuditActionRegistered, changes, at)
 	if err != nil {
-		return nil, &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
-		}
+		return err
 	}
 
-	res := &user.UserRegistrationResponse{
-		UserID: userData.ID,
+	event := entity.UserRegisteredEvent{
+		UserID:      userData.ID,
+		FullName:    userData.FullName,
+		PhoneNumber: maskPhoneNumber(userData.PhoneNumber),
 	}
-	return res, nil
+	return u.emitEvent(ctx, entity.EventUserRegistered, userData.ID, event, at)
 }
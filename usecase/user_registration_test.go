@@ -8,7 +8,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sawitpro/technical_test/config"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/repository/mocks"
 	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
@@ -122,18 +124,47 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 			},
 			want:    nil,
 			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
+					repo: mockRepo,
+				}
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, errors.New("error")).Once()
+
+				return uc
+			},
+		},
+		{
+			name: "TestUserRegistration-OrganizationNotFound",
+			args: args{
+				form: &user.UserRegistrationRequest{
+					PhoneNumber:    `+621234567890`,
+					FullName:       `User123`,
+					Password:       `Password123!`,
+					OrganizationID: 2,
+				},
+			},
+			want:    nil,
+			wantErr: true,
 			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This organization does not exist",
 			},
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
 				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
 					repo: mockRepo,
 				}
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, errors.New("error")).Once()
+				mockRepo.On(`GetOrganization`, mock.Anything, 2).Return(nil, repository.ErrNotFound).Once()
 
 				return uc
 			},
@@ -157,9 +188,12 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 				mockRepo := new(mocks.Repository)
 
 				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
 					repo: mockRepo,
 				}
 
+				mockTransaction(mockRepo)
+
 				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(&entity.User{}, nil).Once()
 
 				return uc
@@ -176,21 +210,69 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 			},
 			want:    nil,
 			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
+					repo: mockRepo,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				password := shared.MD5(`Password123!` + `123456789ABC`)
+				mockUserData := &entity.User{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    password,
+					AccountSalt: `123456789ABC`,
+					CreatedAt:   now,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(errors.New(`error`)).Once()
+
+				return uc
+			},
+		},
+		{
+			name: "TestUserRegistration-CreateUserConflict",
+			args: args{
+				form: &user.UserRegistrationRequest{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    `Password123!`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
 			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
+				ErrorCode:    http.StatusConflict,
+				ErrorMessage: "Phone number already exists",
 			},
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
 				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
 					repo: mockRepo,
 				}
 
+				mockTransaction(mockRepo)
+
 				timeNow := time.Now()
 				now := shared.UTC7(timeNow)
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, nil).Once()
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
 
 				mockRepo.On(`Now`).Return(timeNow).Once()
 
@@ -205,7 +287,157 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 					CreatedAt:   now,
 					UpdatedAt:   &now,
 				}
-				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(errors.New(`error`)).Once()
+				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(&repository.ConflictError{Err: errors.New(`error`)}).Once()
+
+				return uc
+			},
+		},
+		{
+			name: "TestUserRegistration-CommitFailed",
+			args: args{
+				form: &user.UserRegistrationRequest{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    `Password123!`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`commit failed`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
+					repo: mockRepo,
+				}
+
+				mockRepo.On(`WithTransaction`, mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+					if err := fn(ctx); err != nil {
+						return err
+					}
+					return errors.New(`commit failed`)
+				})
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				password := shared.MD5(`Password123!` + `123456789ABC`)
+				mockUserData := &entity.User{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    password,
+					AccountSalt: `123456789ABC`,
+					CreatedAt:   now,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, mock.Anything).Return(nil).Once()
+
+				return uc
+			},
+		},
+		{
+			name: "TestUserRegistration-AuditLogError",
+			args: args{
+				form: &user.UserRegistrationRequest{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    `Password123!`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
+					repo: mockRepo,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				password := shared.MD5(`Password123!` + `123456789ABC`)
+				mockUserData := &entity.User{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    password,
+					AccountSalt: `123456789ABC`,
+					CreatedAt:   now,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
+
+				return uc
+			},
+		},
+		{
+			name: "TestUserRegistration-OutboxEventError",
+			args: args{
+				form: &user.UserRegistrationRequest{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    `Password123!`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
+					repo: mockRepo,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
+
+				mockRepo.On(`Now`).Return(timeNow).Once()
+
+				mockRepo.On(`RandomString`, 12).Return(`123456789ABC`).Once()
+
+				password := shared.MD5(`Password123!` + `123456789ABC`)
+				mockUserData := &entity.User{
+					PhoneNumber: `+621234567890`,
+					FullName:    `User123`,
+					Password:    password,
+					AccountSalt: `123456789ABC`,
+					CreatedAt:   now,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
 
 				return uc
 			},
@@ -217,6 +449,10 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 					PhoneNumber: `+621234567890`,
 					FullName:    `User123`,
 					Password:    `Password123!`,
+					RequestMeta: user.RequestMeta{
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+					},
 				},
 			},
 			want: &user.UserRegistrationResponse{
@@ -228,13 +464,16 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 				mockRepo := new(mocks.Repository)
 
 				uc := &userUsecaseCtx{
+					cfg:  &config.Config{},
 					repo: mockRepo,
 				}
 
+				mockTransaction(mockRepo)
+
 				timeNow := time.Now()
 				now := shared.UTC7(timeNow)
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, nil).Once()
+				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+621234567890`).Return(nil, repository.ErrNotFound).Once()
 
 				mockRepo.On(`Now`).Return(timeNow).Once()
 
@@ -251,6 +490,21 @@ func Test_userUsecaseCtx_UserRegistration(t *testing.T) {
 				}
 				mockRepo.On(`Create`, mock.Anything, mockUserData).Return(nil).Once()
 
+				mockRepo.On(`CreateAuditLog`, mock.Anything, &entity.AuditLog{
+					ActorRole: entity.RoleUser,
+					Action:    entity.AuditActionRegistered,
+					Changes:   `{"full_name":{"old":"","new":"User123"},"password":{"old":"","new":"[redacted]"},"phone_number":{"old":"","new":"+621234567890"}}`,
+					IPAddress: `127.0.0.1`,
+					RequestID: `request-1`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, &entity.OutboxEvent{
+					Type:      entity.EventUserRegistered,
+					Payload:   `{"user_id":0,"full_name":"User123","phone_number":"**********890"}`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
 				return uc
 			},
 		},
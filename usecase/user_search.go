@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+	"math"
+
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+func (u *userUsecaseCtx) SearchUsers(ctx context.Context, form *user.SearchUsersRequest) (*user.SearchUsersResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	matches, err := u.repo.SearchUsers(ctx, form.Filter())
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.SearchUsersResponse{
+		Users: make([]user.UserSearchResult, 0, len(matches)),
+	}
+	for _, match := range matches {
+		res.Users = append(res.Users, user.UserSearchResult{
+			ID:          match.ID,
+			FullName:    match.FullName,
+			PhoneNumber: match.PhoneNumber,
+			// Postgres computes the score as a float4, which would show 0.6
+			// as 0.6000000238.
+			Score: math.Round(match.Score*1000) / 1000,
+		})
+	}
+
+	return res, nil
+}
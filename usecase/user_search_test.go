@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_SearchUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		form    *user.SearchUsersRequest
+		want    *user.SearchUsersResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name:    `TestSearchUsers-QueryTooShort`,
+			form:    &user.SearchUsersRequest{Q: ` b `},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Search query length must be between 2 to 100 characters",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name:    `TestSearchUsers-QueryWithoutLetters`,
+			form:    &user.SearchUsersRequest{Q: `--`},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Search query must contain letters or digits",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name:    `TestSearchUsers-LimitTooLarge`,
+			form:    &user.SearchUsersRequest{Q: `budi`, Limit: 101},
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Limit must be between 1 to 100",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name:    `TestSearchUsers-RepositoryError`,
+			form:    &user.SearchUsersRequest{Q: `budi`},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`connection refused`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+				mockRepo.On(`SearchUsers`, mock.Anything, mock.Anything).Return(nil, errors.New(`connection refused`))
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name: `TestSearchUsers-Success`,
+			form: &user.SearchUsersRequest{Q: ` budi `, Offset: 20},
+			want: &user.SearchUsersResponse{
+				Users: []user.UserSearchResult{
+					{ID: 3, FullName: `Budi Santoso`, PhoneNumber: `+628123456783`, Score: 0.6},
+				},
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+				mockRepo.On(`SearchUsers`, mock.Anything, entity.UserSearch{Query: `budi`, Limit: 20, Offset: 20}).Return([]entity.UserMatch{
+					{ID: 3, FullName: `Budi Santoso`, PhoneNumber: `+628123456783`, Score: float64(float32(0.6))},
+				}, nil)
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+
+			got, err := u.SearchUsers(context.Background(), tt.form)
+			if tt.wantErr {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+// mockTransaction makes WithTransaction run the callback directly.
+func mockTransaction(mockRepo *mocks.Repository) {
+	mockRepo.On(`WithTransaction`, mock.Anything, mock.Anything).Return(func(ctx context.Context, fn func(ctx context.Context) error) error {
+		return fn(ctx)
+	})
+}
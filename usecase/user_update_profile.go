@@ -2,8 +2,12 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
 )
@@ -14,47 +18,88 @@ func (u *userUsecaseCtx) UpdateProfile(ctx context.Context, form *user.UpdatePro
 		return err
 	}
 
-	existsUser, err := u.repo.GetUserByID(ctx, userID)
-	if err != nil {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
-		}
-	}
-	if existsUser == nil {
+	if form.Version == 0 && u.cfg.RequireIfMatch {
 		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusForbidden,
-			ErrorMessage: "This user does not exists",
+			ErrorCode:    http.StatusPreconditionRequired,
+			ErrorMessage: "If-Match header is required",
 		}
 	}
 
-	if form.PhoneNumber != `` {
-		existsData, err := u.repo.GetUserByPhoneNumber(ctx, form.PhoneNumber)
+	err = u.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		existsUser, err := u.repo.GetUserByID(ctx, userID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return errUserNotFound
+		}
 		if err != nil {
-			return &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
-			}
+			return shared.Internal(err)
 		}
-		if existsData != nil {
-			return &shared.ErrorMessage{
-				ErrorCode:    http.StatusConflict,
-				ErrorMessage: "Phone number already exists",
-			}
+		if form.Version > 0 && form.Version != existsUser.Version {
+			return errProfileModified
 		}
-	}
 
-	timeNow := shared.UTC7(u.repo.Now())
-	existsUser.PhoneNumber = form.PhoneNumber
-	existsUser.FullName = form.FullName
-	existsUser.UpdatedAt = &timeNow
-	err = u.repo.UpdateProfile(ctx, existsUser)
-	if err != nil {
-		return &shared.ErrorMessage{
-			ErrorCode:    http.StatusUnprocessableEntity,
-			ErrorMessage: "Internal server error",
+		// Sending the current phone number is not a change.
+		if form.PhoneNumber != `` && form.PhoneNumber != existsUser.PhoneNumber {
+			return errPhoneNumberChangeRequired
 		}
+
+		// Empty fields are left unchanged by the update.
+		changes := map[string]entity.AuditChange{}
+		if form.FullName != `` && form.FullName != existsUser.FullName {
+			changes[`full_name`] = entity.AuditChange{Old: existsUser.FullName, New: form.FullName}
+		}
+
+		timeNow := shared.UTC7(u.repo.Now())
+		if form.FullName != `` {
+			existsUser.FullName = form.FullName
+		}
+		existsUser.UpdatedAt = &timeNow
+		return u.saveProfile(ctx, form.RequestMeta, form.ActorID, userID, existsUser, changes, timeNow)
+	})
+	if err != nil {
+		return transactionError(err)
 	}
 
 	return nil
 }
+
+var errProfileModified = &shared.ErrorMessage{
+	ErrorCode:    http.StatusPreconditionFailed,
+	ErrorMessage: "Profile has been modified, please reload it and try again",
+}
+
+// saveProfile writes existsUser, which already holds the updated profile of
+// userID, and records changes in the audit log and the outbox.
+func (u *userUsecaseCtx) saveProfile(ctx context.Context, meta user.RequestMeta, actorID int, userID int, existsUser *entity.User, changes map[string]entity.AuditChange, at time.Time) error {
+	err := u.repo.UpdateProfile(ctx, existsUser)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return errProfileModified
+	}
+	if repository.IsConflict(err) {
+		return errPhoneNumberExists
+	}
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	err = u.recordChange(ctx, meta, actorID, userID, entity.AuditActionProfileUpdated, changes, at)
+	if err != nil {
+		return err
+	}
+
+	// An update that changes nothing is audited but not published.
+	if len(changes) == 0 {
+		return nil
+	}
+
+	event := entity.ProfileUpdatedEvent{
+		UserID:            userID,
+		FullName:          existsUser.FullName,
+		PhoneNumber:       maskPhoneNumber(existsUser.PhoneNumber),
+		DateOfBirth:       existsUser.DateOfBirth,
+		Gender:            existsUser.Gender,
+		Address:           existsUser.Address,
+		PreferredLanguage: existsUser.PreferredLanguage,
+		Changes:           eventChanges(changes),
+	}
+	return u.emitEvent(ctx, entity.EventProfileUpdated, userID, event, at)
+}
@@ -7,10 +7,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/sawitpro/technical_test/config"
 	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
 	"github.com/sawitpro/technical_test/repository/mocks"
 	"github.com/sawitpro/technical_test/shared"
 	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -19,6 +22,9 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 		form   *user.UpdateProfileRequest
 		userID int
 	}
+
+	cfg := &config.Config{}
+
 	tests := []struct {
 		name    string
 		args    args
@@ -75,17 +81,17 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 				userID: 1,
 			},
 			wantErr: true,
-			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusUnprocessableEntity,
-				ErrorMessage: "Internal server error",
-			},
+			err:     shared.Internal(errors.New(`error`)),
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
 				u := &userUsecaseCtx{
 					repo: mockRepo,
+					cfg:  cfg,
 				}
 
+				mockTransaction(mockRepo)
+
 				mockRepo.On("GetUserByID", mock.Anything, 1).Return(nil, errors.New(`error`)).Once()
 
 				return u
@@ -102,7 +108,7 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 			},
 			wantErr: true,
 			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusForbidden,
+				ErrorCode:    http.StatusNotFound,
 				ErrorMessage: "This user does not exists",
 			},
 			before: func() *userUsecaseCtx {
@@ -110,15 +116,18 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 
 				u := &userUsecaseCtx{
 					repo: mockRepo,
+					cfg:  cfg,
 				}
 
-				mockRepo.On("GetUserByID", mock.Anything, 1).Return(nil, nil).Once()
+				mockTransaction(mockRepo)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
 
 				return u
 			},
 		},
 		{
-			name: "TestUpdateProfile-PhoneNumberExists",
+			name: "TestUpdateProfile-PhoneNumberChangeRequired",
 			args: args{
 				form: &user.UpdateProfileRequest{
 					PhoneNumber: `+62123456789`,
@@ -127,20 +136,58 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 				userID: 1,
 			},
 			wantErr: true,
-			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusConflict,
-				ErrorMessage: "Phone number already exists",
+			err:     errPhoneNumberChangeRequired,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456780`}, nil).Once()
+
+				return u
 			},
+		},
+		{
+			// An omitted phone number leaves it unchanged.
+			name: "TestUpdateProfile-NameOnly",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					FullName: `user123`,
+				},
+				userID: 1,
+			},
+			wantErr: false,
+			err:     nil,
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
 				u := &userUsecaseCtx{
 					repo: mockRepo,
+					cfg:  cfg,
 				}
 
-				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{}, nil).Once()
+				mockTransaction(mockRepo)
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(&entity.User{}, nil).Once()
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456780`, FullName: `user`}, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockUserData := &entity.User{
+					PhoneNumber: `+62123456780`,
+					FullName:    `user123`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(nil).Once()
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(nil).Once()
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, mock.Anything).Return(nil).Once()
 
 				return u
 			},
@@ -155,23 +202,153 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 				userID: 1,
 			},
 			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`}, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockUserData := &entity.User{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(errors.New(`error`)).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestUpdateProfile-IfMatchRequired",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+				},
+				userID: 1,
+			},
+			wantErr: true,
 			err: &shared.ErrorMessage{
-				ErrorCode:    http.StatusConflict,
-				ErrorMessage: "Phone number already exists",
+				ErrorCode:    http.StatusPreconditionRequired,
+				ErrorMessage: "If-Match header is required",
+			},
+			before: func() *userUsecaseCtx {
+				u := &userUsecaseCtx{
+					cfg: &config.Config{
+						RequireIfMatch: true,
+					},
+				}
+
+				return u
+			},
+		},
+		{
+			name: "TestUpdateProfile-VersionMismatch",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					Version:     1,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     errProfileModified,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`, Version: 2}, nil).Once()
+
+				return u
+			},
+		},
+		{
+			// If-Match: * satisfies REQUIRE_IF_MATCH and matches any version.
+			name: "TestUpdateProfile-AnyVersion",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					Version:     shared.AnyVersion,
+				},
+				userID: 1,
 			},
+			wantErr: false,
+			err:     nil,
 			before: func() *userUsecaseCtx {
 				mockRepo := new(mocks.Repository)
 
 				u := &userUsecaseCtx{
 					repo: mockRepo,
+					cfg:  &config.Config{RequireIfMatch: true},
 				}
 
+				mockTransaction(mockRepo)
+
 				timeNow := time.Now()
 				now := shared.UTC7(timeNow)
 
-				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{}, nil).Once()
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`, FullName: `user123`, Version: 2}, nil).Once()
 
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(nil, nil).Once()
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockUserData := &entity.User{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					UpdatedAt:   &now,
+					Version:     2,
+				}
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(nil).Once()
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(nil).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestUpdateProfile-UpdateVersionConflict",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					Version:     2,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     errProfileModified,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`, Version: 2}, nil).Once()
 
 				mockRepo.On(`Now`).Return(timeNow)
 
@@ -179,8 +356,89 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 					PhoneNumber: `+62123456789`,
 					FullName:    `user123`,
 					UpdatedAt:   &now,
+					Version:     2,
 				}
-				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(errors.New(`error`)).Once()
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(repository.ErrVersionConflict).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestUpdateProfile-UpdatePhoneNumberConflict",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					Version:     2,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     errPhoneNumberExists,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`, Version: 2}, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockUserData := &entity.User{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					UpdatedAt:   &now,
+					Version:     2,
+				}
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(&repository.ConflictError{Err: errors.New(`error`)}).Once()
+
+				return u
+			},
+		},
+		{
+			name: "TestUpdateProfile-AuditLogError",
+			args: args{
+				form: &user.UpdateProfileRequest{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+				},
+				userID: 1,
+			},
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				u := &userUsecaseCtx{
+					repo: mockRepo,
+					cfg:  cfg,
+				}
+
+				mockTransaction(mockRepo)
+
+				timeNow := time.Now()
+				now := shared.UTC7(timeNow)
+
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`}, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockUserData := &entity.User{
+					PhoneNumber: `+62123456789`,
+					FullName:    `user123`,
+					UpdatedAt:   &now,
+				}
+				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(nil).Once()
+
+				mockRepo.On(`CreateAuditLog`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
 
 				return u
 			},
@@ -191,6 +449,11 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 				form: &user.UpdateProfileRequest{
 					PhoneNumber: `+62123456789`,
 					FullName:    `user123`,
+					RequestMeta: user.RequestMeta{
+						Role:      entity.RoleUser,
+						IPAddress: `127.0.0.1`,
+						RequestID: `request-1`,
+					},
 				},
 				userID: 1,
 			},
@@ -201,14 +464,15 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 
 				u := &userUsecaseCtx{
 					repo: mockRepo,
+					cfg:  cfg,
 				}
 
+				mockTransaction(mockRepo)
+
 				timeNow := time.Now()
 				now := shared.UTC7(timeNow)
 
-				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{}, nil).Once()
-
-				mockRepo.On(`GetUserByPhoneNumber`, mock.Anything, `+62123456789`).Return(nil, nil).Once()
+				mockRepo.On("GetUserByID", mock.Anything, 1).Return(&entity.User{PhoneNumber: `+62123456789`}, nil).Once()
 
 				mockRepo.On(`Now`).Return(timeNow)
 
@@ -219,6 +483,24 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 				}
 				mockRepo.On(`UpdateProfile`, mock.Anything, mockUserData).Return(nil).Once()
 
+				mockRepo.On(`CreateAuditLog`, mock.Anything, &entity.AuditLog{
+					ActorID:   1,
+					ActorRole: entity.RoleUser,
+					Action:    entity.AuditActionProfileUpdated,
+					UserID:    1,
+					Changes:   `{"full_name":{"old":"","new":"user123"}}`,
+					IPAddress: `127.0.0.1`,
+					RequestID: `request-1`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
+				mockRepo.On(`CreateOutboxEvent`, mock.Anything, &entity.OutboxEvent{
+					Type:      entity.EventProfileUpdated,
+					UserID:    1,
+					Payload:   `{"user_id":1,"full_name":"user123","phone_number":"*********789","date_of_birth":null,"gender":null,"address":null,"preferred_language":null,"changes":{"full_name":{"old":"","new":"user123"}}}`,
+					CreatedAt: now,
+				}).Return(nil).Once()
+
 				return u
 			},
 		},
@@ -226,7 +508,8 @@ func Test_userUsecaseCtx_UpdateProfile(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			u := tt.before()
-			if err := u.UpdateProfile(context.Background(), tt.args.form, tt.args.userID); (err != nil) != tt.wantErr {
+			err := u.UpdateProfile(context.Background(), tt.args.form, tt.args.userID)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
 				t.Errorf("userUsecaseCtx.UpdateProfile() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -0,0 +1,244 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+)
+
+var errWebhookNotFound = shared.NotFound("This webhook does not exists")
+
+var errWebhookDeliveryNotFound = shared.NotFound("This webhook delivery does not exists")
+
+func (u *userUsecaseCtx) CreateWebhook(ctx context.Context, form *user.CreateWebhookRequest) (*user.CreateWebhookResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	secret := form.Secret
+	if secret == `` {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, shared.Internal(err)
+		}
+	}
+
+	var eventTypes []string
+	for _, eventType := range form.EventTypes {
+		if !contains(eventTypes, eventType) {
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+
+	subscription := &entity.WebhookSubscription{
+		URL:        form.URL,
+		EventTypes: strings.Join(eventTypes, `,`),
+		Secret:     secret,
+		CreatedAt:  shared.UTC7(u.repo.Now()),
+	}
+	err = u.repo.CreateWebhookSubscription(ctx, subscription)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.CreateWebhookResponse{
+		WebhookResponse: newWebhookResponse(subscription),
+		Secret:          subscription.Secret,
+	}
+	return res, nil
+}
+
+func (u *userUsecaseCtx) ListWebhooks(ctx context.Context) (*user.ListWebhooksResponse, error) {
+	subscriptions, err := u.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.ListWebhooksResponse{
+		Webhooks: make([]user.WebhookResponse, 0, len(subscriptions)),
+	}
+	for i := range subscriptions {
+		res.Webhooks = append(res.Webhooks, newWebhookResponse(&subscriptions[i]))
+	}
+
+	return res, nil
+}
+
+// DeleteWebhook removes the subscription. Its pending deliveries fail on
+// their next attempt.
+func (u *userUsecaseCtx) DeleteWebhook(ctx context.Context, subscriptionID int) error {
+	if _, err := u.getWebhook(ctx, subscriptionID); err != nil {
+		return err
+	}
+
+	err := u.repo.DeleteWebhookSubscription(ctx, subscriptionID)
+	if err != nil {
+		return shared.Internal(err)
+	}
+
+	return nil
+}
+
+func (u *userUsecaseCtx) ListWebhookDeliveries(ctx context.Context, form *user.ListWebhookDeliveriesRequest, subscriptionID int) (*user.ListWebhookDeliveriesResponse, error) {
+	var err error
+	if err = form.Validation(); err != nil {
+		return nil, err
+	}
+
+	if _, err = u.getWebhook(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := u.repo.ListWebhookDeliveries(ctx, subscriptionID, form.PageSize(), form.Offset)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.ListWebhookDeliveriesResponse{
+		Deliveries: make([]user.WebhookDeliveryResponse, 0, len(deliveries)),
+	}
+	for i := range deliveries {
+		res.Deliveries = append(res.Deliveries, newWebhookDeliveryResponse(&deliveries[i]))
+	}
+
+	return res, nil
+}
+
+func (u *userUsecaseCtx) GetWebhookDelivery(ctx context.Context, deliveryID int) (*user.WebhookDeliveryDetailResponse, error) {
+	delivery, err := u.getWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := u.repo.ListWebhookAttempts(ctx, deliveryID)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := &user.WebhookDeliveryDetailResponse{
+		WebhookDeliveryResponse: newWebhookDeliveryResponse(delivery),
+		Payload:                 json.RawMessage(delivery.Payload),
+		Attempts:                make([]user.WebhookAttemptResponse, 0, len(attempts)),
+	}
+	for _, attempt := range attempts {
+		res.Attempts = append(res.Attempts, user.WebhookAttemptResponse{
+			StatusCode: attempt.StatusCode,
+			Error:      attempt.Error,
+			DurationMS: attempt.DurationMS,
+			CreatedAt:  attempt.CreatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// ReplayWebhookDelivery queues the payload of a delivery to be sent again
+// as a new delivery, whatever the outcome of the original.
+func (u *userUsecaseCtx) ReplayWebhookDelivery(ctx context.Context, deliveryID int) (*user.WebhookDeliveryResponse, error) {
+	delivery, err := u.getWebhookDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = u.getWebhook(ctx, delivery.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	timeNow := shared.UTC7(u.repo.Now())
+	replay := &entity.WebhookDelivery{
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Payload:        delivery.Payload,
+		Status:         entity.WebhookDeliveryPending,
+		NextAttemptAt:  timeNow,
+		CreatedAt:      timeNow,
+	}
+	err = u.repo.CreateWebhookDelivery(ctx, replay)
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	res := newWebhookDeliveryResponse(replay)
+	return &res, nil
+}
+
+func (u *userUsecaseCtx) getWebhook(ctx context.Context, subscriptionID int) (*entity.WebhookSubscription, error) {
+	subscription, err := u.repo.GetWebhookSubscription(ctx, subscriptionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errWebhookNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	return subscription, nil
+}
+
+func (u *userUsecaseCtx) getWebhookDelivery(ctx context.Context, deliveryID int) (*entity.WebhookDelivery, error) {
+	delivery, err := u.repo.GetWebhookDelivery(ctx, deliveryID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, errWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, shared.Internal(err)
+	}
+
+	return delivery, nil
+}
+
+func newWebhookResponse(subscription *entity.WebhookSubscription) user.WebhookResponse {
+	return user.WebhookResponse{
+		ID:         subscription.ID,
+		URL:        subscription.URL,
+		EventTypes: strings.Split(subscription.EventTypes, `,`),
+		CreatedAt:  subscription.CreatedAt,
+	}
+}
+
+func newWebhookDeliveryResponse(delivery *entity.WebhookDelivery) user.WebhookDeliveryResponse {
+	res := user.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Status:         delivery.Status,
+		AttemptCount:   delivery.Attempts,
+		LastStatusCode: delivery.LastStatusCode,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		DeliveredAt:    delivery.DeliveredAt,
+	}
+	if delivery.Status == entity.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		res.NextAttemptAt = &nextAttemptAt
+	}
+
+	return res
+}
+
+// newWebhookSecret returns 32 random bytes, hex encoded.
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return ``, err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
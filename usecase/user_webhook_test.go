@@ -0,0 +1,382 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/repository/mocks"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_userUsecaseCtx_CreateWebhook(t *testing.T) {
+	type args struct {
+		form *user.CreateWebhookRequest
+	}
+
+	timeNow := time.Now()
+
+	tests := []struct {
+		name    string
+		args    args
+		want    *user.CreateWebhookResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name: `TestCreateWebhook-URLInvalid`,
+			args: args{
+				form: &user.CreateWebhookRequest{
+					URL:        `ftp://example.com`,
+					EventTypes: []string{entity.EventUserRegistered},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Url must be an absolute http or https URL",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestCreateWebhook-EventTypeInvalid`,
+			args: args{
+				form: &user.CreateWebhookRequest{
+					URL:        `https://example.com/hook`,
+					EventTypes: []string{`UserDeleted`},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Event types must be UserRegistered, ProfileUpdated, UserLoggedIn or PasswordChanged",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestCreateWebhook-SecretTooShort`,
+			args: args{
+				form: &user.CreateWebhookRequest{
+					URL:        `https://example.com/hook`,
+					EventTypes: []string{entity.EventUserRegistered},
+					Secret:     `short`,
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusBadRequest,
+				ErrorMessage: "Secret must be between 16 to 255 characters",
+			},
+			before: func() *userUsecaseCtx {
+				return &userUsecaseCtx{}
+			},
+		},
+		{
+			name: `TestCreateWebhook-CreateError`,
+			args: args{
+				form: &user.CreateWebhookRequest{
+					URL:        `https://example.com/hook`,
+					EventTypes: []string{entity.EventUserRegistered},
+				},
+			},
+			want:    nil,
+			wantErr: true,
+			err:     shared.Internal(errors.New(`error`)),
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				mockRepo.On(`CreateWebhookSubscription`, mock.Anything, mock.Anything).Return(errors.New(`error`)).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name: `TestCreateWebhook-Success`,
+			args: args{
+				form: &user.CreateWebhookRequest{
+					URL:        `https://example.com/hook`,
+					EventTypes: []string{entity.EventUserRegistered, entity.EventProfileUpdated, entity.EventUserRegistered},
+					Secret:     `0123456789abcdef`,
+				},
+			},
+			want: &user.CreateWebhookResponse{
+				WebhookResponse: user.WebhookResponse{
+					ID:         1,
+					URL:        `https://example.com/hook`,
+					EventTypes: []string{entity.EventUserRegistered, entity.EventProfileUpdated},
+					CreatedAt:  shared.UTC7(timeNow),
+				},
+				Secret: `0123456789abcdef`,
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				subscription := &entity.WebhookSubscription{
+					URL:        `https://example.com/hook`,
+					EventTypes: `UserRegistered,ProfileUpdated`,
+					Secret:     `0123456789abcdef`,
+					CreatedAt:  shared.UTC7(timeNow),
+				}
+				mockRepo.On(`CreateWebhookSubscription`, mock.Anything, subscription).Run(func(args mock.Arguments) {
+					args.Get(1).(*entity.WebhookSubscription).ID = 1
+				}).Return(nil).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.CreateWebhook(context.Background(), tt.args.form)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.CreateWebhook() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.CreateWebhook() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userUsecaseCtx_CreateWebhookGeneratesSecret(t *testing.T) {
+	mockRepo := new(mocks.Repository)
+	mockRepo.On(`Now`).Return(time.Now())
+	mockRepo.On(`CreateWebhookSubscription`, mock.Anything, mock.Anything).Return(nil).Once()
+
+	u := &userUsecaseCtx{repo: mockRepo}
+	form := &user.CreateWebhookRequest{
+		URL:        `https://example.com/hook`,
+		EventTypes: []string{entity.EventUserRegistered},
+	}
+
+	first, err := u.CreateWebhook(context.Background(), form)
+	assert.NoError(t, err)
+	assert.Len(t, first.Secret, 64)
+
+	mockRepo.On(`CreateWebhookSubscription`, mock.Anything, mock.Anything).Return(nil).Once()
+	second, _ := u.CreateWebhook(context.Background(), form)
+	assert.NotEqual(t, first.Secret, second.Secret)
+}
+
+func Test_userUsecaseCtx_DeleteWebhook(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		before func() *userUsecaseCtx
+	}{
+		{
+			name: `TestDeleteWebhook-NotFound`,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This webhook does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`GetWebhookSubscription`, mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name: `TestDeleteWebhook-Success`,
+			err:  nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`GetWebhookSubscription`, mock.Anything, 1).Return(&entity.WebhookSubscription{ID: 1}, nil).Once()
+
+				mockRepo.On(`DeleteWebhookSubscription`, mock.Anything, 1).Return(nil).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			err := u.DeleteWebhook(context.Background(), 1)
+			assert.Equal(t, tt.err, err)
+		})
+	}
+}
+
+func Test_userUsecaseCtx_ReplayWebhookDelivery(t *testing.T) {
+	timeNow := time.Now()
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	original := &entity.WebhookDelivery{
+		ID:             3,
+		SubscriptionID: 1,
+		EventID:        7,
+		EventType:      entity.EventUserRegistered,
+		Payload:        `{"id":7}`,
+		Status:         entity.WebhookDeliveryFailed,
+		Attempts:       8,
+		LastStatusCode: 500,
+		CreatedAt:      createdAt,
+	}
+
+	tests := []struct {
+		name    string
+		want    *user.WebhookDeliveryResponse
+		wantErr bool
+		err     error
+		before  func() *userUsecaseCtx
+	}{
+		{
+			name:    `TestReplayWebhookDelivery-NotFound`,
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This webhook delivery does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`GetWebhookDelivery`, mock.Anything, 3).Return(nil, repository.ErrNotFound).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name:    `TestReplayWebhookDelivery-SubscriptionDeleted`,
+			want:    nil,
+			wantErr: true,
+			err: &shared.ErrorMessage{
+				ErrorCode:    http.StatusNotFound,
+				ErrorMessage: "This webhook does not exists",
+			},
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`GetWebhookDelivery`, mock.Anything, 3).Return(original, nil).Once()
+
+				mockRepo.On(`GetWebhookSubscription`, mock.Anything, 1).Return(nil, repository.ErrNotFound).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+		{
+			name: `TestReplayWebhookDelivery-Success`,
+			want: &user.WebhookDeliveryResponse{
+				ID:             4,
+				SubscriptionID: 1,
+				EventID:        7,
+				EventType:      entity.EventUserRegistered,
+				Status:         entity.WebhookDeliveryPending,
+				NextAttemptAt:  func() *time.Time { t := shared.UTC7(timeNow); return &t }(),
+				CreatedAt:      shared.UTC7(timeNow),
+			},
+			wantErr: false,
+			err:     nil,
+			before: func() *userUsecaseCtx {
+				mockRepo := new(mocks.Repository)
+
+				mockRepo.On(`GetWebhookDelivery`, mock.Anything, 3).Return(original, nil).Once()
+
+				mockRepo.On(`GetWebhookSubscription`, mock.Anything, 1).Return(&entity.WebhookSubscription{ID: 1}, nil).Once()
+
+				mockRepo.On(`Now`).Return(timeNow)
+
+				replay := &entity.WebhookDelivery{
+					SubscriptionID: 1,
+					EventID:        7,
+					EventType:      entity.EventUserRegistered,
+					Payload:        `{"id":7}`,
+					Status:         entity.WebhookDeliveryPending,
+					NextAttemptAt:  shared.UTC7(timeNow),
+					CreatedAt:      shared.UTC7(timeNow),
+				}
+				mockRepo.On(`CreateWebhookDelivery`, mock.Anything, replay).Run(func(args mock.Arguments) {
+					args.Get(1).(*entity.WebhookDelivery).ID = 4
+				}).Return(nil).Once()
+
+				return &userUsecaseCtx{repo: mockRepo}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.before()
+			got, err := u.ReplayWebhookDelivery(context.Background(), 3)
+			if (err != nil) != tt.wantErr || !assert.Equal(t, err, tt.err) {
+				t.Errorf("userUsecaseCtx.ReplayWebhookDelivery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("userUsecaseCtx.ReplayWebhookDelivery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_userUsecaseCtx_GetWebhookDelivery(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	mockRepo := new(mocks.Repository)
+	mockRepo.On(`GetWebhookDelivery`, mock.Anything, 3).Return(&entity.WebhookDelivery{
+		ID:             3,
+		SubscriptionID: 1,
+		EventID:        7,
+		EventType:      entity.EventUserRegistered,
+		Payload:        `{"id":7}`,
+		Status:         entity.WebhookDeliverySucceeded,
+		Attempts:       2,
+		LastStatusCode: 204,
+		CreatedAt:      createdAt,
+		DeliveredAt:    &createdAt,
+	}, nil).Once()
+	mockRepo.On(`ListWebhookAttempts`, mock.Anything, 3).Return([]entity.WebhookAttempt{
+		{ID: 1, DeliveryID: 3, StatusCode: 500, Error: `responded 500 Internal Server Error`, DurationMS: 12, CreatedAt: createdAt},
+		{ID: 2, DeliveryID: 3, StatusCode: 204, DurationMS: 8, CreatedAt: createdAt},
+	}, nil).Once()
+
+	u := &userUsecaseCtx{repo: mockRepo}
+	got, err := u.GetWebhookDelivery(context.Background(), 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &user.WebhookDeliveryDetailResponse{
+		WebhookDeliveryResponse: user.WebhookDeliveryResponse{
+			ID:             3,
+			SubscriptionID: 1,
+			EventID:        7,
+			EventType:      entity.EventUserRegistered,
+			Status:         entity.WebhookDeliverySucceeded,
+			AttemptCount:   2,
+			LastStatusCode: 204,
+			CreatedAt:      createdAt,
+			DeliveredAt:    &createdAt,
+		},
+		Payload: json.RawMessage(`{"id":7}`),
+		Attempts: []user.WebhookAttemptResponse{
+			{StatusCode: 500, Error: `responded 500 Internal Server Error`, DurationMS: 12, CreatedAt: createdAt},
+			{StatusCode: 204, DurationMS: 8, CreatedAt: createdAt},
+		},
+	}, got)
+}
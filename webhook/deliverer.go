@@ -0,0 +1,184 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+const (
+	baseBackoff = 10 * time.Second
+	maxBackoff  = time.Hour
+
+	// maxErrorLength fits the error columns of the delivery tables.
+	maxErrorLength = 255
+)
+
+// Deliverer posts queued webhook deliveries to their subscriptions. A
+// delivery that fails is retried with exponential backoff until it has
+// been attempted maxAttempts times, and every request is recorded as an
+// attempt. Deliveries are independent, so a failing delivery does not hold
+// back later events for the same subscription.
+//
+// Only one deliverer should run per database; several would each send the
+// same due deliveries.
+type Deliverer struct {
+	repo        repository.Repository
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+}
+
+func NewDeliverer(repo repository.Repository, client *http.Client, interval time.Duration, maxAttempts int) *Deliverer {
+	return &Deliverer{
+		repo:        repo,
+		client:      client,
+		interval:    interval,
+		batchSize:   100,
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run sends due deliveries every interval until ctx is done.
+func (d *Deliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Deliver(ctx); err != nil {
+			log.Printf(`Webhook delivery error %s`, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Deliver sends one batch of due deliveries and returns how many were
+// attempted.
+func (d *Deliverer) Deliver(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ListDueWebhookDeliveries(ctx, shared.UTC7(d.repo.Now()), d.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		if err = d.attempt(ctx, &deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// attempt sends delivery once and saves the outcome in the organization of
+// the delivery.
+func (d *Deliverer) attempt(ctx context.Context, delivery *entity.WebhookDelivery) error {
+	ctx = tenant.WithID(ctx, delivery.OrganizationID)
+
+	subscription, err := d.repo.GetWebhookSubscription(ctx, delivery.SubscriptionID)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	start := d.repo.Now()
+	attempt := &entity.WebhookAttempt{
+		DeliveryID: delivery.ID,
+	}
+	if subscription == nil {
+		attempt.Error = `subscription deleted`
+	} else {
+		attempt.StatusCode, err = d.send(ctx, subscription, delivery, start)
+		if err != nil {
+			attempt.Error = truncate(err.Error(), maxErrorLength)
+		}
+	}
+	now := shared.UTC7(d.repo.Now())
+	attempt.DurationMS = int(now.Sub(start) / time.Millisecond)
+	attempt.CreatedAt = now
+
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	switch {
+	case attempt.Error == ``:
+		delivery.Status = entity.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+	case subscription == nil || delivery.Attempts >= d.maxAttempts:
+		delivery.Status = entity.WebhookDeliveryFailed
+	default:
+		delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+	}
+
+	return d.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := d.repo.CreateWebhookAttempt(ctx, attempt); err != nil {
+			return err
+		}
+		return d.repo.UpdateWebhookDelivery(ctx, delivery)
+	})
+}
+
+// send posts the delivery payload and returns the response status. Any
+// status other than 2xx is an error.
+func (d *Deliverer) send(ctx context.Context, subscription *entity.WebhookSubscription, delivery *entity.WebhookDelivery, at time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := at.Unix()
+	req.Header.Set(`Content-Type`, `application/json`)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 1<<20))
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf(`responded %s`, res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Backoff returns the wait before retrying a delivery that has failed
+// attempts times: 10s doubling up to an hour.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		return maxBackoff
+	}
+	return backoff
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,263 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/outbox"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// clockRepository is a memory repository whose clock the test moves.
+type clockRepository struct {
+	repository.Repository
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (r *clockRepository) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+func (r *clockRepository) advance(d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now = r.now.Add(d)
+}
+
+// receiver is a local webhook endpoint answering with the queued statuses,
+// then 204, and verifying every signature against the test clock.
+type receiver struct {
+	t        *testing.T
+	secret   string
+	now      func() time.Time
+	mu       sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	err := Verify(rc.secret, r.Header.Get(HeaderTimestamp), body, r.Header.Get(HeaderSignature), rc.now(), 5*time.Minute)
+	assert.NoError(rc.t, err)
+
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+
+	status := http.StatusNoContent
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	ctx := context.Background()
+
+	setup := func(t *testing.T, statuses ...int) (*clockRepository, *receiver, *entity.WebhookSubscription) {
+		repo := &clockRepository{Repository: repository.NewMemoryRepository(), now: time.Now()}
+		rc := &receiver{t: t, secret: `0123456789abcdef`, now: repo.Now, statuses: statuses}
+		server := httptest.NewServer(rc)
+		t.Cleanup(server.Close)
+
+		subscription := &entity.WebhookSubscription{
+			URL:        server.URL,
+			EventTypes: entity.EventUserRegistered + `,` + entity.EventProfileUpdated,
+			Secret:     rc.secret,
+		}
+		if err := repo.CreateWebhookSubscription(ctx, subscription); err != nil {
+			t.Fatal(err)
+		}
+
+		return repo, rc, subscription
+	}
+
+	publish := func(t *testing.T, repo repository.Repository, msg outbox.Message) {
+		if err := NewSink(repo).Publish(ctx, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	registered := outbox.Message{ID: 1, Type: entity.EventUserRegistered, OrganizationID: 1, UserID: 1, Payload: json.RawMessage(`{"user_id":1}`)}
+
+	t.Run(`SinkQueuesMatchingSubscriptionsOnce`, func(t *testing.T) {
+		repo, _, subscription := setup(t)
+		other := &entity.WebhookSubscription{URL: `http://localhost`, EventTypes: entity.EventUserLoggedIn, Secret: `0123456789abcdef`}
+		assert.NoError(t, repo.CreateWebhookSubscription(ctx, other))
+
+		publish(t, repo, registered)
+		publish(t, repo, registered)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, 1, deliveries[0].EventID)
+			assert.Equal(t, entity.WebhookDeliveryPending, deliveries[0].Status)
+			assert.JSONEq(t, `{"id":1,"type":"UserRegistered","organization_id":1,"user_id":1,"payload":{"user_id":1},"occurred_at":"0001-01-01T00:00:00Z"}`, deliveries[0].Payload)
+		}
+		deliveries, _ = repo.ListWebhookDeliveries(ctx, other.ID, 10, 0)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run(`SinkSkipsOtherOrganizations`, func(t *testing.T) {
+		repo, _, subscription := setup(t)
+
+		elsewhere := registered
+		elsewhere.OrganizationID = 2
+		publish(t, repo, elsewhere)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		assert.Empty(t, deliveries)
+	})
+
+	t.Run(`DeliversSignedPayload`, func(t *testing.T) {
+		repo, rc, subscription := setup(t)
+		publish(t, repo, registered)
+
+		n, err := NewDeliverer(repo, http.DefaultClient, time.Second, 3).Deliver(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		if !assert.Len(t, deliveries, 1) || !assert.Len(t, rc.requests, 1) {
+			return
+		}
+		delivery := deliveries[0]
+		assert.Equal(t, entity.WebhookDeliverySucceeded, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, delivery.LastStatusCode)
+		assert.NotNil(t, delivery.DeliveredAt)
+
+		req := rc.requests[0]
+		assert.Equal(t, `application/json`, req.Header.Get(`Content-Type`))
+		assert.Equal(t, entity.EventUserRegistered, req.Header.Get(HeaderEvent))
+		assert.Equal(t, `1`, req.Header.Get(HeaderDelivery))
+		assert.Equal(t, delivery.Payload, string(rc.bodies[0]))
+
+		attempts, _ := repo.ListWebhookAttempts(ctx, delivery.ID)
+		if assert.Len(t, attempts, 1) {
+			assert.Equal(t, http.StatusNoContent, attempts[0].StatusCode)
+			assert.Empty(t, attempts[0].Error)
+		}
+	})
+
+	t.Run(`RetriesWithBackoff`, func(t *testing.T) {
+		repo, rc, subscription := setup(t, http.StatusInternalServerError, http.StatusBadGateway)
+		publish(t, repo, registered)
+		d := NewDeliverer(repo, http.DefaultClient, time.Second, 5)
+
+		_, err := d.Deliver(ctx)
+		assert.NoError(t, err)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		assert.Equal(t, entity.WebhookDeliveryPending, deliveries[0].Status)
+		assert.Equal(t, http.StatusInternalServerError, deliveries[0].LastStatusCode)
+		assert.Equal(t, `responded 500 Internal Server Error`, deliveries[0].LastError)
+
+		// Not due until the first backoff has passed.
+		repo.advance(Backoff(1) - time.Second)
+		n, _ := d.Deliver(ctx)
+		assert.Equal(t, 0, n)
+
+		repo.advance(time.Second)
+		n, _ = d.Deliver(ctx)
+		assert.Equal(t, 1, n)
+
+		repo.advance(Backoff(2))
+		n, _ = d.Deliver(ctx)
+		assert.Equal(t, 1, n)
+
+		deliveries, _ = repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		assert.Equal(t, entity.WebhookDeliverySucceeded, deliveries[0].Status)
+		assert.Equal(t, 3, deliveries[0].Attempts)
+		assert.Len(t, rc.requests, 3)
+
+		attempts, _ := repo.ListWebhookAttempts(ctx, deliveries[0].ID)
+		if assert.Len(t, attempts, 3) {
+			assert.Equal(t, http.StatusInternalServerError, attempts[0].StatusCode)
+			assert.Equal(t, http.StatusBadGateway, attempts[1].StatusCode)
+			assert.Equal(t, http.StatusNoContent, attempts[2].StatusCode)
+		}
+	})
+
+	t.Run(`FailsAfterMaxAttempts`, func(t *testing.T) {
+		repo, _, subscription := setup(t, http.StatusInternalServerError, http.StatusInternalServerError)
+		publish(t, repo, registered)
+		d := NewDeliverer(repo, http.DefaultClient, time.Second, 2)
+
+		d.Deliver(ctx)
+		repo.advance(time.Hour)
+		d.Deliver(ctx)
+		repo.advance(time.Hour)
+		n, _ := d.Deliver(ctx)
+		assert.Equal(t, 0, n)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		assert.Equal(t, entity.WebhookDeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, 2, deliveries[0].Attempts)
+	})
+
+	t.Run(`FailsForDeletedSubscription`, func(t *testing.T) {
+		repo, rc, subscription := setup(t)
+		publish(t, repo, registered)
+		assert.NoError(t, repo.DeleteWebhookSubscription(ctx, subscription.ID))
+
+		NewDeliverer(repo, http.DefaultClient, time.Second, 5).Deliver(ctx)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		assert.Equal(t, entity.WebhookDeliveryFailed, deliveries[0].Status)
+		assert.Equal(t, `subscription deleted`, deliveries[0].LastError)
+		assert.Empty(t, rc.requests)
+	})
+	t.Run(`ClientRefusesPrivateAddresses`, func(t *testing.T) {
+		// The test server listens on 127.0.0.1.
+		repo, rc, subscription := setup(t)
+		publish(t, repo, registered)
+
+		NewDeliverer(repo, NewClient(time.Second), time.Second, 5).Deliver(ctx)
+
+		deliveries, _ := repo.ListWebhookDeliveries(ctx, subscription.ID, 10, 0)
+		if assert.Len(t, deliveries, 1) {
+			assert.Equal(t, entity.WebhookDeliveryPending, deliveries[0].Status)
+			assert.Zero(t, deliveries[0].LastStatusCode)
+			assert.Contains(t, deliveries[0].LastError, `127.0.0.1 is not a public address`)
+		}
+		assert.Empty(t, rc.requests)
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	for address, want := range map[string]bool{
+		`8.8.8.8`:          true,
+		`2001:4860::8888`:  true,
+		`127.0.0.1`:        false,
+		`::1`:              false,
+		`10.1.2.3`:         false,
+		`172.16.0.1`:       false,
+		`192.168.1.1`:      false,
+		`100.64.0.1`:       false,
+		`169.254.169.254`:  false,
+		`fe80::1`:          false,
+		`fd00::1`:          false,
+		`0.0.0.0`:          false,
+		`::ffff:127.0.0.1`: false,
+		`224.0.0.1`:        false,
+	} {
+		assert.Equal(t, want, isPublic(netip.MustParseAddr(address)), address)
+	}
+}
@@ -0,0 +1,57 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every webhook request.
+const (
+	HeaderEvent     = `X-Webhook-Event`
+	HeaderDelivery  = `X-Webhook-Delivery`
+	HeaderTimestamp = `X-Webhook-Timestamp`
+	HeaderSignature = `X-Webhook-Signature`
+)
+
+const signaturePrefix = `sha256=`
+
+var (
+	ErrInvalidSignature = errors.New(`webhook signature does not match`)
+	ErrStaleTimestamp   = errors.New(`webhook timestamp is outside the tolerance`)
+)
+
+// Sign returns the signature header value for a request body sent at
+// timestamp, in Unix seconds: the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the subscription secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte(`.`))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature and timestamp headers of a received webhook.
+// Requests signed more than tolerance away from now are rejected, so that a
+// captured request cannot be replayed later.
+func Verify(secret string, timestamp string, body []byte, signature string, now time.Time, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrStaleTimestamp
+	}
+
+	if !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"id":1}`)
+	signature := Sign(`secret`, now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	assert.Equal(t, `sha256=`, signature[:7])
+	assert.Len(t, signature, 7+64)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		signature string
+		now       time.Time
+		want      error
+	}{
+		{`Valid`, `secret`, timestamp, body, signature, now.Add(time.Minute), nil},
+		{`WrongSecret`, `other`, timestamp, body, signature, now, ErrInvalidSignature},
+		{`ChangedBody`, `secret`, timestamp, []byte(`{"id":2}`), signature, now, ErrInvalidSignature},
+		{`ChangedTimestamp`, `secret`, strconv.FormatInt(now.Unix()+1, 10), body, signature, now, ErrInvalidSignature},
+		{`MalformedTimestamp`, `secret`, `yesterday`, body, signature, now, ErrInvalidSignature},
+		{`Stale`, `secret`, timestamp, body, signature, now.Add(10 * time.Minute), ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.timestamp, tt.body, tt.signature, tt.now, 5*time.Minute)
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, Backoff(1))
+	assert.Equal(t, 20*time.Second, Backoff(2))
+	assert.Equal(t, 80*time.Second, Backoff(4))
+	assert.Equal(t, time.Hour, Backoff(20))
+}
@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/sawitpro/technical_test/entity"
+	"github.com/sawitpro/technical_test/outbox"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/shared"
+	"github.com/sawitpro/technical_test/tenant"
+)
+
+// sink queues a delivery of each outbox event to every subscription that
+// wants its type. The Deliverer sends them.
+type sink struct {
+	repo repository.Repository
+}
+
+// NewSink returns an outbox Sink that fans events out to the webhook
+// subscriptions of the organization they happened in. Publishing an event
+// again does not queue it twice.
+func NewSink(repo repository.Repository) outbox.Sink {
+	return &sink{repo: repo}
+}
+
+func (s *sink) Publish(ctx context.Context, msg outbox.Message) error {
+	ctx = tenant.WithID(ctx, msg.OrganizationID)
+
+	queued, err := s.repo.HasWebhookDeliveries(ctx, msg.ID)
+	if err != nil || queued {
+		return err
+	}
+
+	subscriptions, err := s.repo.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	now := shared.UTC7(s.repo.Now())
+	return s.repo.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, subscription := range subscriptions {
+			if !subscription.Subscribes(msg.Type) {
+				continue
+			}
+
+			err := s.repo.CreateWebhookDelivery(ctx, &entity.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        msg.ID,
+				EventType:      msg.Type,
+				Payload:        string(body),
+				Status:         entity.WebhookDeliveryPending,
+				NextAttemptAt:  now,
+				CreatedAt:      now,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sawitpro/technical_test/config"
+	"github.com/sawitpro/technical_test/job"
+	"github.com/sawitpro/technical_test/repository"
+	"github.com/sawitpro/technical_test/sms"
+)
+
+var errWorkerUsage = errors.New(`usage: main worker`)
+
+// RunWorker implements the `worker` subcommand, which runs background jobs
+// without serving the API until it is interrupted.
+func RunWorker(args []string) error {
+	if len(args) != 0 {
+		return errWorkerUsage
+	}
+
+	cfg := config.NewConfig()
+	repo := repository.NewRepository(cfg)
+	worker, err := NewWorker(cfg, repo)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	worker.Run(ctx)
+	return nil
+}
+
+// NewWorker returns a worker running every job of the application.
+func NewWorker(cfg *config.Config, repo repository.Repository) (*job.Worker, error) {
+	worker := job.NewWorker(repo, cfg.JobInterval, cfg.JobConcurrency, cfg.JobTimeout, cfg.ShutdownTimeout)
+	if err := job.SchedulePruning(worker, cfg.JobRetention); err != nil {
+		return nil, err
+	}
+	job.HandleSMS(worker, sms.NewSender(cfg), cfg.Keyring)
+
+	return worker, nil
+}